@@ -0,0 +1,883 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"sentinel-agent/internal/apps"
+	"sentinel-agent/internal/checks"
+	"sentinel-agent/internal/cleanup"
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/discovery"
+	"sentinel-agent/internal/logship"
+	"sentinel-agent/internal/snmptrap"
+	"sentinel-agent/internal/spool"
+	"sentinel-agent/internal/state"
+	"sentinel-agent/internal/tasks"
+	"sentinel-agent/internal/utils"
+)
+
+// maxReplayPerHeartbeat bounds how much spooled data is sent after each
+// successful heartbeat so catching up never delays collection for long.
+const maxReplayPerHeartbeat = 20
+
+type agent struct {
+	cfg      *config.Config
+	client   *client.APIClient
+	events   *collector.EventBuffer
+	pipeline *pipelineStats
+	queue    *sendQueue
+	sinks    []*sink
+	chaos    *chaos
+	quiet    *quietHours
+	burst    *burstMode
+	anomaly  *collector.AnomalyDetector
+	// maintenance is the downtime schedule synced from the backend.
+	maintenance *maintenanceSchedule
+	// desiredInterval is the interval the backend asked for, if any.
+	desiredInterval atomic.Int64
+	// collectErrors gathers the failures of the heartbeat being built.
+	collectErrors []client.CollectorError
+
+	system     *collector.SystemCollector
+	suspend    *collector.SuspendWatcher
+	hostname   *collector.HostnameResolver
+	facts      *collector.FactsCollector
+	network    *collector.NetworkCollector
+	traceroute *collector.TracerouteCollector
+	publicIP   *collector.PublicIPCollector
+	neighbors  *collector.NeighborCollector
+	vpn        *collector.VPNCollector
+	tcpStats   *collector.TCPStatsCollector
+	procTCP    *collector.ProcessTCPCollector
+	topTalkers *collector.TopTalkersCollector
+	firewall   *collector.FirewallCollector
+	security   *collector.SecurityCollector
+	compliance *collector.ComplianceCollector
+	windows    *collector.WindowsCollector
+	wmiQueries *collector.WMIQueryCollector
+	domain     *collector.DomainCollector
+	sessions   *collector.SessionCollector
+	authKeys   *collector.AuthorizedKeysCollector
+	localUsers *collector.LocalUsersCollector
+	sysctl     *collector.SysctlCollector
+	watchFiles *collector.FileWatchCollector
+	hardware   *collector.HardwareCollector
+	macOS      *collector.MacOSCollector
+
+	certificates  *collector.CertificateCollector
+	scheduledJobs *collector.ScheduledJobsCollector
+	kernel        *collector.KernelWatcher
+	crashes       *collector.CrashCollector
+	accounting    *collector.AccountingCollector
+	quotas        *collector.QuotaCollector
+	diskUsage     *collector.DiskUsageCollector
+	networkMounts *collector.NetworkMountCollector
+	lvm           *collector.LVMCollector
+	power         *collector.PowerCollector
+
+	apps    *apps.Collector
+	scraper *apps.Scraper
+
+	docker *collector.DockerCollector
+	checks *checks.Runner
+	// remediation restarts units when their checks go down.
+	remediation *remediator
+	cluster     *clusterMember
+
+	tasks   *tasks.Runner
+	cleanup *cleanup.Cleaner
+	scripts *tasks.Library
+	spool   *spool.Spool
+	logs    *logship.Shipper
+	traps   *snmptrap.Receiver
+
+	sequence *utils.Sequence
+	state    *state.Store
+}
+
+func newAgent(cfg *config.Config, apiClient *client.APIClient, hostID string) (*agent, error) {
+	sequence, err := utils.OpenSequence(utils.SequenceFile(cfg.HostIDFile))
+	if err != nil {
+		return nil, err
+	}
+	keys := utils.KeySource{KeyFile: cfg.Crypto.KeyFile, FallbackFile: utils.HostKeySecretFile(cfg.HostIDFile)}
+	if (cfg.StateEncrypt || cfg.Spool.Enabled && cfg.Spool.Encrypt || cfg.Logs.Enabled && cfg.Logs.Encrypt) && !keys.Protected() {
+		log.Printf("Warning: no hardware key material and no crypto.key_file; local data is encrypted with a secret stored beside it, so a stolen disk or backup can be decrypted")
+	}
+	var cipher state.Cipher
+	if cfg.StateEncrypt {
+		key, err := keys.Key("state")
+		if err != nil {
+			return nil, err
+		}
+		cipher = utils.Cipher(key)
+	}
+	store, err := state.Open(cfg.StateFile, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	events := collector.NewEventBuffer()
+	a := &agent{
+		cfg:      cfg,
+		client:   apiClient,
+		events:   events,
+		pipeline: newPipelineStats(),
+		queue:    newSendQueue(cfg.SendQueue.Size, cfg.SendQueue.Workers),
+		sinks:    newSinks(cfg, apiClient, hostID),
+		quiet:    newQuietHours(cfg.QuietHours, events),
+
+		maintenance: newMaintenanceSchedule(store.Bucket("maintenance"), events),
+		system:      collector.NewSystemCollector(),
+		suspend:     collector.NewSuspendWatcher(events),
+		hostname:    collector.NewHostnameResolver(cfg.Hostname, events),
+		network:     collector.NewNetworkCollector(events),
+
+		sequence: sequence,
+		state:    store,
+	}
+
+	if cfg.FactsDir != "" || len(cfg.FactsCmd) > 0 {
+		a.facts = collector.NewFactsCollector(cfg.FactsDir, cfg.FactsCmd, time.Duration(cfg.FactsInterval)*time.Second)
+	}
+	if cfg.Traceroute.Enabled {
+		a.traceroute = collector.NewTracerouteCollector(cfg.Traceroute)
+	}
+	if cfg.PublicIP.Enabled {
+		a.publicIP = collector.NewPublicIPCollector(cfg.PublicIP, events)
+	}
+	if cfg.Neighbors.Enabled {
+		a.neighbors = collector.NewNeighborCollector(cfg.Neighbors)
+	}
+	if cfg.VPN.Enabled {
+		a.vpn = collector.NewVPNCollector(cfg.VPN, events)
+	}
+	if cfg.TCPStats.Enabled {
+		a.tcpStats = collector.NewTCPStatsCollector()
+	}
+	if cfg.ProcessTCP.Enabled {
+		a.procTCP = collector.NewProcessTCPCollector(cfg.ProcessTCP)
+	}
+	if cfg.TopTalkers.Enabled {
+		a.topTalkers = collector.NewTopTalkersCollector(cfg.TopTalkers)
+	}
+	if cfg.Firewall.Enabled {
+		a.firewall = collector.NewFirewallCollector(cfg.Firewall, events)
+	}
+	if cfg.Security.Enabled {
+		a.security = collector.NewSecurityCollector(cfg.Security, events)
+	}
+	if cfg.Compliance.Enabled {
+		compliance, err := collector.NewComplianceCollector(cfg.Compliance)
+		if err != nil {
+			return nil, err
+		}
+		a.compliance = compliance
+	}
+	if cfg.Windows.Enabled {
+		a.windows = collector.NewWindowsCollector(cfg.Windows)
+	}
+	if cfg.AuthorizedKeys.Enabled {
+		a.authKeys = collector.NewAuthorizedKeysCollector(cfg.AuthorizedKeys, events, store.Bucket("authorized_keys"))
+	}
+	if cfg.LocalUsers.Enabled {
+		a.localUsers = collector.NewLocalUsersCollector(cfg.LocalUsers, events, store.Bucket("local_users"))
+	}
+	if cfg.Sysctl.Enabled {
+		a.sysctl = collector.NewSysctlCollector(cfg.Sysctl, events, store.Bucket("sysctl"))
+	}
+	if cfg.WatchFiles.Enabled {
+		a.watchFiles, err = collector.NewFileWatchCollector(cfg.WatchFiles, events, store.Bucket("watch_files"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Hardware.Enabled {
+		a.hardware = collector.NewHardwareCollector(cfg.Hardware)
+	}
+	if cfg.Sessions.Enabled {
+		a.sessions = collector.NewSessionCollector(events)
+	}
+	if cfg.Domain.Enabled {
+		a.domain = collector.NewDomainCollector(cfg.Domain, events)
+	}
+	if len(cfg.WMIQueries) > 0 {
+		a.wmiQueries = collector.NewWMIQueryCollector(cfg.WMIQueries)
+	}
+	if cfg.MacOS.Enabled {
+		a.macOS = collector.NewMacOSCollector()
+	}
+	if cfg.Certificates.Enabled {
+		a.certificates = collector.NewCertificateCollector(cfg.Certificates, events)
+	}
+	if cfg.ScheduledJobs.Enabled {
+		a.scheduledJobs = collector.NewScheduledJobsCollector(cfg.ScheduledJobs, events)
+	}
+	if cfg.KernelEvents.Enabled {
+		a.kernel = collector.NewKernelWatcher(events)
+	}
+	if cfg.Crashes.Enabled {
+		a.crashes = collector.NewCrashCollector(cfg.Crashes, events)
+	}
+	if cfg.Anomalies.Enabled {
+		a.anomaly = collector.NewAnomalyDetector(cfg.Anomalies, store.Bucket("anomalies"))
+	}
+	if cfg.Burst.Enabled {
+		a.burst = newBurstMode(cfg.Burst, events)
+	}
+	if cfg.Accounting.Enabled {
+		a.accounting = collector.NewAccountingCollector(cfg.Accounting)
+	}
+	if cfg.Quotas.Enabled {
+		a.quotas = collector.NewQuotaCollector(cfg.Quotas)
+	}
+	if cfg.DiskUsage.Enabled {
+		a.diskUsage = collector.NewDiskUsageCollector(cfg.DiskUsage)
+	}
+	if cfg.NetworkMounts.Enabled {
+		a.networkMounts = collector.NewNetworkMountCollector(cfg.NetworkMounts, events)
+	}
+	if cfg.LVM.Enabled {
+		a.lvm = collector.NewLVMCollector(cfg.LVM, events)
+	}
+	if cfg.Power.Battery || cfg.Power.Energy || len(cfg.Power.UPS) > 0 {
+		a.power = collector.NewPowerCollector(cfg.Power, events)
+	}
+	if len(cfg.Apps) > 0 {
+		a.apps, err = apps.NewCollector(cfg.Apps)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.PrometheusScrape) > 0 {
+		a.scraper = apps.NewScraper(cfg.PrometheusScrape)
+	}
+	checkConfigs := cfg.Checks
+	if cfg.Discovery.Enabled {
+		discovered, err := discovery.DiscoverServices(cfg.Discovery, cfg.Checks)
+		if err != nil {
+			log.Printf("Service discovery failed: %v", err)
+		}
+		for _, c := range discovered {
+			log.Printf("Discovered %s, monitoring %s", c.Name, c.Target)
+		}
+		checkConfigs = append(checkConfigs, discovered...)
+	}
+	if cfg.Docker.Enabled {
+		a.docker, err = collector.NewDockerCollector(cfg.Docker, events)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(checkConfigs) > 0 || (cfg.Docker.Enabled && cfg.Docker.LabelChecks) {
+		runner, err := checks.NewRunner(checkConfigs, store.Bucket("checks"), events)
+		if err != nil {
+			return nil, err
+		}
+		a.checks = runner
+	}
+	if cfg.Cluster.Name != "" {
+		runner, err := checks.NewRunner(cfg.Cluster.Checks, store.Bucket("cluster_checks"), events)
+		if err != nil {
+			return nil, err
+		}
+		a.cluster = newClusterMember(cfg.Cluster.Name, runner, events, time.Duration(cfg.Interval)*time.Second)
+	}
+	if cfg.ScriptLibrary.Enabled {
+		library, err := tasks.NewLibrary(cfg.ScriptLibrary.Dir, cfg.ScriptLibrary.ManifestPath,
+			cfg.ScriptLibrary.PublicKey, apiClient.Get)
+		if err != nil {
+			return nil, err
+		}
+		a.scripts = library
+	}
+	if cfg.Spool.Enabled {
+		var key []byte
+		if cfg.Spool.Encrypt {
+			if key, err = keys.Key("spool"); err != nil {
+				return nil, err
+			}
+		}
+		sp, err := spool.New(cfg.Spool.Dir, cfg.Spool.MaxEntries, key)
+		if err != nil {
+			return nil, err
+		}
+		a.spool = sp
+	}
+	if cfg.Logs.Enabled {
+		var key []byte
+		if cfg.Logs.Encrypt {
+			if key, err = keys.Key("logs"); err != nil {
+				return nil, err
+			}
+		}
+		shipper, err := logship.New(cfg.Logs, a.sendLogs, store.Bucket("logs"), events, key)
+		if err != nil {
+			return nil, err
+		}
+		a.logs = shipper
+	}
+	if cfg.SNMPTraps.Enabled {
+		a.traps = snmptrap.NewReceiver(cfg.SNMPTraps, events)
+	}
+	if cfg.Cleanup.Enabled {
+		a.cleanup = cleanup.New(cfg.Cleanup)
+	}
+	if cfg.Remediation.Enabled {
+		a.remediation = newRemediator(cfg.Remediation, events)
+	}
+	if cfg.Tasks.Enabled {
+		runner, err := tasks.NewRunner(cfg.Tasks, hostID, a.scripts, store.Bucket("tasks"))
+		if err != nil {
+			return nil, err
+		}
+		if a.cleanup != nil {
+			runner.SetCleaner(a.cleanup)
+		}
+		a.tasks = runner
+	}
+
+	return a, nil
+}
+
+func (a *agent) sendHeartbeat(ctx context.Context) {
+	a.chaos.delay(ctx)
+	a.pipeline.collected()
+	a.collectErrors = nil
+	metrics, err := a.system.Collect(ctx)
+	if err != nil {
+		// Everything else is still worth sending.
+		a.collectorError("system", "collecting system metrics", err)
+		metrics = &collector.SystemMetrics{Errors: map[string]error{"cpu": err, "memory": err, "disk": err}}
+		metrics.Hostname, _ = os.Hostname()
+	} else {
+		sections := make([]string, 0, len(metrics.Errors))
+		for section := range metrics.Errors {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+		for _, section := range sections {
+			a.collectorError("system."+section, "collecting "+section+" metrics", metrics.Errors[section])
+		}
+	}
+
+	networkInfo, err := a.network.Collect(ctx)
+	if err != nil {
+		a.collectorError("network", "collecting network info", err)
+	}
+	if networkInfo != nil && a.cfg.Profile == config.ProfileMinimal {
+		// Primary IP/MAC and the default route are enough for small gateways.
+		networkInfo.Interfaces = nil
+		if networkInfo.Routes != nil {
+			networkInfo.Routes.StaticRoutes = nil
+		}
+	}
+
+	seq, err := a.sequence.Next()
+	if err != nil {
+		log.Printf("Error persisting heartbeat sequence: %v", err)
+	}
+
+	heartbeat := client.Heartbeat{
+		ID:           utils.NewUUID(),
+		Sequence:     seq,
+		Hostname:     a.hostname.Resolve(metrics.Hostname),
+		AgentVersion: Version,
+		AgentStatus:  "running",
+		Uptime:       metrics.Uptime,
+		CollectedAt:  time.Now().UTC(),
+		Network:      networkInfo,
+		Metrics:      metricsPayload(metrics),
+	}
+	heartbeat.QuietHours = a.quiet.check(heartbeat.CollectedAt)
+	heartbeat.Maintenance = a.maintenance.check(heartbeat.CollectedAt)
+	if a.anomaly != nil {
+		heartbeat.Anomalies = a.anomaly.Observe(anomalyValues(heartbeat.Metrics))
+	}
+	heartbeat.Burst = a.burst.check(heartbeat.CollectedAt, heartbeat.Metrics, heartbeat.Maintenance)
+	if heartbeat.Burst != nil {
+		heartbeat.Processes, err = a.burst.processes.Collect(ctx)
+		if err != nil {
+			a.collectorError("processes", "listing processes", err)
+		}
+	}
+
+	if a.facts != nil {
+		heartbeat.Facts, err = a.facts.Collect(ctx)
+		if err != nil {
+			a.collectorError("facts", "collecting custom facts", err)
+		}
+	}
+
+	if a.traceroute != nil {
+		heartbeat.Traceroute, err = a.traceroute.Collect(ctx)
+		if err != nil {
+			a.collectorError("traceroute", "running traceroute probes", err)
+		}
+	}
+
+	if a.publicIP != nil {
+		heartbeat.PublicIP, err = a.publicIP.Collect(ctx)
+		if err != nil {
+			a.collectorError("publicIP", "detecting public IP", err)
+		}
+	}
+
+	if a.neighbors != nil {
+		heartbeat.Neighbors, err = a.neighbors.Collect(ctx)
+		if err != nil {
+			a.collectorError("neighbors", "collecting neighbor table", err)
+		}
+	}
+
+	if a.vpn != nil {
+		heartbeat.VPN, err = a.vpn.Collect(ctx)
+		if err != nil {
+			a.collectorError("vpn", "collecting VPN status", err)
+		}
+	}
+
+	if a.tcpStats != nil {
+		heartbeat.TCPStats, err = a.tcpStats.Collect(ctx)
+		if err != nil {
+			a.collectorError("tcpStats", "collecting TCP counters", err)
+		}
+	}
+
+	if a.procTCP != nil {
+		heartbeat.ProcessTCP, err = a.procTCP.Collect(ctx)
+		if err != nil {
+			a.collectorError("procTCP", "collecting per-process TCP usage", err)
+		}
+	}
+
+	if a.topTalkers != nil {
+		heartbeat.TopTalkers, err = a.topTalkers.Collect(ctx)
+		if err != nil {
+			a.collectorError("topTalkers", "collecting connection summary", err)
+		}
+	}
+
+	if a.firewall != nil {
+		heartbeat.Firewall, err = a.firewall.Collect(ctx)
+		if err != nil {
+			a.collectorError("firewall", "collecting firewall status", err)
+		}
+	}
+
+	if a.security != nil {
+		heartbeat.Security, err = a.security.Collect(ctx)
+		if err != nil {
+			a.collectorError("security", "collecting security posture", err)
+		}
+	}
+
+	if a.compliance != nil {
+		heartbeat.Compliance, err = a.compliance.Collect(ctx)
+		if err != nil {
+			a.collectorError("compliance", "running compliance checks", err)
+		}
+	}
+
+	if a.windows != nil {
+		heartbeat.Windows, err = a.windows.Collect(ctx)
+		if err != nil {
+			a.collectorError("windows", "collecting Windows counters", err)
+		}
+	}
+	if a.wmiQueries != nil {
+		heartbeat.WMIQueries = a.wmiQueries.Collect(ctx)
+	}
+	if a.sessions != nil {
+		heartbeat.Sessions, err = a.sessions.Collect(ctx)
+		if err != nil {
+			a.collectorError("sessions", "collecting user sessions", err)
+		}
+	}
+	if a.authKeys != nil {
+		heartbeat.AuthorizedKeys, err = a.authKeys.Collect(ctx)
+		if err != nil {
+			a.collectorError("authKeys", "collecting authorized SSH keys", err)
+		}
+	}
+	if a.localUsers != nil {
+		heartbeat.LocalUsers, err = a.localUsers.Collect(ctx)
+		if err != nil {
+			a.collectorError("localUsers", "collecting local users", err)
+		}
+	}
+	if a.sysctl != nil {
+		heartbeat.Sysctl, err = a.sysctl.Collect(ctx)
+		if err != nil {
+			a.collectorError("sysctl", "reading kernel parameters", err)
+		}
+	}
+	if a.watchFiles != nil {
+		heartbeat.WatchedFiles, err = a.watchFiles.Collect(ctx)
+		if err != nil {
+			a.collectorError("watchFiles", "checking watched files", err)
+		}
+	}
+	if a.hardware != nil {
+		heartbeat.Hardware, err = a.hardware.Collect(ctx)
+		if err != nil {
+			a.collectorError("hardware", "collecting hardware inventory", err)
+		}
+	}
+	if a.domain != nil {
+		heartbeat.Domain, err = a.domain.Collect(ctx)
+		if err != nil {
+			a.collectorError("domain", "collecting domain status", err)
+		}
+	}
+
+	if a.macOS != nil {
+		heartbeat.MacOS, err = a.macOS.Collect(ctx)
+		if err != nil {
+			a.collectorError("macOS", "collecting macOS metrics", err)
+		}
+	}
+
+	if a.certificates != nil {
+		heartbeat.Certificates, err = a.certificates.Collect(ctx)
+		if err != nil {
+			a.collectorError("certificates", "scanning certificates", err)
+		}
+	}
+
+	if a.scheduledJobs != nil {
+		heartbeat.ScheduledJobs, err = a.scheduledJobs.Collect(ctx)
+		if err != nil {
+			a.collectorError("scheduledJobs", "collecting scheduled jobs", err)
+		}
+	}
+
+	if a.kernel != nil {
+		heartbeat.Kernel, err = a.kernel.Collect(ctx)
+		if err != nil {
+			a.collectorError("kernel", "collecting kernel error counters", err)
+		}
+	}
+
+	if a.crashes != nil {
+		if err := a.crashes.Poll(ctx); err != nil {
+			a.collectorError("crashes", "checking for crashes", err)
+		}
+	}
+
+	if a.accounting != nil {
+		heartbeat.Accounting, err = a.accounting.Collect(ctx)
+		if err != nil {
+			a.collectorError("accounting", "collecting per-user usage", err)
+		}
+	}
+
+	if a.quotas != nil {
+		heartbeat.Quotas, err = a.quotas.Collect(ctx)
+		if err != nil {
+			a.collectorError("quotas", "collecting disk quotas", err)
+		}
+	}
+
+	if a.diskUsage != nil {
+		var diskPercent float64
+		if heartbeat.Metrics.Disk != nil {
+			diskPercent = heartbeat.Metrics.Disk.UsagePercent
+		}
+		heartbeat.DiskUsage, err = a.diskUsage.Collect(ctx, diskPercent)
+		if err != nil {
+			a.collectorError("diskUsage", "scanning disk usage", err)
+		}
+	}
+
+	if a.networkMounts != nil {
+		heartbeat.NetworkMounts, err = a.networkMounts.Collect(ctx)
+		if err != nil {
+			a.collectorError("networkMounts", "checking network mounts", err)
+		}
+	}
+
+	if a.lvm != nil {
+		heartbeat.LVM, err = a.lvm.Collect(ctx)
+		if err != nil {
+			a.collectorError("lvm", "collecting LVM metrics", err)
+		}
+	}
+
+	if a.power != nil {
+		heartbeat.Power, err = a.power.Collect(ctx)
+		if err != nil {
+			a.collectorError("power", "collecting power metrics", err)
+		}
+	}
+
+	if a.apps != nil {
+		heartbeat.Apps = a.apps.Collect(ctx)
+	}
+	if a.scraper != nil {
+		heartbeat.PrometheusScrape = a.scraper.Collect(ctx)
+	}
+
+	if a.docker != nil {
+		heartbeat.Docker, err = a.docker.Collect(ctx)
+		if err != nil {
+			a.collectorError("docker", "collecting containers", err)
+		} else if a.checks != nil && a.cfg.Docker.LabelChecks {
+			a.checks.Replace("docker", a.docker.LabelChecks())
+		}
+	}
+
+	if a.checks != nil {
+		heartbeat.Checks = a.checks.Collect(ctx)
+		if a.remediation != nil {
+			a.remediation.handle(ctx, heartbeat.Checks)
+		}
+	}
+	if a.cluster != nil {
+		heartbeat.Cluster = a.cluster.name
+		heartbeat.ClusterChecks = a.cluster.collect(ctx, time.Now())
+	}
+
+	// Collections cut short by shutdown would report bogus failures; keep
+	// the pending events for the next run instead.
+	if ctx.Err() != nil {
+		log.Printf("Shutting down, discarding partially collected heartbeat")
+		return
+	}
+
+	if a.logs != nil {
+		heartbeat.LogShipper = a.logs.Stats()
+	}
+
+	heartbeat.CollectorErrors = a.collectErrors
+	heartbeat.Events = a.events.Drain()
+	if a.tasks != nil {
+		heartbeat.TaskResults = a.tasks.DrainResults()
+	}
+	if a.cleanup != nil {
+		heartbeat.Cleanup = a.cleanup.DrainReports()
+	}
+
+	if err := a.state.Flush(); err != nil {
+		log.Printf("Error saving agent state: %v", err)
+	}
+
+	a.enqueue(heartbeat)
+}
+
+// resumed handles a resume from suspend: rates measured across the suspend
+// would be meaningless, and the backend should hear from the host right
+// away rather than a full interval after wake.
+func (a *agent) resumed(ctx context.Context, suspended time.Duration) {
+	log.Printf("System resumed after about %s suspended", suspended)
+	if a.procTCP != nil {
+		a.procTCP.Rebaseline()
+	}
+	if a.tcpStats != nil {
+		a.tcpStats.Rebaseline()
+	}
+	if a.accounting != nil {
+		a.accounting.Rebaseline()
+	}
+	if a.power != nil {
+		a.power.Rebaseline()
+	}
+	a.sendHeartbeat(ctx)
+}
+
+// collectorError logs a collection failure, reports it in the heartbeat
+// being built and counts it for the status endpoint.
+func (a *agent) collectorError(name, what string, err error) {
+	log.Printf("Error %s: %v", what, err)
+	a.collectErrors = append(a.collectErrors, client.CollectorError{Collector: name, Error: err.Error()})
+	a.pipeline.collectorError(name)
+}
+
+// metricsPayload converts the system metrics, leaving failed sections null
+// so the backend does not read them as zero usage.
+func metricsPayload(metrics *collector.SystemMetrics) client.MetricsPayload {
+	var payload client.MetricsPayload
+	if metrics.Errors["cpu"] == nil {
+		payload.CPU = &client.CPUMetrics{
+			Usage:     metrics.CPU.Usage,
+			Cores:     metrics.CPU.Cores,
+			Model:     metrics.CPU.Model,
+			LoadAvg1:  metrics.CPU.LoadAvg1,
+			LoadAvg5:  metrics.CPU.LoadAvg5,
+			LoadAvg15: metrics.CPU.LoadAvg15,
+		}
+	}
+	if metrics.Errors["memory"] == nil {
+		payload.Memory = &client.MemoryMetrics{
+			Total:        metrics.Memory.Total,
+			Used:         metrics.Memory.Used,
+			Available:    metrics.Memory.Available,
+			UsagePercent: metrics.Memory.UsagePercent,
+			SwapTotal:    metrics.Memory.SwapTotal,
+			SwapUsed:     metrics.Memory.SwapUsed,
+		}
+	}
+	if metrics.Errors["disk"] == nil {
+		payload.Disk = &client.DiskMetrics{
+			Total:        metrics.Disk.Total,
+			Used:         metrics.Disk.Used,
+			Available:    metrics.Disk.Available,
+			UsagePercent: metrics.Disk.UsagePercent,
+			MountPoint:   metrics.Disk.MountPoint,
+		}
+	}
+	return payload
+}
+
+// anomalyValues are the headline metrics tracked for anomalies, named
+// after their payload fields.
+func anomalyValues(metrics client.MetricsPayload) map[string]float64 {
+	values := make(map[string]float64)
+	if metrics.CPU != nil {
+		values["cpu.usage"] = metrics.CPU.Usage
+		values["cpu.loadAvg1"] = metrics.CPU.LoadAvg1
+	}
+	if metrics.Memory != nil {
+		values["memory.usagePercent"] = metrics.Memory.UsagePercent
+	}
+	if metrics.Disk != nil {
+		values["disk.usagePercent"] = metrics.Disk.UsagePercent
+	}
+	return values
+}
+
+func (a *agent) handleResponse(response *client.HeartbeatResponse) {
+	a.queue.responseMu.Lock()
+	defer a.queue.responseMu.Unlock()
+	// Concurrent sends and replays can all report the same conflict; only
+	// the first, for the ID still in use, replaces it.
+	if response.HostIDConflict && response.SentHostID == a.client.GetHostID() {
+		a.regenerateHostID()
+	}
+	if a.cluster != nil {
+		a.cluster.update(response, time.Now())
+	}
+	if response.MaintenanceWindows != nil {
+		a.maintenance.update(response.MaintenanceWindows, time.Now())
+	}
+	a.setDesiredInterval(response.DesiredInterval)
+	if len(response.Tasks) > 0 {
+		if a.tasks == nil {
+			log.Printf("Ignoring %d remote task(s): tasks are not enabled", len(response.Tasks))
+		} else {
+			a.tasks.Submit(response.Tasks)
+		}
+	}
+}
+
+// setDesiredInterval adopts the interval from a heartbeat response, clamped
+// to the configured bounds. Zero returns to the configured interval.
+func (a *agent) setDesiredInterval(seconds int) {
+	if seconds > 0 {
+		clamped := min(max(seconds, a.cfg.MinInterval), a.cfg.MaxInterval)
+		if clamped != seconds {
+			log.Printf("Backend requested a %ds heartbeat interval; using %ds within the configured bounds", seconds, clamped)
+		}
+		seconds = clamped
+	}
+	a.desiredInterval.Store(int64(time.Duration(seconds) * time.Second))
+}
+
+// heartbeatInterval is the interval the backend asked for, or else the
+// configured one, unless a maintenance window sets its own. Burst mode
+// shortens it further.
+func (a *agent) heartbeatInterval(now time.Time) time.Duration {
+	interval := time.Duration(a.desiredInterval.Load())
+	if interval == 0 {
+		interval = time.Duration(a.cfg.Interval) * time.Second
+	}
+	return a.burst.interval(now, a.maintenance.interval(now, interval))
+}
+
+func (a *agent) spoolHeartbeat(heartbeat client.Heartbeat) {
+	if a.spool == nil {
+		return
+	}
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		log.Printf("Error encoding heartbeat for spool: %v", err)
+		return
+	}
+	if err := a.spool.Push(a.chaos.corrupt(data)); err != nil {
+		log.Printf("Error spooling heartbeat: %v", err)
+	}
+}
+
+// sendLogs posts a log batch. Client errors other than timeouts and rate
+// limiting mean the backend will never accept the batch. It is not tied to
+// the shutdown context because the shipper flushes its buffer on Stop.
+func (a *agent) sendLogs(data []byte) error {
+	err := a.client.SendLogs(context.Background(), a.cfg.Logs.Path, data)
+	var status *client.StatusError
+	if errors.As(err, &status) && status.StatusCode >= 400 && status.StatusCode < 500 &&
+		status.StatusCode != http.StatusRequestTimeout && status.StatusCode != http.StatusTooManyRequests {
+		return &logship.PermanentError{Err: err}
+	}
+	return err
+}
+
+func (a *agent) replaySpool(ctx context.Context) {
+	if a.spool == nil {
+		return
+	}
+	sent, err := a.spool.Replay(maxReplayPerHeartbeat, func(data []byte) error {
+		var heartbeat client.Heartbeat
+		if err := json.Unmarshal(data, &heartbeat); err != nil {
+			log.Printf("Discarding malformed spooled heartbeat: %v", err)
+			return nil
+		}
+		heartbeat.Replayed = true
+		response, err := a.client.SendHeartbeat(ctx, heartbeat)
+		a.pipeline.sendResult(err)
+		if err != nil {
+			return err
+		}
+		a.handleResponse(response)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error replaying spooled heartbeats: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("Replayed %d spooled heartbeat(s)", sent)
+	}
+}
+
+// regenerateHostID resolves a duplicate host ID reported by the backend,
+// typically caused by hosts cloned from an image that already had one.
+func (a *agent) regenerateHostID() {
+	previous := a.client.GetHostID()
+	hostID, err := utils.RegenerateHostID(a.cfg.HostIDFile)
+	if err != nil {
+		log.Printf("Error regenerating duplicate host ID: %v", err)
+		return
+	}
+
+	a.client.SetHostID(hostID)
+	for _, s := range a.sinks {
+		s.client.SetHostID(hostID)
+	}
+	if a.tasks != nil {
+		a.tasks.SetHostID(hostID)
+	}
+	log.Printf("Backend reported host ID %s as duplicate; now using %s", previous, hostID)
+	a.events.Add("host_id_regenerated", collector.SeverityWarning,
+		fmt.Sprintf("Host ID %s was reported as a duplicate and replaced by %s", previous, hostID),
+		map[string]string{"previousHostId": previous, "hostId": hostID})
+}
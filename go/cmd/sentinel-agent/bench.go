@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/utils"
+)
+
+type benchCollector struct {
+	name    string
+	collect func(ctx context.Context) error
+}
+
+// benchCollectors lists the enabled collectors under the names they report
+// collection errors with.
+func (a *agent) benchCollectors() []benchCollector {
+	var collectors []benchCollector
+	add := func(name string, collect func(ctx context.Context) error) {
+		collectors = append(collectors, benchCollector{name, collect})
+	}
+	add("system", func(ctx context.Context) error {
+		_, err := a.system.Collect(ctx)
+		return err
+	})
+	add("network", func(ctx context.Context) error {
+		_, err := a.network.Collect(ctx)
+		return err
+	})
+	if a.facts != nil {
+		add("facts", func(ctx context.Context) error {
+			_, err := a.facts.Collect(ctx)
+			return err
+		})
+	}
+	if a.traceroute != nil {
+		add("traceroute", func(ctx context.Context) error {
+			_, err := a.traceroute.Collect(ctx)
+			return err
+		})
+	}
+	if a.publicIP != nil {
+		add("publicIP", func(ctx context.Context) error {
+			_, err := a.publicIP.Collect(ctx)
+			return err
+		})
+	}
+	if a.neighbors != nil {
+		add("neighbors", func(ctx context.Context) error {
+			_, err := a.neighbors.Collect(ctx)
+			return err
+		})
+	}
+	if a.vpn != nil {
+		add("vpn", func(ctx context.Context) error {
+			_, err := a.vpn.Collect(ctx)
+			return err
+		})
+	}
+	if a.tcpStats != nil {
+		add("tcpStats", func(ctx context.Context) error {
+			_, err := a.tcpStats.Collect(ctx)
+			return err
+		})
+	}
+	if a.procTCP != nil {
+		add("procTCP", func(ctx context.Context) error {
+			_, err := a.procTCP.Collect(ctx)
+			return err
+		})
+	}
+	if a.topTalkers != nil {
+		add("topTalkers", func(ctx context.Context) error {
+			_, err := a.topTalkers.Collect(ctx)
+			return err
+		})
+	}
+	if a.firewall != nil {
+		add("firewall", func(ctx context.Context) error {
+			_, err := a.firewall.Collect(ctx)
+			return err
+		})
+	}
+	if a.security != nil {
+		add("security", func(ctx context.Context) error {
+			_, err := a.security.Collect(ctx)
+			return err
+		})
+	}
+	if a.compliance != nil {
+		add("compliance", func(ctx context.Context) error {
+			_, err := a.compliance.Collect(ctx)
+			return err
+		})
+	}
+	if a.windows != nil {
+		add("windows", func(ctx context.Context) error {
+			_, err := a.windows.Collect(ctx)
+			return err
+		})
+	}
+	if a.wmiQueries != nil {
+		add("wmiQueries", func(ctx context.Context) error {
+			a.wmiQueries.Collect(ctx)
+			return nil
+		})
+	}
+	if a.sessions != nil {
+		add("sessions", func(ctx context.Context) error {
+			_, err := a.sessions.Collect(ctx)
+			return err
+		})
+	}
+	if a.authKeys != nil {
+		add("authKeys", func(ctx context.Context) error {
+			_, err := a.authKeys.Collect(ctx)
+			return err
+		})
+	}
+	if a.localUsers != nil {
+		add("localUsers", func(ctx context.Context) error {
+			_, err := a.localUsers.Collect(ctx)
+			return err
+		})
+	}
+	if a.sysctl != nil {
+		add("sysctl", func(ctx context.Context) error {
+			_, err := a.sysctl.Collect(ctx)
+			return err
+		})
+	}
+	if a.watchFiles != nil {
+		add("watchFiles", func(ctx context.Context) error {
+			_, err := a.watchFiles.Collect(ctx)
+			return err
+		})
+	}
+	if a.hardware != nil {
+		add("hardware", func(ctx context.Context) error {
+			_, err := a.hardware.Collect(ctx)
+			return err
+		})
+	}
+	if a.domain != nil {
+		add("domain", func(ctx context.Context) error {
+			_, err := a.domain.Collect(ctx)
+			return err
+		})
+	}
+	if a.macOS != nil {
+		add("macOS", func(ctx context.Context) error {
+			_, err := a.macOS.Collect(ctx)
+			return err
+		})
+	}
+	if a.certificates != nil {
+		add("certificates", func(ctx context.Context) error {
+			_, err := a.certificates.Collect(ctx)
+			return err
+		})
+	}
+	if a.scheduledJobs != nil {
+		add("scheduledJobs", func(ctx context.Context) error {
+			_, err := a.scheduledJobs.Collect(ctx)
+			return err
+		})
+	}
+	if a.kernel != nil {
+		add("kernel", func(ctx context.Context) error {
+			_, err := a.kernel.Collect(ctx)
+			return err
+		})
+	}
+	if a.crashes != nil {
+		add("crashes", func(ctx context.Context) error {
+			return a.crashes.Poll(ctx)
+		})
+	}
+	if a.accounting != nil {
+		add("accounting", func(ctx context.Context) error {
+			_, err := a.accounting.Collect(ctx)
+			return err
+		})
+	}
+	if a.quotas != nil {
+		add("quotas", func(ctx context.Context) error {
+			_, err := a.quotas.Collect(ctx)
+			return err
+		})
+	}
+	if a.diskUsage != nil {
+		add("diskUsage", func(ctx context.Context) error {
+			a.diskUsage.Scan(ctx)
+			return nil
+		})
+	}
+	if a.networkMounts != nil {
+		add("networkMounts", func(ctx context.Context) error {
+			_, err := a.networkMounts.Collect(ctx)
+			return err
+		})
+	}
+	if a.lvm != nil {
+		add("lvm", func(ctx context.Context) error {
+			_, err := a.lvm.Collect(ctx)
+			return err
+		})
+	}
+	if a.power != nil {
+		add("power", func(ctx context.Context) error {
+			_, err := a.power.Collect(ctx)
+			return err
+		})
+	}
+	if a.burst != nil {
+		add("processes", func(ctx context.Context) error {
+			_, err := a.burst.processes.Collect(ctx)
+			return err
+		})
+	}
+	if a.apps != nil {
+		add("apps", func(ctx context.Context) error {
+			a.apps.Collect(ctx)
+			return nil
+		})
+	}
+	if a.scraper != nil {
+		add("scraper", func(ctx context.Context) error {
+			a.scraper.Collect(ctx)
+			return nil
+		})
+	}
+	if a.docker != nil {
+		add("docker", func(ctx context.Context) error {
+			_, err := a.docker.Collect(ctx)
+			return err
+		})
+	}
+	if a.checks != nil {
+		add("checks", func(ctx context.Context) error {
+			a.checks.Collect(ctx)
+			return nil
+		})
+	}
+	return collectors
+}
+
+type benchResult struct {
+	runs, errors  int
+	wall, maxWall time.Duration
+	cpu           time.Duration
+	allocs, bytes uint64
+	lastErr       error
+}
+
+// runBench runs every enabled collector a number of times and reports what
+// each costs, to help decide which collectors a constrained host can afford.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	lenient := lenientFlag(fs)
+	runs := fs.Int("n", 5, "Runs per collector")
+	only := fs.String("only", "", "Comma-separated collectors to run (default: all enabled)")
+	fs.Parse(args)
+
+	if *runs < 1 {
+		return fmt.Errorf("-n must be at least 1")
+	}
+	cfg, err := config.Load(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+	hostID, err := utils.GetOrCreateHostID(cfg.HostIDFile)
+	if err != nil {
+		return err
+	}
+	// Keep the running agent's state, sequence and spool untouched.
+	dir, err := os.MkdirTemp("", "sentinel-bench")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	cfg.HostIDFile = dir + "/host-id"
+	cfg.StateFile = dir + "/state.json"
+	cfg.Spool.Enabled = false
+
+	a, err := newAgent(cfg, client.NewFromConfig(cfg, hostID), hostID)
+	if err != nil {
+		return err
+	}
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(*only, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selected[name] = true
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "collector\truns\terrors\twall avg\twall max\tcpu avg\tallocs avg\tbytes avg\t")
+	ctx := context.Background()
+	for _, c := range a.benchCollectors() {
+		if len(selected) > 0 && !selected[c.name] {
+			continue
+		}
+		r := benchCollect(ctx, self, c, *runs)
+		n := time.Duration(r.runs)
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%d\t%d\t\n", c.name, r.runs, r.errors,
+			(r.wall / n).Round(time.Microsecond), r.maxWall.Round(time.Microsecond),
+			(r.cpu / n).Round(time.Microsecond), r.allocs/uint64(r.runs), r.bytes/uint64(r.runs))
+		if r.lastErr != nil {
+			defer fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, r.lastErr)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\nCollectors with their own interval do the work only on the first run; see wall max.")
+	fmt.Println("CPU time counts the agent process, not the commands collectors run.")
+	return nil
+}
+
+func benchCollect(ctx context.Context, self *process.Process, c benchCollector, runs int) benchResult {
+	var r benchResult
+	var before, after runtime.MemStats
+	for i := 0; i < runs; i++ {
+		runtime.ReadMemStats(&before)
+		cpuBefore := processCPU(self)
+		start := time.Now()
+		err := c.collect(ctx)
+		wall := time.Since(start)
+		cpuAfter := processCPU(self)
+		runtime.ReadMemStats(&after)
+
+		r.runs++
+		r.wall += wall
+		r.maxWall = max(r.maxWall, wall)
+		r.cpu += cpuAfter - cpuBefore
+		r.allocs += after.Mallocs - before.Mallocs
+		r.bytes += after.TotalAlloc - before.TotalAlloc
+		if err != nil {
+			r.errors++
+			r.lastErr = err
+		}
+	}
+	return r
+}
+
+func processCPU(p *process.Process) time.Duration {
+	times, err := p.Times()
+	if err != nil {
+		return 0
+	}
+	return time.Duration((times.User + times.System) * float64(time.Second))
+}
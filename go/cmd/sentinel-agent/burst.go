@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+)
+
+// burstMode raises heartbeat frequency and detail for a while after a
+// threshold breach. It is only used from the collection loop.
+type burstMode struct {
+	cfg       config.BurstConfig
+	events    *collector.EventBuffer
+	processes *collector.ProcessListCollector
+
+	until  time.Time
+	reason string
+}
+
+func newBurstMode(cfg config.BurstConfig, events *collector.EventBuffer) *burstMode {
+	return &burstMode{cfg: cfg, events: events, processes: collector.NewProcessListCollector()}
+}
+
+// check extends burst mode on a breach and returns its state for the
+// heartbeat, or nil when it is off. b may be nil.
+func (b *burstMode) check(now time.Time, metrics client.MetricsPayload, maintenance bool) *client.BurstInfo {
+	if b == nil {
+		return nil
+	}
+	if reason := b.breach(metrics); reason != "" && !maintenance {
+		if !b.active(now) {
+			log.Printf("Burst mode on for %d minute(s): %s", b.cfg.Duration, reason)
+			b.events.Add("burst_started", collector.SeverityWarning, "Burst mode started: "+reason,
+				map[string]string{"reason": reason})
+		}
+		b.until = now.Add(time.Duration(b.cfg.Duration) * time.Minute)
+		b.reason = reason
+	} else if !b.until.IsZero() && !b.active(now) {
+		log.Printf("Burst mode off")
+		b.events.Add("burst_ended", collector.SeverityInfo, "Burst mode ended", nil)
+		b.until = time.Time{}
+	}
+	if !b.active(now) {
+		return nil
+	}
+	return &client.BurstInfo{Reason: b.reason, Until: b.until}
+}
+
+func (b *burstMode) active(now time.Time) bool {
+	return now.Before(b.until)
+}
+
+// breach describes the thresholds metrics reach, if any.
+func (b *burstMode) breach(metrics client.MetricsPayload) string {
+	var reasons []string
+	if metrics.CPU != nil && b.cfg.CPUPercent > 0 && metrics.CPU.Usage >= b.cfg.CPUPercent {
+		reasons = append(reasons, fmt.Sprintf("CPU %.1f%%", metrics.CPU.Usage))
+	}
+	if metrics.Memory != nil && b.cfg.MemoryPercent > 0 && metrics.Memory.UsagePercent >= b.cfg.MemoryPercent {
+		reasons = append(reasons, fmt.Sprintf("memory %.1f%%", metrics.Memory.UsagePercent))
+	}
+	if metrics.Disk != nil && b.cfg.DiskPercent > 0 && metrics.Disk.UsagePercent >= b.cfg.DiskPercent {
+		reasons = append(reasons, fmt.Sprintf("disk %.1f%%", metrics.Disk.UsagePercent))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// interval shortens base while burst mode is on. b may be nil.
+func (b *burstMode) interval(now time.Time, base time.Duration) time.Duration {
+	if b == nil || !b.active(now) {
+		return base
+	}
+	return min(base, time.Duration(b.cfg.Interval)*time.Second)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chaosEnv enables fault injection for resilience testing, e.g.
+// SENTINEL_AGENT_CHAOS="drop_sends=0.2,corrupt_spool=0.1,delay_collectors=5s".
+// It is deliberately not a documented flag.
+const chaosEnv = "SENTINEL_AGENT_CHAOS"
+
+// chaos injects failures so operators can watch spooling, retries and
+// backend alerting work before relying on them.
+type chaos struct {
+	dropSends       float64
+	corruptSpool    float64
+	delayCollectors time.Duration
+}
+
+func parseChaos(spec string) (*chaos, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	c := &chaos{}
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: expected key=value, got %q", chaosEnv, field)
+		}
+		var err error
+		switch key {
+		case "drop_sends":
+			c.dropSends, err = parseFraction(value)
+		case "corrupt_spool":
+			c.corruptSpool, err = parseFraction(value)
+		case "delay_collectors":
+			c.delayCollectors, err = time.ParseDuration(value)
+		default:
+			err = fmt.Errorf("unknown fault")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", chaosEnv, key, err)
+		}
+	}
+	return c, nil
+}
+
+func parseFraction(value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || f > 1 {
+		return 0, fmt.Errorf("must be between 0 and 1")
+	}
+	return f, nil
+}
+
+func (c *chaos) String() string {
+	return fmt.Sprintf("drop_sends=%g corrupt_spool=%g delay_collectors=%s", c.dropSends, c.corruptSpool, c.delayCollectors)
+}
+
+// dropSend reports whether a heartbeat send should fail. c may be nil.
+func (c *chaos) dropSend() error {
+	if c != nil && rand.Float64() < c.dropSends {
+		return fmt.Errorf("chaos: send dropped")
+	}
+	return nil
+}
+
+// corrupt truncates a spool entry so its replay fails to decode.
+func (c *chaos) corrupt(data []byte) []byte {
+	if c != nil && rand.Float64() < c.corruptSpool {
+		return data[:len(data)/2]
+	}
+	return data
+}
+
+// delay holds up collection as a slow collector would.
+func (c *chaos) delay(ctx context.Context) {
+	if c == nil || c.delayCollectors <= 0 {
+		return
+	}
+	select {
+	case <-time.After(c.delayCollectors):
+	case <-ctx.Done():
+	}
+}
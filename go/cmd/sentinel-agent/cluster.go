@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/checks"
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/collector"
+)
+
+// clusterMember runs cluster-wide checks while the backend has granted this
+// host the cluster lease. Leadership lapses when the lease is not renewed,
+// e.g. while the backend is unreachable, so a partitioned former leader
+// stops checking before the backend hands the lease to another member.
+type clusterMember struct {
+	name   string
+	checks *checks.Runner
+	events *collector.EventBuffer
+
+	defaultLease time.Duration
+
+	// mu guards the lease, which send workers update while the collection
+	// loop reads it.
+	mu         sync.Mutex
+	leaseUntil time.Time
+	leader     bool
+}
+
+func newClusterMember(name string, runner *checks.Runner, events *collector.EventBuffer, interval time.Duration) *clusterMember {
+	return &clusterMember{
+		name:         name,
+		checks:       runner,
+		events:       events,
+		defaultLease: 3 * interval,
+	}
+}
+
+// collect returns the cluster check results if this host currently leads.
+func (m *clusterMember) collect(ctx context.Context, now time.Time) []checks.Result {
+	m.mu.Lock()
+	if m.leader && now.After(m.leaseUntil) {
+		m.setLeader(false, "lease expired")
+	}
+	leader := m.leader
+	m.mu.Unlock()
+	if !leader {
+		return nil
+	}
+	return m.checks.Collect(ctx)
+}
+
+func (m *clusterMember) update(response *client.HeartbeatResponse, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !response.ClusterLeader {
+		if m.leader {
+			m.setLeader(false, "leadership moved to another member")
+		}
+		return
+	}
+	lease := m.defaultLease
+	if response.ClusterLeaseSeconds > 0 {
+		lease = time.Duration(response.ClusterLeaseSeconds) * time.Second
+	}
+	m.leaseUntil = now.Add(lease)
+	if !m.leader {
+		m.setLeader(true, "elected by backend")
+	}
+}
+
+func (m *clusterMember) setLeader(leader bool, reason string) {
+	m.leader = leader
+	message := fmt.Sprintf("Became leader of cluster %s (%s)", m.name, reason)
+	if !leader {
+		message = fmt.Sprintf("No longer leader of cluster %s (%s)", m.name, reason)
+	}
+	log.Print(message)
+	m.events.Add("cluster_leader_changed", collector.SeverityInfo, message,
+		map[string]string{"cluster": m.name, "leader": fmt.Sprint(leader)})
+}
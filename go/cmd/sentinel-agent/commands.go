@@ -0,0 +1,22 @@
+package main
+
+import "flag"
+
+// subcommands are dispatched on the first argument; anything else falls
+// through to the regular agent flags.
+var subcommands = map[string]func(args []string) error{
+	"launchd":       runLaunchd,
+	"enroll":        runEnroll,
+	"hostid":        runHostID,
+	"prepare-image": runPrepareImage,
+	"record":        runRecord,
+	"mock-server":   runMockServer,
+	"bench":         runBench,
+	"config":        runConfig,
+	"replay":        runReplay,
+}
+
+// lenientFlag adds -lenient to a command that reads the config file.
+func lenientFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("lenient", false, "Ignore unknown keys in the configuration file")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/cryptopolicy"
+	"sentinel-agent/internal/utils"
+)
+
+// applyCryptoPolicy must run before any HTTP client is created: in FIPS mode
+// the default transport, used by the API client and tasks, and the
+// transports of checks and collectors are restricted to approved TLS
+// settings.
+func applyCryptoPolicy(cfg config.CryptoConfig) error {
+	if err := utils.SetHashAlgorithm(cfg.Hash); err != nil {
+		return err
+	}
+	if !cfg.FIPS {
+		return nil
+	}
+
+	cryptopolicy.EnableFIPS()
+	if cryptopolicy.Boring {
+		log.Printf("FIPS mode enabled (BoringCrypto module)")
+	} else {
+		log.Printf("FIPS mode enabled: TLS restricted to approved algorithms; build with 'make build-fips' for a validated crypto module")
+	}
+	return nil
+}
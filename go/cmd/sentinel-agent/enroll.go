@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/utils"
+)
+
+func runEnroll(args []string) error {
+	fs := flag.NewFlagSet("enroll", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	lenient := lenientFlag(fs)
+	token := fs.String("token", "", "Enrollment token (overrides enrollment_token)")
+	endpoint := fs.String("endpoint", "", "API endpoint (overrides api_endpoint)")
+	fs.Parse(args)
+
+	cfg, err := config.Parse(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+	if *token != "" {
+		cfg.EnrollmentToken = *token
+	}
+	if *endpoint != "" {
+		cfg.APIEndpoint = *endpoint
+	}
+	if cfg.EnrollmentToken == "" {
+		return fmt.Errorf("no enrollment token: pass -token or set enrollment_token")
+	}
+	if cfg.APIEndpoint == "" {
+		return fmt.Errorf("no endpoint: pass -endpoint or set api_endpoint")
+	}
+	if cfg.APIEndpoint == config.EndpointAuto {
+		cfg.APIEndpoint = discoverEndpoint(cfg.APIEndpointHosts, 5*time.Second)
+	}
+
+	if err := enroll(cfg); err != nil {
+		return err
+	}
+	fmt.Printf("Enrolled in organization %s; credentials saved to %s\n", cfg.OrganizationSlug, cfg.CredentialsFile)
+	return nil
+}
+
+// enroll exchanges the enrollment token for credentials, persists them and
+// the assigned host ID, and updates cfg in place.
+func enroll(cfg *config.Config) error {
+	hostname, _ := os.Hostname()
+	request := client.EnrollRequest{
+		Token:        cfg.EnrollmentToken,
+		Hostname:     hostname,
+		Fingerprint:  utils.GetMachineFingerprint(),
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		AgentVersion: Version,
+	}
+	if data, err := os.ReadFile(cfg.HostIDFile); err == nil {
+		request.HostID = strings.TrimSpace(string(data))
+	}
+
+	response, err := client.Enroll(context.Background(), cfg.APIEndpoint, request)
+	if err != nil {
+		return fmt.Errorf("enrollment failed: %w", err)
+	}
+
+	creds := config.Credentials{
+		APIEndpoint:      response.APIEndpoint,
+		OrganizationSlug: response.OrganizationSlug,
+		APIKey:           response.APIKey,
+	}
+	if err := config.SaveCredentials(cfg.CredentialsFile, creds); err != nil {
+		return err
+	}
+	if response.HostID != "" {
+		if err := utils.SaveHostID(cfg.HostIDFile, response.HostID); err != nil {
+			return err
+		}
+	}
+
+	cfg.OrganizationSlug = response.OrganizationSlug
+	cfg.APIKey = response.APIKey
+	if response.APIEndpoint != "" {
+		cfg.APIEndpoint = response.APIEndpoint
+	}
+	log.Printf("Enrolled in organization %s", cfg.OrganizationSlug)
+	return nil
+}
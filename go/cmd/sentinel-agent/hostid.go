@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/utils"
+)
+
+func runHostID(args []string) error {
+	fs := flag.NewFlagSet("hostid", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	lenient := lenientFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sentinel-agent hostid [flags] show|regenerate\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected show or regenerate")
+	}
+
+	cfg, err := config.Parse(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "show":
+		data, err := os.ReadFile(cfg.HostIDFile)
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.TrimSpace(string(data)))
+	case "regenerate":
+		hostID, err := utils.RegenerateHostID(cfg.HostIDFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("New host ID: %s\nRestart the agent to apply it.\n", hostID)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", fs.Arg(0))
+	}
+	return nil
+}
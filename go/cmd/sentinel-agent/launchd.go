@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"text/template"
+)
+
+const (
+	launchdLabel     = "com.sentinel.agent"
+	launchdPlistPath = "/Library/LaunchDaemons/com.sentinel.agent.plist"
+)
+
+var launchdPlist = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{ xml .Label }}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{ xml .Binary }}</string>
+		<string>-config</string>
+		<string>{{ xml .Config }}</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>StandardOutPath</key>
+	<string>{{ xml .LogFile }}</string>
+	<key>StandardErrorPath</key>
+	<string>{{ xml .LogFile }}</string>
+</dict>
+</plist>
+`))
+
+func runLaunchd(args []string) error {
+	fs := flag.NewFlagSet("launchd", flag.ExitOnError)
+	binary := fs.String("binary", "/usr/local/bin/sentinel-agent", "Path to the agent binary")
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	logFile := fs.String("log", "/var/log/sentinel-agent.log", "Path to the agent log file")
+	printOnly := fs.Bool("print", false, "Print the plist instead of installing it")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sentinel-agent launchd [flags] install|uninstall\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected install or uninstall")
+	}
+
+	switch fs.Arg(0) {
+	case "install":
+		var plist bytes.Buffer
+		err := launchdPlist.Execute(&plist, map[string]string{
+			"Label":   launchdLabel,
+			"Binary":  *binary,
+			"Config":  *configPath,
+			"LogFile": *logFile,
+		})
+		if err != nil {
+			return err
+		}
+		if *printOnly {
+			_, err := os.Stdout.Write(plist.Bytes())
+			return err
+		}
+		if runtime.GOOS != "darwin" {
+			return fmt.Errorf("launchd is only available on macOS (use -print to generate the plist)")
+		}
+		if err := os.WriteFile(launchdPlistPath, plist.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", launchdPlistPath, err)
+		}
+		// bootout first so reinstalling picks up a changed plist.
+		exec.Command("launchctl", "bootout", "system/"+launchdLabel).Run()
+		if out, err := exec.Command("launchctl", "bootstrap", "system", launchdPlistPath).CombinedOutput(); err != nil {
+			return fmt.Errorf("launchctl bootstrap failed: %v: %s", err, out)
+		}
+		fmt.Printf("Installed and started %s\n", launchdPlistPath)
+		return nil
+
+	case "uninstall":
+		if runtime.GOOS != "darwin" {
+			return fmt.Errorf("launchd is only available on macOS")
+		}
+		exec.Command("launchctl", "bootout", "system/"+launchdLabel).Run()
+		if err := os.Remove(launchdPlistPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", launchdPlistPath, err)
+		}
+		fmt.Printf("Removed %s\n", launchdPlistPath)
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown launchd action %q", fs.Arg(0))
+	}
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -10,8 +11,9 @@ import (
 	"time"
 
 	"sentinel-agent/internal/client"
-	"sentinel-agent/internal/collector"
 	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/discovery"
+	"sentinel-agent/internal/sandbox"
 	"sentinel-agent/internal/utils"
 )
 
@@ -21,8 +23,19 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	configPath := flag.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version information")
+	lenient := lenientFlag(flag.CommandLine)
 	flag.Parse()
 
 	if *showVersion {
@@ -33,92 +46,175 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Printf("Starting Sentinel Agent v%s", Version)
 
-	cfg, err := config.Load(*configPath)
+	cfg, err := config.Load(*configPath, config.Options{Lenient: *lenient})
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if cfg.Profile == config.ProfileMinimal {
+		log.Printf("Minimal profile active: heavy collectors disabled")
+	}
+	applyResourceLimits(cfg.Resources)
+	if err := applyCryptoPolicy(cfg.Crypto); err != nil {
+		log.Fatalf("Failed to apply crypto policy: %v", err)
+	}
+
+	if cfg.APIEndpoint == config.EndpointAuto {
+		cfg.APIEndpoint = discoverEndpoint(cfg.APIEndpointHosts, time.Duration(cfg.Interval)*time.Second)
+	}
+
+	if cfg.OrganizationSlug == "" {
+		for {
+			err := enroll(cfg)
+			if err == nil {
+				break
+			}
+			log.Printf("%v; retrying in %d seconds", err, cfg.Interval)
+			time.Sleep(time.Duration(cfg.Interval) * time.Second)
+		}
+	}
+
 	hostID, err := utils.GetOrCreateHostID(cfg.HostIDFile)
 	if err != nil {
 		log.Fatalf("Failed to get host ID: %v", err)
 	}
 	log.Printf("Host ID: %s", hostID)
 
-	apiClient := client.New(cfg.APIEndpoint, cfg.OrganizationSlug, cfg.APIKey, hostID)
-
-	sysCollector := collector.NewSystemCollector()
-	netCollector := collector.NewNetworkCollector()
-
-	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
-	defer ticker.Stop()
+	apiClient := client.NewFromConfig(cfg, hostID)
+	if err := apiClient.Negotiate(context.Background()); err != nil {
+		log.Printf("%v (retrying with the next heartbeat)", err)
+	}
 
+	a, err := newAgent(cfg, apiClient, hostID)
+	if err != nil {
+		log.Fatalf("Failed to initialize collectors: %v", err)
+	}
+	if a.chaos, err = parseChaos(os.Getenv(chaosEnv)); err != nil {
+		log.Fatalf("Invalid fault injection settings: %v", err)
+	}
+	if a.chaos != nil {
+		log.Printf("WARNING: fault injection active (%s); do not run like this in production", a.chaos)
+	}
+	if a.kernel != nil {
+		if err := a.kernel.Start(); err != nil {
+			log.Printf("Kernel event monitoring disabled: %v", err)
+			a.kernel = nil
+		}
+	}
+	// Listeners are bound before the sandbox drops privileges, as the
+	// default syslog and trap ports are privileged.
+	if a.logs != nil {
+		a.logs.Listen()
+	}
+	if a.traps != nil {
+		if err := a.traps.Listen(); err != nil {
+			log.Printf("SNMP trap receiver disabled: %v", err)
+			a.traps = nil
+		}
+	}
+	if sandboxRequested(cfg.Security.Sandbox) {
+		if err := sandbox.Apply(cfg.Security.Sandbox, writableDirs(cfg), removableDirs(cfg)); err != nil {
+			log.Fatalf("Failed to apply sandbox: %v", err)
+		}
+	}
+	if a.tasks != nil {
+		a.tasks.Start()
+		defer a.tasks.Stop()
+	}
+	if a.logs != nil {
+		a.logs.Start()
+		defer func() {
+			a.logs.Stop()
+			if err := a.state.Flush(); err != nil {
+				log.Printf("Error saving agent state: %v", err)
+			}
+		}()
+	}
+	if a.traps != nil {
+		if err := a.traps.Start(); err != nil {
+			log.Printf("SNMP trap receiver disabled: %v", err)
+		} else {
+			defer a.traps.Stop()
+		}
+	}
+	if cfg.Status.Enabled {
+		status := newStatusServer(cfg.Status, a)
+		if err := status.Start(); err != nil {
+			log.Printf("Status endpoint disabled: %v", err)
+		} else {
+			log.Printf("Serving agent status on %s", cfg.Status.Listen)
+			defer status.Stop()
+		}
+	}
+	// ctx is canceled on SIGINT or SIGTERM, aborting in-flight collections
+	// and requests instead of waiting out their timeouts.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received signal %v, shutting down...", sig)
+		cancel()
+	}()
+
+	var urgent <-chan struct{}
+	if a.power != nil {
+		a.power.Start(ctx)
+		urgent = a.power.Urgent()
+	}
+
+	a.suspend.Start(ctx)
+	if a.scripts != nil {
+		a.scripts.Start(ctx, time.Duration(cfg.ScriptLibrary.Interval)*time.Second)
+	}
+	if a.cleanup != nil {
+		a.cleanup.Start(ctx)
+	}
+
+	interval := time.Duration(cfg.Interval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	log.Printf("Agent started. Sending heartbeats every %d seconds to %s", cfg.Interval, cfg.APIEndpoint)
 
-	sendHeartbeat(apiClient, sysCollector, netCollector)
+	a.startSenders(ctx)
+	defer a.stopSenders()
+
+	a.sendHeartbeat(ctx)
+
+	current := interval
+	for ctx.Err() == nil {
+		// The backend, maintenance windows and burst mode may change the
+		// interval.
+		if next := a.heartbeatInterval(time.Now()); next != current {
+			log.Printf("Heartbeat interval changed to %s", next)
+			current = next
+			ticker.Reset(current)
+		}
 
-	for {
 		select {
 		case <-ticker.C:
-			sendHeartbeat(apiClient, sysCollector, netCollector)
-		case sig := <-sigChan:
-			log.Printf("Received signal %v, shutting down...", sig)
-			return
+			a.sendHeartbeat(ctx)
+		case <-urgent:
+			a.sendHeartbeat(ctx)
+		case suspended := <-a.suspend.Resumed():
+			a.resumed(ctx, suspended)
+			ticker.Reset(current)
+		case <-ctx.Done():
 		}
 	}
 }
 
-func sendHeartbeat(apiClient *client.APIClient, sysCollector *collector.SystemCollector, netCollector *collector.NetworkCollector) {
-	metrics, err := sysCollector.Collect()
-	if err != nil {
-		log.Printf("Error collecting system metrics: %v", err)
-		return
-	}
-
-	networkInfo, err := netCollector.Collect()
-	if err != nil {
-		log.Printf("Error collecting network info: %v", err)
-	}
-
-	heartbeat := client.Heartbeat{
-		Hostname:     metrics.Hostname,
-		AgentVersion: Version,
-		AgentStatus:  "running",
-		Uptime:       metrics.Uptime,
-		Network:      networkInfo,
-		Metrics: client.MetricsPayload{
-			CPU: client.CPUMetrics{
-				Usage:      metrics.CPU.Usage,
-				Cores:      metrics.CPU.Cores,
-				Model:      metrics.CPU.Model,
-				LoadAvg1:   metrics.CPU.LoadAvg1,
-				LoadAvg5:   metrics.CPU.LoadAvg5,
-				LoadAvg15:  metrics.CPU.LoadAvg15,
-			},
-			Memory: client.MemoryMetrics{
-				Total:        metrics.Memory.Total,
-				Used:         metrics.Memory.Used,
-				Available:    metrics.Memory.Available,
-				UsagePercent: metrics.Memory.UsagePercent,
-				SwapTotal:    metrics.Memory.SwapTotal,
-				SwapUsed:     metrics.Memory.SwapUsed,
-			},
-			Disk: client.DiskMetrics{
-				Total:        metrics.Disk.Total,
-				Used:         metrics.Disk.Used,
-				Available:    metrics.Disk.Available,
-				UsagePercent: metrics.Disk.UsagePercent,
-				MountPoint:   metrics.Disk.MountPoint,
-			},
-		},
-	}
-
-	if err := apiClient.SendHeartbeat(heartbeat); err != nil {
-		log.Printf("Error sending heartbeat: %v", err)
-	} else {
-		log.Printf("Heartbeat sent successfully (CPU: %.1f%%, Memory: %.1f%%, Disk: %.1f%%)",
-			metrics.CPU.Usage, metrics.Memory.UsagePercent, metrics.Disk.UsagePercent)
+// discoverEndpoint blocks until a collector is found on the local network.
+func discoverEndpoint(hosts []string, retry time.Duration) string {
+	for {
+		endpoint, err := discovery.Discover(3*time.Second, hosts)
+		if err == nil {
+			log.Printf("Discovered Sentinel endpoint %s via %s", endpoint.URL, endpoint.Source)
+			return endpoint.URL
+		}
+		log.Printf("Endpoint discovery failed: %v; retrying in %s", err, retry)
+		time.Sleep(retry)
 	}
 }
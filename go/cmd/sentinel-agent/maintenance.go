@@ -0,0 +1,103 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/state"
+)
+
+// maintenanceSchedule holds the maintenance windows the backend sends with
+// heartbeat responses, persisted so they still apply after a restart while
+// the backend is unreachable.
+type maintenanceSchedule struct {
+	bucket *state.Bucket
+	events *collector.EventBuffer
+
+	mu      sync.Mutex
+	windows []client.MaintenanceWindow
+	// activeID is the window the last check found the host in.
+	activeID string
+}
+
+func newMaintenanceSchedule(bucket *state.Bucket, events *collector.EventBuffer) *maintenanceSchedule {
+	m := &maintenanceSchedule{bucket: bucket, events: events}
+	bucket.Get("windows", &m.windows)
+	return m
+}
+
+// update replaces the schedule, dropping windows that are already over.
+func (m *maintenanceSchedule) update(windows []client.MaintenanceWindow, now time.Time) {
+	var upcoming []client.MaintenanceWindow
+	for _, w := range windows {
+		if w.End.After(now) && w.End.After(w.Start) {
+			upcoming = append(upcoming, w)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(upcoming) != len(m.windows) {
+		log.Printf("Maintenance schedule updated: %d upcoming window(s)", len(upcoming))
+	}
+	m.windows = upcoming
+	if len(upcoming) == 0 {
+		m.bucket.Delete("windows")
+	} else if err := m.bucket.Put("windows", upcoming); err != nil {
+		log.Printf("Error saving maintenance schedule: %v", err)
+	}
+}
+
+// current returns the window now falls in, if any.
+func (m *maintenanceSchedule) current(now time.Time) *client.MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.windows {
+		if w := m.windows[i]; !now.Before(w.Start) && now.Before(w.End) {
+			return &w
+		}
+	}
+	return nil
+}
+
+// check reports whether now is within a maintenance window, emitting an
+// event whenever the host enters or leaves one.
+func (m *maintenanceSchedule) check(now time.Time) bool {
+	w := m.current(now)
+	id := ""
+	if w != nil {
+		id = w.ID
+		if id == "" {
+			id = w.Start.Format(time.RFC3339)
+		}
+	}
+
+	m.mu.Lock()
+	previous := m.activeID
+	m.activeID = id
+	m.mu.Unlock()
+
+	switch {
+	case id == previous:
+	case id == "":
+		log.Printf("Maintenance window %s ended", previous)
+		m.events.Add("maintenance_ended", collector.SeverityInfo, "Maintenance window ended",
+			map[string]string{"window": previous})
+	default:
+		log.Printf("Maintenance window %s started, until %s", id, w.End.Format(time.RFC3339))
+		m.events.Add("maintenance_started", collector.SeverityInfo, "Maintenance window started",
+			map[string]string{"window": id, "end": w.End.Format(time.RFC3339)})
+	}
+	return w != nil
+}
+
+// interval returns the heartbeat interval to use at now.
+func (m *maintenanceSchedule) interval(now time.Time, base time.Duration) time.Duration {
+	if w := m.current(now); w != nil && w.Interval > 0 {
+		return time.Duration(w.Interval) * time.Second
+	}
+	return base
+}
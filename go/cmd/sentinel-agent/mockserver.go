@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"sentinel-agent/internal/client"
+)
+
+// mockServer implements enough of the backend API to run the agent end to
+// end: heartbeats, log batches and the capabilities handshake, with
+// optional latency and injected failures.
+type mockServer struct {
+	heartbeatPath string
+	logsPath      string
+	capabilities  bool
+	latency       time.Duration
+	failRate      float64
+	throttleRate  float64
+	interval      int
+	verbose       bool
+
+	mu         sync.Mutex
+	received   int
+	duplicates int
+	failed     int
+	throttled  int
+	logBatches int
+	seen       map[string]bool
+}
+
+func runMockServer(args []string) error {
+	fs := flag.NewFlagSet("mock-server", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+	s := &mockServer{seen: make(map[string]bool)}
+	fs.StringVar(&s.heartbeatPath, "heartbeat-path", "/api/v2/heartbeat", "Heartbeat endpoint path")
+	fs.StringVar(&s.logsPath, "logs-path", "/api/v2/logs", "Log batch endpoint path")
+	fs.BoolVar(&s.capabilities, "capabilities", false, "Answer the capabilities handshake instead of acting as a legacy backend")
+	fs.DurationVar(&s.latency, "latency", 0, "Delay before every response")
+	fs.Float64Var(&s.failRate, "fail-rate", 0, "Fraction of requests answered with 500")
+	fs.Float64Var(&s.throttleRate, "throttle-rate", 0, "Fraction of requests answered with 429")
+	fs.IntVar(&s.interval, "desired-interval", 0, "Heartbeat interval in seconds to request from agents")
+	fs.BoolVar(&s.verbose, "verbose", false, "Print every heartbeat")
+	fs.Parse(args)
+
+	if s.failRate < 0 || s.throttleRate < 0 || s.failRate+s.throttleRate > 1 {
+		return fmt.Errorf("-fail-rate and -throttle-rate must be between 0 and 1 in total")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.heartbeatPath, s.handleHeartbeat)
+	mux.HandleFunc(s.logsPath, s.handleLogs)
+	mux.HandleFunc("/api/v2/capabilities", s.handleCapabilities)
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	log.Printf("Mock backend listening on http://%s", *listen)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Printf("Received %d heartbeat(s) (%d duplicate), %d log batch(es); injected %d failure(s) and %d throttle(s)\n",
+		s.received, s.duplicates, s.logBatches, s.failed, s.throttled)
+	return nil
+}
+
+// inject applies the configured latency and decides whether the request
+// fails. It reports whether a response has been written.
+func (s *mockServer) inject(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return true
+	}
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	roll := rand.Float64()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case roll < s.failRate:
+		s.failed++
+		http.Error(w, "injected failure", http.StatusInternalServerError)
+		return true
+	case roll < s.failRate+s.throttleRate:
+		s.throttled++
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "injected throttle", http.StatusTooManyRequests)
+		return true
+	}
+	return false
+}
+
+func (s *mockServer) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if s.inject(w, r) {
+		return
+	}
+	var request client.HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var heartbeat client.Heartbeat
+	if err := json.Unmarshal(request.Heartbeat, &heartbeat); err != nil {
+		http.Error(w, "invalid heartbeat: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.received++
+	duplicate := heartbeat.ID != "" && s.seen[heartbeat.ID]
+	if duplicate {
+		s.duplicates++
+	}
+	s.seen[heartbeat.ID] = true
+	s.mu.Unlock()
+
+	if s.verbose {
+		var sections map[string]json.RawMessage
+		json.Unmarshal(request.Heartbeat, &sections)
+		note := ""
+		if duplicate {
+			note = ", duplicate"
+		}
+		log.Printf("Heartbeat #%d from %s (%s): %d bytes, %d sections, %d event(s), %d collector error(s)%s",
+			heartbeat.Sequence, heartbeat.Hostname, request.HostID, r.ContentLength, len(sections),
+			len(heartbeat.Events), len(heartbeat.CollectorErrors), note)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(client.HeartbeatResponse{Success: true, HostID: request.HostID, DesiredInterval: s.interval})
+}
+
+func (s *mockServer) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if s.inject(w, r) {
+		return
+	}
+	n, _ := io.Copy(io.Discard, r.Body)
+	s.mu.Lock()
+	s.logBatches++
+	s.mu.Unlock()
+	if s.verbose {
+		log.Printf("Log batch: %d bytes", n)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *mockServer) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if !s.capabilities {
+		http.NotFound(w, r)
+		return
+	}
+	// Advertise every section so nothing is stripped.
+	caps := client.Capabilities{SchemaVersion: client.SchemaVersion}
+	t := reflect.TypeOf(client.Heartbeat{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			caps.Features = append(caps.Features, name)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(caps)
+}
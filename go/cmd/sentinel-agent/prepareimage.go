@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/utils"
+)
+
+// runPrepareImage removes all per-host state so a VM template or AMI baked
+// with the agent gives every clone its own identity on first boot.
+func runPrepareImage(args []string) error {
+	fs := flag.NewFlagSet("prepare-image", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	lenient := lenientFlag(fs)
+	keepCredentials := fs.Bool("keep-credentials", false, "Keep enrollment credentials (all clones then share one API key)")
+	dryRun := fs.Bool("dry-run", false, "Only list what would be removed")
+	fs.Parse(args)
+
+	cfg, err := config.Parse(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+
+	paths := []string{
+		cfg.HostIDFile,
+		utils.SequenceFile(cfg.HostIDFile),
+		utils.HostKeySecretFile(cfg.HostIDFile),
+		cfg.StateFile,
+		cfg.Spool.Dir,
+		cfg.ScriptLibrary.Dir,
+		cfg.Logs.BufferDir,
+	}
+	if !*keepCredentials {
+		paths = append(paths, cfg.CredentialsFile)
+	}
+
+	fmt.Println("Stop the agent service before preparing an image.")
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("Would remove %s\n", path)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		fmt.Printf("Removed %s\n", path)
+	}
+	return nil
+}
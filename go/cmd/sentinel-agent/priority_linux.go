@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	ioprioClassBestEffort = 2
+	ioprioClassIdle       = 3
+	ioprioClassShift      = 13
+	ioprioWhoProcess      = 1
+)
+
+// setPriority applies nice and ionice settings. On Linux both are
+// per-thread, so every existing runtime thread is adjusted; threads created
+// later inherit the setting, as do collector subprocesses.
+func setPriority(cfg config.ResourcesConfig) error {
+	if cfg.Nice == 0 && cfg.IOClass == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+
+	ioprio := -1
+	switch cfg.IOClass {
+	case "best-effort":
+		ioprio = ioprioClassBestEffort<<ioprioClassShift | cfg.IOPriority
+	case "idle":
+		ioprio = ioprioClassIdle << ioprioClassShift
+	}
+
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if cfg.Nice != 0 {
+			if err := unix.Setpriority(unix.PRIO_PROCESS, tid, cfg.Nice); err != nil {
+				return fmt.Errorf("setpriority: %w", err)
+			}
+		}
+		if ioprio >= 0 {
+			if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio)); errno != 0 {
+				return fmt.Errorf("ioprio_set: %w", errno)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !windows
+
+package main
+
+import (
+	"syscall"
+
+	"sentinel-agent/internal/config"
+)
+
+// setPriority applies the nice value; io_class is Linux-only.
+func setPriority(cfg config.ResourcesConfig) error {
+	if cfg.Nice == 0 {
+		return nil
+	}
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, cfg.Nice)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"golang.org/x/sys/windows"
+
+	"sentinel-agent/internal/config"
+)
+
+var priorityClasses = map[string]uint32{
+	"idle":         windows.IDLE_PRIORITY_CLASS,
+	"below_normal": windows.BELOW_NORMAL_PRIORITY_CLASS,
+	"normal":       windows.NORMAL_PRIORITY_CLASS,
+	"above_normal": windows.ABOVE_NORMAL_PRIORITY_CLASS,
+	"high":         windows.HIGH_PRIORITY_CLASS,
+}
+
+func setPriority(cfg config.ResourcesConfig) error {
+	class, ok := priorityClasses[cfg.PriorityClass]
+	if !ok {
+		return nil
+	}
+	return windows.SetPriorityClass(windows.CurrentProcess(), class)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/cron"
+)
+
+type quietWindow struct {
+	schedule *cron.Schedule
+	duration time.Duration
+}
+
+// quietHours tracks the configured quiet windows and reports when the host
+// enters or leaves one.
+type quietHours struct {
+	location *time.Location
+	windows  []quietWindow
+	events   *collector.EventBuffer
+	active   bool
+}
+
+// newQuietHours returns nil without windows. The config has been validated.
+func newQuietHours(cfg config.QuietHoursConfig, events *collector.EventBuffer) *quietHours {
+	if len(cfg.Windows) == 0 {
+		return nil
+	}
+	q := &quietHours{location: time.Local, events: events}
+	if cfg.Timezone != "" {
+		q.location, _ = time.LoadLocation(cfg.Timezone)
+	}
+	for _, w := range cfg.Windows {
+		schedule, _ := cron.Parse(w.Schedule)
+		q.windows = append(q.windows, quietWindow{schedule: schedule, duration: time.Duration(w.Duration) * time.Minute})
+	}
+	return q
+}
+
+// check reports whether now is within a quiet window, emitting an event
+// on every transition. q may be nil.
+func (q *quietHours) check(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	active := q.within(now)
+	if active != q.active {
+		q.active = active
+		message := "Quiet hours ended"
+		if active {
+			message = "Quiet hours started"
+		}
+		log.Print(message)
+		q.events.Add("quiet_hours_changed", collector.SeverityInfo, message,
+			map[string]string{"active": strconv.FormatBool(active)})
+	}
+	return active
+}
+
+// within looks back over each window's duration for a minute in which its
+// schedule fired.
+func (q *quietHours) within(now time.Time) bool {
+	minute := now.In(q.location).Truncate(time.Minute)
+	for _, w := range q.windows {
+		for start := minute; minute.Sub(start) < w.duration; start = start.Add(-time.Minute) {
+			if w.schedule.Matches(start) {
+				return true
+			}
+		}
+	}
+	return false
+}
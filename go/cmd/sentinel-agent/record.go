@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/utils"
+)
+
+// runRecord collects heartbeats like the agent would but writes them to a
+// file, one JSON document per line, instead of sending them.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file")
+	lenient := lenientFlag(fs)
+	output := fs.String("output", "", "File to write the heartbeats to")
+	count := fs.Int("count", 10, "Number of heartbeats to record")
+	interval := fs.Duration("interval", 0, "Time between heartbeats (default: the configured interval)")
+	fs.Parse(args)
+
+	if *output == "" {
+		return fmt.Errorf("-output is required")
+	}
+	cfg, err := config.Load(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		*interval = time.Duration(cfg.Interval) * time.Second
+	}
+	hostID, err := utils.GetOrCreateHostID(cfg.HostIDFile)
+	if err != nil {
+		return err
+	}
+
+	// A running agent owns the state, sequence and spool files.
+	dir, err := os.MkdirTemp("", "sentinel-record")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	cfg.HostIDFile = filepath.Join(dir, "host-id")
+	cfg.StateFile = filepath.Join(dir, "state.json")
+	cfg.Spool.Enabled = false
+	if err := os.WriteFile(cfg.HostIDFile, []byte(hostID), 0600); err != nil {
+		return err
+	}
+
+	a, err := newAgent(cfg, client.NewFromConfig(cfg, hostID), hostID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	recorded := 0
+	for recorded < *count && ctx.Err() == nil {
+		if recorded > 0 {
+			select {
+			case <-time.After(*interval):
+			case <-ctx.Done():
+				continue
+			}
+		}
+		a.sendHeartbeat(ctx)
+		select {
+		case heartbeat := <-a.queue.heartbeats:
+			if err := enc.Encode(heartbeat); err != nil {
+				return err
+			}
+			recorded++
+			fmt.Fprintf(os.Stderr, "Recorded heartbeat %d/%d\n", recorded, *count)
+		default:
+			// Interrupted while collecting.
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// runReplay sends recorded heartbeats to a backend, e.g. a staging
+// environment, and reports how they were received.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := fs.String("config", "/etc/sentinel-agent/config.yaml", "Path to configuration file (for credentials and transport settings)")
+	lenient := lenientFlag(fs)
+	input := fs.String("input", "", "File written by record")
+	endpoint := fs.String("endpoint", "", "API endpoint (overrides api_endpoint)")
+	interval := fs.Duration("interval", 0, "Time between heartbeats")
+	repeat := fs.Int("repeat", 1, "Number of passes over the file")
+	fresh := fs.Bool("fresh", false, "Give every heartbeat a new ID and collection time")
+	fs.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("-input is required")
+	}
+	cfg, err := config.Parse(*configPath, config.Options{Lenient: *lenient})
+	if err != nil {
+		return err
+	}
+	if *endpoint != "" {
+		cfg.APIEndpoint = *endpoint
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	var heartbeats []client.Heartbeat
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var heartbeat client.Heartbeat
+		if err := dec.Decode(&heartbeat); err != nil {
+			return fmt.Errorf("%s: heartbeat %d: %w", *input, len(heartbeats)+1, err)
+		}
+		heartbeats = append(heartbeats, heartbeat)
+	}
+	if len(heartbeats) == 0 {
+		return fmt.Errorf("%s contains no heartbeats", *input)
+	}
+
+	hostID, err := utils.GetOrCreateHostID(cfg.HostIDFile)
+	if err != nil {
+		return err
+	}
+	apiClient := client.NewFromConfig(cfg, hostID)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var sent, failed int
+	var total time.Duration
+replay:
+	for pass := 0; pass < *repeat; pass++ {
+		for i, heartbeat := range heartbeats {
+			if ctx.Err() != nil {
+				break replay
+			}
+			if *fresh {
+				heartbeat.ID = utils.NewUUID()
+				heartbeat.CollectedAt = time.Now().UTC()
+			}
+			start := time.Now()
+			_, err := apiClient.SendHeartbeat(ctx, heartbeat)
+			elapsed := time.Since(start)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Heartbeat %d (pass %d): %v\n", i+1, pass+1, err)
+			} else {
+				sent++
+				total += elapsed
+			}
+			if *interval > 0 {
+				select {
+				case <-time.After(*interval):
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+
+	fmt.Printf("Sent %d heartbeat(s), %d failed", sent, failed)
+	if sent > 0 {
+		fmt.Printf(", average latency %s", (total / time.Duration(sent)).Round(time.Millisecond))
+	}
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d heartbeat(s) failed", failed)
+	}
+	return nil
+}
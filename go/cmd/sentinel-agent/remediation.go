@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/checks"
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+)
+
+const remediationTimeout = 90 * time.Second
+
+// remediationRule tracks the restarts of one rule for its rate limit.
+type remediationRule struct {
+	check      string
+	unit       string
+	maxPerHour int
+	cooldown   time.Duration
+
+	mu       sync.Mutex
+	restarts []time.Time
+	running  bool
+	// suppressed is set once a suppression was reported, until the next
+	// restart, so a long outage doesn't flood events.
+	suppressed bool
+}
+
+// remediator restarts allowlisted units when their checks go down.
+type remediator struct {
+	events *collector.EventBuffer
+	rules  []*remediationRule
+}
+
+func newRemediator(cfg config.RemediationConfig, events *collector.EventBuffer) *remediator {
+	r := &remediator{events: events}
+	for _, rc := range cfg.Rules {
+		rule := &remediationRule{check: rc.Check, unit: rc.RestartService, maxPerHour: rc.MaxPerHour, cooldown: time.Duration(rc.Cooldown) * time.Second}
+		if rule.maxPerHour == 0 {
+			rule.maxPerHour = 3
+		}
+		if rule.cooldown == 0 {
+			rule.cooldown = 2 * time.Minute
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r
+}
+
+// handle starts remediation for the rules whose check is down in results.
+// Restarts run in the background so heartbeats are not held up, and are
+// not tied to ctx: a restart already under way is not killed by shutdown.
+func (r *remediator) handle(ctx context.Context, results []checks.Result) {
+	if ctx.Err() != nil {
+		// Results cut short by shutdown are not real failures.
+		return
+	}
+	for _, result := range results {
+		if result.Status != checks.StatusDown {
+			continue
+		}
+		for _, rule := range r.rules {
+			if rule.check == result.Name {
+				r.remediate(rule, result, time.Now())
+			}
+		}
+	}
+}
+
+func (r *remediator) remediate(rule *remediationRule, result checks.Result, now time.Time) {
+	rule.mu.Lock()
+	defer rule.mu.Unlock()
+	if rule.running {
+		return
+	}
+
+	recent := rule.restarts[:0]
+	for _, at := range rule.restarts {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	rule.restarts = recent
+
+	attributes := map[string]string{"check": rule.check, "unit": rule.unit, "restartsLastHour": strconv.Itoa(len(recent))}
+	if len(recent) > 0 && now.Sub(recent[len(recent)-1]) < rule.cooldown {
+		return
+	}
+	if len(recent) >= rule.maxPerHour {
+		if !rule.suppressed {
+			rule.suppressed = true
+			log.Printf("Not restarting %s for check %s: %d restart(s) in the last hour", rule.unit, rule.check, len(recent))
+			r.events.Add("remediation_suppressed", collector.SeverityCritical,
+				fmt.Sprintf("Not restarting %s: limit of %d restart(s) per hour reached while %s is down", rule.unit, rule.maxPerHour, rule.check),
+				attributes)
+		}
+		return
+	}
+
+	rule.restarts = append(rule.restarts, now)
+	rule.running = true
+	rule.suppressed = false
+	attributes["reason"] = result.Message
+	log.Printf("Restarting %s: check %s is down (%s)", rule.unit, rule.check, result.Message)
+	r.events.Add("remediation_started", collector.SeverityWarning,
+		fmt.Sprintf("Restarting %s because %s is down", rule.unit, rule.check), attributes)
+
+	go func() {
+		err := r.restart(rule.unit)
+		rule.mu.Lock()
+		rule.running = false
+		rule.mu.Unlock()
+
+		done := map[string]string{"check": rule.check, "unit": rule.unit}
+		if err != nil {
+			log.Printf("Restarting %s failed: %v", rule.unit, err)
+			done["error"] = err.Error()
+			r.events.Add("remediation_failed", collector.SeverityCritical,
+				fmt.Sprintf("Restarting %s failed: %v", rule.unit, err), done)
+			return
+		}
+		log.Printf("Restarted %s", rule.unit)
+		r.events.Add("remediation_succeeded", collector.SeverityInfo,
+			fmt.Sprintf("Restarted %s", rule.unit), done)
+	}()
+}
+
+func (r *remediator) restart(unit string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remediationTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "systemctl", "restart", "--", unit).CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
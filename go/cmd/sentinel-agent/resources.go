@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+
+	"sentinel-agent/internal/config"
+)
+
+// applyResourceLimits bounds the agent's own CPU and memory use. GOMAXPROCS
+// and GOMEMLIMIT set in the environment take precedence over the config.
+func applyResourceLimits(cfg config.ResourcesConfig) {
+	if cfg.MaxProcs > 0 && os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(cfg.MaxProcs)
+		log.Printf("Limiting agent to %d CPU thread(s)", cfg.MaxProcs)
+	}
+	if cfg.MaxMemoryMB > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(int64(cfg.MaxMemoryMB) << 20)
+		log.Printf("Limiting agent memory to %d MiB", cfg.MaxMemoryMB)
+	}
+	if err := setPriority(cfg); err != nil {
+		log.Printf("Failed to set process priority: %v", err)
+	}
+	if cfg.Cgroup != "" {
+		if err := joinCgroup(cfg); err != nil {
+			log.Printf("Failed to place agent in cgroup %s: %v", cfg.Cgroup, err)
+		}
+	}
+}
+
+func sandboxRequested(cfg config.SandboxConfig) bool {
+	return cfg.User != "" || cfg.Landlock || cfg.Seccomp
+}
+
+// writableDirs lists the directories the agent writes to after startup.
+func writableDirs(cfg *config.Config) []string {
+	dirs := []string{filepath.Dir(cfg.HostIDFile), filepath.Dir(cfg.StateFile)}
+	if cfg.Spool.Enabled {
+		dirs = append(dirs, cfg.Spool.Dir)
+	}
+	if cfg.ScriptLibrary.Enabled {
+		dirs = append(dirs, cfg.ScriptLibrary.Dir)
+	}
+	if cfg.Logs.Enabled {
+		dirs = append(dirs, cfg.Logs.BufferDir)
+	}
+	if cfg.Tasks.Enabled {
+		dirs = append(dirs, cfg.Tasks.FetchDirs...)
+	}
+	return dirs
+}
+
+// removableDirs lists the directories the agent deletes files from. Unlike
+// writableDirs they are shared with other users, so the sandbox only grants
+// file removal below them.
+func removableDirs(cfg *config.Config) []string {
+	var dirs []string
+	if cfg.Cleanup.Enabled && !cfg.Cleanup.DryRun {
+		for _, rule := range cfg.Cleanup.Rules {
+			dirs = append(dirs, rule.Paths...)
+		}
+	}
+	return dirs
+}
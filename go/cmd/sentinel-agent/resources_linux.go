@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	cgroupRoot      = "/sys/fs/cgroup"
+	cgroupCPUPeriod = 100000
+)
+
+// joinCgroup creates a cgroup v2 group for the agent, applies the CPU quota
+// and memory ceiling, and moves the agent process into it. The GOMEMLIMIT
+// soft limit keeps the heap below memory.high so the kernel only throttles
+// as a last resort.
+func joinCgroup(cfg config.ResourcesConfig) error {
+	dir := filepath.Join(cgroupRoot, filepath.Clean("/"+cfg.Cgroup))
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if cfg.CPUQuotaPercent > 0 {
+		quota := cfg.CPUQuotaPercent * cgroupCPUPeriod / 100
+		if err := writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)); err != nil {
+			return err
+		}
+	}
+	if cfg.MaxMemoryMB > 0 {
+		// Leave headroom above the Go heap limit for stacks and child processes.
+		high := int64(cfg.MaxMemoryMB) << 21
+		if err := writeCgroupFile(dir, "memory.high", strconv.FormatInt(high, 10)); err != nil {
+			return err
+		}
+	}
+	return writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(os.Getpid()))
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+
+	"sentinel-agent/internal/config"
+)
+
+func joinCgroup(cfg config.ResourcesConfig) error {
+	return fmt.Errorf("cgroups are only supported on Linux")
+}
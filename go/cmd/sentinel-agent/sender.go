@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"sentinel-agent/internal/client"
+)
+
+// sendQueue decouples collection from delivery. Heartbeats are queued by
+// the collection loop and sent by a fixed number of workers, so a slow
+// backend never delays the next collection and slow sends overlap instead
+// of stacking up behind each other.
+type sendQueue struct {
+	heartbeats chan client.Heartbeat
+	workers    int
+	wg         sync.WaitGroup
+	// responseMu serializes response handling across workers.
+	responseMu sync.Mutex
+}
+
+func newSendQueue(size, workers int) *sendQueue {
+	return &sendQueue{heartbeats: make(chan client.Heartbeat, size), workers: workers}
+}
+
+// startSenders runs the send workers until stopSenders. Once ctx is
+// canceled, in-flight and queued heartbeats fail fast and are spooled.
+func (a *agent) startSenders(ctx context.Context) {
+	for i := 0; i < a.queue.workers; i++ {
+		a.queue.wg.Add(1)
+		go func() {
+			defer a.queue.wg.Done()
+			for heartbeat := range a.queue.heartbeats {
+				a.deliver(ctx, heartbeat)
+			}
+		}()
+	}
+}
+
+// stopSenders waits for the queued heartbeats to be sent or spooled. The
+// collection loop must not enqueue afterwards.
+func (a *agent) stopSenders() {
+	close(a.queue.heartbeats)
+	a.queue.wg.Wait()
+}
+
+// enqueue hands a heartbeat to the send workers. When every worker is
+// stuck and the queue is full, the heartbeat goes to the spool instead.
+func (a *agent) enqueue(heartbeat client.Heartbeat) {
+	select {
+	case a.queue.heartbeats <- heartbeat:
+	default:
+		log.Printf("Send queue full, spooling heartbeat")
+		a.pipeline.sendResult(fmt.Errorf("send queue full"))
+		a.spoolHeartbeat(heartbeat)
+	}
+}
+
+func (a *agent) deliver(ctx context.Context, heartbeat client.Heartbeat) {
+	var response *client.HeartbeatResponse
+	err := a.chaos.dropSend()
+	if err == nil {
+		response, err = a.client.SendHeartbeat(ctx, heartbeat)
+	}
+	a.pipeline.sendResult(err)
+	a.sendSinks(ctx, heartbeat)
+	if err != nil {
+		log.Printf("Error sending heartbeat: %v", err)
+		a.spoolHeartbeat(heartbeat)
+		return
+	}
+	if len(heartbeat.CollectorErrors) > 0 {
+		log.Printf("Heartbeat sent with %d collector error(s) (%s)", len(heartbeat.CollectorErrors), usageSummary(heartbeat.Metrics))
+	} else {
+		log.Printf("Heartbeat sent successfully (%s)", usageSummary(heartbeat.Metrics))
+	}
+
+	a.handleResponse(response)
+	a.replaySpool(ctx)
+}
+
+// usageSummary formats the headline metrics for the log, leaving out
+// sections that failed to collect.
+func usageSummary(metrics client.MetricsPayload) string {
+	cpu, memory, disk := "n/a", "n/a", "n/a"
+	if metrics.CPU != nil {
+		cpu = fmt.Sprintf("%.1f%%", metrics.CPU.Usage)
+	}
+	if metrics.Memory != nil {
+		memory = fmt.Sprintf("%.1f%%", metrics.Memory.UsagePercent)
+	}
+	if metrics.Disk != nil {
+		disk = fmt.Sprintf("%.1f%%", metrics.Disk.UsagePercent)
+	}
+	return fmt.Sprintf("CPU: %s, Memory: %s, Disk: %s", cpu, memory, disk)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"sentinel-agent/internal/client"
+	"sentinel-agent/internal/config"
+)
+
+// sink is an additional backend receiving some heartbeat sections, e.g. a
+// SIEM-facing endpoint for events and security findings, or an additional
+// organization receiving all of them.
+type sink struct {
+	name   string
+	client *client.APIClient
+}
+
+// mainOnlySections answer the main organization's requests, so additional
+// organizations never receive them.
+var mainOnlySections = []string{"taskResults", "cleanup"}
+
+// newSinks creates a client per sink. They share the main backend's
+// transport settings but not its credentials or request signing. The main
+// client stops sending sections that a sink takes exclusively, and so do
+// additional organizations.
+func newSinks(cfg *config.Config, main *client.APIClient, hostID string) []*sink {
+	var sinks []*sink
+	var exclusive []string
+	for _, sc := range cfg.Sinks {
+		sinkCfg := *cfg
+		sinkCfg.APIEndpoint = sc.APIEndpoint
+		sinkCfg.OrganizationSlug = sc.OrganizationSlug
+		sinkCfg.APIKey = sc.APIKey
+		sinkCfg.Headers = sc.Headers
+		sinkCfg.AWSSigV4 = config.AWSSigV4Config{}
+		if sc.HeartbeatPath != "" {
+			sinkCfg.HeartbeatPath = sc.HeartbeatPath
+		}
+		c := client.NewFromConfig(&sinkCfg, hostID)
+		c.Route(sc.Sections, nil)
+		sinks = append(sinks, &sink{name: sc.Name, client: c})
+		if sc.Exclusive {
+			exclusive = append(exclusive, sc.Sections...)
+		}
+	}
+	main.Route(nil, exclusive)
+
+	for _, org := range cfg.Organizations {
+		orgCfg := *cfg
+		orgCfg.OrganizationSlug = org.OrganizationSlug
+		orgCfg.APIKey = org.APIKey
+		orgCfg.Headers = org.Headers
+		orgCfg.AWSSigV4 = config.AWSSigV4Config{}
+		if org.APIEndpoint != "" {
+			orgCfg.APIEndpoint = org.APIEndpoint
+		}
+		c := client.NewFromConfig(&orgCfg, hostID)
+		var include []string
+		if len(org.Sections) > 0 {
+			include = org.Sections
+		}
+		c.Route(include, append(append([]string(nil), exclusive...), mainOnlySections...))
+		sinks = append(sinks, &sink{name: "organization " + org.OrganizationSlug, client: c})
+	}
+	return sinks
+}
+
+// sendSinks delivers the heartbeat to every sink, including additional
+// organizations. Sinks are best effort: failures are logged but not
+// spooled, so an organization misses the heartbeats sent while it is
+// unreachable.
+func (a *agent) sendSinks(ctx context.Context, heartbeat client.Heartbeat) {
+	for _, s := range a.sinks {
+		if _, err := s.client.SendHeartbeat(ctx, heartbeat); err != nil {
+			log.Printf("Error sending heartbeat to sink %s: %v", s.name, err)
+		}
+	}
+}
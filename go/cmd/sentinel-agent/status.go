@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+// pipelineStats counts what the heartbeat pipeline did since startup.
+type pipelineStats struct {
+	mu          sync.Mutex
+	started     time.Time
+	collections uint64
+	errors      map[string]uint64
+	sent        uint64
+	failed      uint64
+	lastSuccess time.Time
+	lastError   string
+}
+
+func newPipelineStats() *pipelineStats {
+	return &pipelineStats{started: time.Now(), errors: make(map[string]uint64)}
+}
+
+func (s *pipelineStats) collected() {
+	s.mu.Lock()
+	s.collections++
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) collectorError(name string) {
+	s.mu.Lock()
+	s.errors[name]++
+	s.mu.Unlock()
+}
+
+func (s *pipelineStats) sendResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failed++
+		s.lastError = err.Error()
+		return
+	}
+	s.sent++
+	s.lastSuccess = time.Now()
+}
+
+// pipelineStatus is the /status document.
+type pipelineStatus struct {
+	Version          string            `json:"version"`
+	UptimeSeconds    int64             `json:"uptimeSeconds"`
+	Collections      uint64            `json:"collections"`
+	CollectionErrors map[string]uint64 `json:"collectionErrors"`
+	SendsSucceeded   uint64            `json:"sendsSucceeded"`
+	SendsFailed      uint64            `json:"sendsFailed"`
+	SpoolDepth       int               `json:"spoolDepth"`
+	SendQueueDepth   int               `json:"sendQueueDepth"`
+	BytesSent        uint64            `json:"bytesSent"`
+	LastSuccess      *time.Time        `json:"lastSuccess,omitempty"`
+	LastError        string            `json:"lastError,omitempty"`
+}
+
+func (a *agent) status() pipelineStatus {
+	s := a.pipeline
+	s.mu.Lock()
+	status := pipelineStatus{
+		Version:          Version,
+		UptimeSeconds:    int64(time.Since(s.started).Seconds()),
+		Collections:      s.collections,
+		CollectionErrors: make(map[string]uint64, len(s.errors)),
+		SendsSucceeded:   s.sent,
+		SendsFailed:      s.failed,
+		LastError:        s.lastError,
+	}
+	for name, n := range s.errors {
+		status.CollectionErrors[name] = n
+	}
+	if !s.lastSuccess.IsZero() {
+		last := s.lastSuccess.UTC()
+		status.LastSuccess = &last
+	}
+	s.mu.Unlock()
+
+	if a.spool != nil {
+		status.SpoolDepth = a.spool.Len()
+	}
+	status.SendQueueDepth = len(a.queue.heartbeats)
+	status.BytesSent = a.client.BytesSent()
+	return status
+}
+
+// statusServer serves /metrics in the Prometheus text format and /status
+// as JSON.
+type statusServer struct {
+	server *http.Server
+}
+
+func newStatusServer(cfg config.StatusConfig, a *agent) *statusServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, a.status())
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a.status())
+	})
+	return &statusServer{server: &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}}
+}
+
+func (s *statusServer) Start() error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Status server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *statusServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+func writeMetrics(w http.ResponseWriter, s pipelineStatus) {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric("sentinel_agent_info", "gauge", "Agent version.")
+	fmt.Fprintf(&b, "sentinel_agent_info{version=%q} 1\n", s.Version)
+	metric("sentinel_agent_uptime_seconds", "gauge", "Seconds since the agent started.")
+	fmt.Fprintf(&b, "sentinel_agent_uptime_seconds %d\n", s.UptimeSeconds)
+	metric("sentinel_agent_collections_total", "counter", "Heartbeat collections run.")
+	fmt.Fprintf(&b, "sentinel_agent_collections_total %d\n", s.Collections)
+	metric("sentinel_agent_collection_errors_total", "counter", "Collection errors by collector.")
+	names := make([]string, 0, len(s.CollectionErrors))
+	for name := range s.CollectionErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "sentinel_agent_collection_errors_total{collector=%q} %d\n", name, s.CollectionErrors[name])
+	}
+	metric("sentinel_agent_heartbeats_sent_total", "counter", "Heartbeat sends by result.")
+	fmt.Fprintf(&b, "sentinel_agent_heartbeats_sent_total{result=\"success\"} %d\n", s.SendsSucceeded)
+	fmt.Fprintf(&b, "sentinel_agent_heartbeats_sent_total{result=\"failure\"} %d\n", s.SendsFailed)
+	metric("sentinel_agent_spool_depth", "gauge", "Heartbeats waiting in the spool.")
+	fmt.Fprintf(&b, "sentinel_agent_spool_depth %d\n", s.SpoolDepth)
+	metric("sentinel_agent_send_queue_depth", "gauge", "Heartbeats waiting for a send worker.")
+	fmt.Fprintf(&b, "sentinel_agent_send_queue_depth %d\n", s.SendQueueDepth)
+	metric("sentinel_agent_sent_bytes_total", "counter", "Request bytes delivered to the backend.")
+	fmt.Fprintf(&b, "sentinel_agent_sent_bytes_total %d\n", s.BytesSent)
+	if s.LastSuccess != nil {
+		metric("sentinel_agent_last_success_timestamp_seconds", "gauge", "Time of the last successful heartbeat.")
+		fmt.Fprintf(&b, "sentinel_agent_last_success_timestamp_seconds %d\n", s.LastSuccess.Unix())
+	}
+	w.Write([]byte(b.String()))
+}
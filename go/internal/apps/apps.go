@@ -0,0 +1,158 @@
+// Package apps collects metrics from applications running on the host,
+// such as PHP-FPM pools, JVMs, load balancers and message brokers.
+package apps
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Result is one application instance. Exactly one of the type-specific
+// fields is set when Status is up.
+type Result struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	PHPFPM *PHPFPMStats `json:"phpFpm,omitempty"`
+	UWSGI  *UWSGIStats  `json:"uwsgi,omitempty"`
+	JVM    *JVMStats    `json:"jvm,omitempty"`
+
+	LoadBalancer *LoadBalancerStats `json:"loadBalancer,omitempty"`
+	RabbitMQ     *RabbitMQStats     `json:"rabbitmq,omitempty"`
+	Kafka        *KafkaStats        `json:"kafka,omitempty"`
+
+	Elasticsearch *ElasticsearchStats `json:"elasticsearch,omitempty"`
+	Memcached     *MemcachedStats     `json:"memcached,omitempty"`
+}
+
+// collectFunc queries one application and fills in its stats.
+type collectFunc func(ctx context.Context, result *Result) error
+
+type app struct {
+	cfg      config.AppConfig
+	collect  collectFunc
+	timeout  time.Duration
+	interval time.Duration
+	next     time.Time
+}
+
+// Collector queries the configured applications on their own intervals.
+type Collector struct {
+	mu   sync.Mutex
+	apps []*app
+}
+
+func NewCollector(cfgs []config.AppConfig) (*Collector, error) {
+	c := &Collector{}
+	for _, cfg := range cfgs {
+		fn, err := newCollectFunc(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("app %s: %w", cfg.Name, err)
+		}
+		a := &app{
+			cfg:      cfg,
+			collect:  fn,
+			timeout:  defaultTimeout,
+			interval: time.Duration(cfg.Interval) * time.Second,
+		}
+		if cfg.Timeout > 0 {
+			a.timeout = time.Duration(cfg.Timeout) * time.Second
+		}
+		c.apps = append(c.apps, a)
+	}
+	return c, nil
+}
+
+func newCollectFunc(cfg config.AppConfig) (collectFunc, error) {
+	if cfg.Target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+	auth := credentials{cfg.Username, cfg.Password}
+	switch cfg.Type {
+	case "phpfpm":
+		return phpFPMCollector(cfg.Target, cfg.StatusPath, auth), nil
+	case "uwsgi":
+		return uwsgiCollector(cfg.Target, auth), nil
+	case "jolokia":
+		return jolokiaCollector(cfg.Target, auth), nil
+	case "haproxy":
+		return haproxyCollector(cfg.Target, auth), nil
+	case "traefik":
+		return traefikCollector(cfg.Target, auth), nil
+	case "rabbitmq":
+		return rabbitMQCollector(cfg.Target, auth), nil
+	case "kafka":
+		return kafkaCollector(cfg.Target), nil
+	case "elasticsearch":
+		return elasticsearchCollector(cfg.Target, auth), nil
+	case "memcached":
+		return memcachedCollector(cfg.Target), nil
+	default:
+		return nil, fmt.Errorf("unknown app type %q", cfg.Type)
+	}
+}
+
+// Collect queries every due application concurrently. Applications
+// without an interval are queried on every heartbeat.
+func (c *Collector) Collect(ctx context.Context) []Result {
+	c.mu.Lock()
+	now := time.Now()
+	due := make([]*app, 0, len(c.apps))
+	for _, a := range c.apps {
+		if now.Before(a.next) {
+			continue
+		}
+		a.next = now.Add(a.interval)
+		due = append(due, a)
+	}
+	c.mu.Unlock()
+
+	results := make([]Result, len(due))
+	var wg sync.WaitGroup
+	for i, a := range due {
+		wg.Add(1)
+		go func(i int, a *app) {
+			defer wg.Done()
+			results[i] = a.run(ctx)
+		}(i, a)
+	}
+	wg.Wait()
+	return results
+}
+
+func (a *app) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Result{
+		Name:      a.cfg.Name,
+		Type:      a.cfg.Type,
+		Target:    a.cfg.Target,
+		Status:    StatusUp,
+		Timestamp: start.UTC(),
+	}
+	err := a.collect(ctx, &result)
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package apps
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type ElasticsearchStats struct {
+	ClusterName        string `json:"clusterName"`
+	Status             string `json:"status"`
+	Nodes              int    `json:"nodes"`
+	DataNodes          int    `json:"dataNodes"`
+	ActiveShards       int    `json:"activeShards"`
+	RelocatingShards   int    `json:"relocatingShards"`
+	InitializingShards int    `json:"initializingShards"`
+	UnassignedShards   int    `json:"unassignedShards"`
+	PendingTasks       int    `json:"pendingTasks"`
+	MaxTaskWaitMs      int64  `json:"maxTaskWaitMs"`
+	// MaxHeapPercent is the highest JVM heap usage of any node.
+	MaxHeapPercent int                 `json:"maxHeapPercent"`
+	NodeHeap       []ElasticsearchNode `json:"nodeHeap"`
+}
+
+type ElasticsearchNode struct {
+	Name        string `json:"name"`
+	HeapPercent int    `json:"heapPercent"`
+}
+
+// elasticsearchCollector reads cluster health and per-node heap usage from
+// an Elasticsearch or OpenSearch base URL.
+func elasticsearchCollector(target string, auth credentials) collectFunc {
+	target = strings.TrimSuffix(target, "/")
+	return func(ctx context.Context, result *Result) error {
+		var health struct {
+			ClusterName        string `json:"cluster_name"`
+			Status             string `json:"status"`
+			Nodes              int    `json:"number_of_nodes"`
+			DataNodes          int    `json:"number_of_data_nodes"`
+			ActiveShards       int    `json:"active_shards"`
+			RelocatingShards   int    `json:"relocating_shards"`
+			InitializingShards int    `json:"initializing_shards"`
+			UnassignedShards   int    `json:"unassigned_shards"`
+			PendingTasks       int    `json:"number_of_pending_tasks"`
+			MaxTaskWaitMs      int64  `json:"task_max_waiting_in_queue_millis"`
+		}
+		if err := getJSON(ctx, auth, target+"/_cluster/health", &health); err != nil {
+			return err
+		}
+		stats := &ElasticsearchStats{
+			ClusterName:        health.ClusterName,
+			Status:             health.Status,
+			Nodes:              health.Nodes,
+			DataNodes:          health.DataNodes,
+			ActiveShards:       health.ActiveShards,
+			RelocatingShards:   health.RelocatingShards,
+			InitializingShards: health.InitializingShards,
+			UnassignedShards:   health.UnassignedShards,
+			PendingTasks:       health.PendingTasks,
+			MaxTaskWaitMs:      health.MaxTaskWaitMs,
+			NodeHeap:           make([]ElasticsearchNode, 0),
+		}
+
+		var nodes struct {
+			Nodes map[string]struct {
+				Name string `json:"name"`
+				JVM  struct {
+					Mem struct {
+						HeapUsedPercent int `json:"heap_used_percent"`
+					} `json:"mem"`
+				} `json:"jvm"`
+			} `json:"nodes"`
+		}
+		if err := getJSON(ctx, auth, target+"/_nodes/stats/jvm", &nodes); err != nil {
+			return err
+		}
+		for _, node := range nodes.Nodes {
+			heap := node.JVM.Mem.HeapUsedPercent
+			stats.NodeHeap = append(stats.NodeHeap, ElasticsearchNode{Name: node.Name, HeapPercent: heap})
+			stats.MaxHeapPercent = max(stats.MaxHeapPercent, heap)
+		}
+		sort.Slice(stats.NodeHeap, func(i, j int) bool {
+			return stats.NodeHeap[i].Name < stats.NodeHeap[j].Name
+		})
+
+		result.Elasticsearch = stats
+		return nil
+	}
+}
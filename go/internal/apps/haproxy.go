@@ -0,0 +1,168 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadBalancerStats summarizes HAProxy or Traefik backends. RequestRate is
+// in requests per second.
+type LoadBalancerStats struct {
+	Backends     []LBBackend `json:"backends"`
+	BackendsUp   int         `json:"backendsUp"`
+	BackendsDown int         `json:"backendsDown"`
+	ServersUp    int         `json:"serversUp"`
+	ServersDown  int         `json:"serversDown"`
+	QueueDepth   int         `json:"queueDepth"`
+	RequestRate  float64     `json:"requestRate"`
+}
+
+// LBBackend is one backend (HAProxy) or service (Traefik). HAProxy only
+// reports session rates for backends, which RequestRate holds instead.
+type LBBackend struct {
+	Name        string  `json:"name"`
+	Status      string  `json:"status"`
+	ServersUp   int     `json:"serversUp"`
+	ServersDown int     `json:"serversDown"`
+	Queue       int     `json:"queue,omitempty"`
+	Sessions    int     `json:"sessions,omitempty"`
+	RequestRate float64 `json:"requestRate"`
+	Errors      uint64  `json:"errors,omitempty"`
+}
+
+// haproxyCollector reads "show stat" from the stats socket (unix:/path or
+// host:port) or the CSV export of the stats page (an http:// URL).
+func haproxyCollector(target string, auth credentials) collectFunc {
+	return func(ctx context.Context, result *Result) error {
+		var data []byte
+		var err error
+		if isHTTP(target) {
+			url := target
+			if !strings.HasSuffix(url, ";csv") {
+				url += ";csv"
+			}
+			data, err = fetch(ctx, auth, "GET", url, nil)
+		} else {
+			data, err = haproxyCommand(ctx, target, "show stat")
+		}
+		if err != nil {
+			return err
+		}
+		stats, err := parseHAProxyStats(data)
+		if err != nil {
+			return err
+		}
+		result.LoadBalancer = stats
+		return nil
+	}
+}
+
+func haproxyCommand(ctx context.Context, target, command string) ([]byte, error) {
+	conn, err := dial(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(command + "\n")); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(conn, maxResponseSize))
+}
+
+func parseHAProxyStats(data []byte) (*LoadBalancerStats, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("# "))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid stats CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty stats response")
+	}
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	number := func(row []string, name string) uint64 {
+		v, _ := strconv.ParseUint(field(row, name), 10, 64)
+		return v
+	}
+	if _, ok := columns["svname"]; !ok {
+		return nil, fmt.Errorf("unexpected stats header")
+	}
+
+	backends := make(map[string]*LBBackend)
+	backend := func(name string) *LBBackend {
+		b, ok := backends[name]
+		if !ok {
+			b = &LBBackend{Name: name}
+			backends[name] = b
+		}
+		return b
+	}
+	stats := &LoadBalancerStats{Backends: make([]LBBackend, 0)}
+	for _, row := range rows[1:] {
+		proxy, server := field(row, "pxname"), field(row, "svname")
+		switch server {
+		case "FRONTEND":
+			// Frontend rates count each request once; backends would
+			// double count requests routed through several of them.
+			// TCP frontends only report sessions.
+			rate := field(row, "req_rate")
+			if rate == "" {
+				rate = field(row, "rate")
+			}
+			v, _ := strconv.ParseFloat(rate, 64)
+			stats.RequestRate += v
+		case "BACKEND":
+			b := backend(proxy)
+			b.Status = strings.ToLower(field(row, "status"))
+			b.Queue = int(number(row, "qcur"))
+			b.Sessions = int(number(row, "scur"))
+			b.RequestRate = float64(number(row, "rate"))
+			b.Errors = number(row, "econ") + number(row, "eresp")
+		default:
+			// Servers report "UP", "DOWN", "UP 1/3" while checks
+			// transition, "MAINT", "NOLB" or "no check".
+			b := backend(proxy)
+			switch status := field(row, "status"); {
+			case strings.HasPrefix(status, "UP"), status == "no check", status == "OPEN":
+				b.ServersUp++
+			case strings.HasPrefix(status, "DOWN"):
+				b.ServersDown++
+			}
+		}
+	}
+
+	for _, b := range backends {
+		if b.Status == "" {
+			continue
+		}
+		if b.Status == "up" {
+			stats.BackendsUp++
+		} else {
+			stats.BackendsDown++
+		}
+		stats.ServersUp += b.ServersUp
+		stats.ServersDown += b.ServersDown
+		stats.QueueDepth += b.Queue
+		stats.Backends = append(stats.Backends, *b)
+	}
+	sort.Slice(stats.Backends, func(i, j int) bool {
+		return stats.Backends[i].Name < stats.Backends[j].Name
+	})
+	return stats, nil
+}
@@ -0,0 +1,159 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type JVMStats struct {
+	VMName        string  `json:"vmName,omitempty"`
+	VMVersion     string  `json:"vmVersion,omitempty"`
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+
+	HeapUsed      uint64  `json:"heapUsed"`
+	HeapCommitted uint64  `json:"heapCommitted"`
+	HeapMax       uint64  `json:"heapMax,omitempty"`
+	HeapPercent   float64 `json:"heapPercent,omitempty"`
+	NonHeapUsed   uint64  `json:"nonHeapUsed"`
+
+	// GCCount and GCTimeMs are totals since JVM start over all collectors.
+	GCCount    uint64    `json:"gcCount"`
+	GCTimeMs   uint64    `json:"gcTimeMs"`
+	Collectors []GCStats `json:"collectors,omitempty"`
+
+	Threads       int `json:"threads"`
+	DaemonThreads int `json:"daemonThreads"`
+	PeakThreads   int `json:"peakThreads"`
+}
+
+type GCStats struct {
+	Name   string `json:"name"`
+	Count  uint64 `json:"count"`
+	TimeMs uint64 `json:"timeMs"`
+}
+
+type jolokiaRequest struct {
+	Type      string   `json:"type"`
+	MBean     string   `json:"mbean"`
+	Attribute []string `json:"attribute"`
+}
+
+type jolokiaResponse struct {
+	Status int             `json:"status"`
+	Error  string          `json:"error"`
+	Value  json.RawMessage `json:"value"`
+}
+
+type jmxMemoryUsage struct {
+	Used      uint64 `json:"used"`
+	Committed uint64 `json:"committed"`
+	Max       int64  `json:"max"`
+}
+
+var jolokiaRequests = []jolokiaRequest{
+	{"read", "java.lang:type=Memory", []string{"HeapMemoryUsage", "NonHeapMemoryUsage"}},
+	{"read", "java.lang:type=GarbageCollector,name=*", []string{"CollectionCount", "CollectionTime"}},
+	{"read", "java.lang:type=Threading", []string{"ThreadCount", "DaemonThreadCount", "PeakThreadCount"}},
+	{"read", "java.lang:type=Runtime", []string{"Uptime", "VmName", "VmVersion"}},
+}
+
+// jolokiaCollector reads the standard java.lang MBeans in one bulk request
+// to a Jolokia agent URL such as http://127.0.0.1:8778/jolokia.
+func jolokiaCollector(target string, auth credentials) collectFunc {
+	return func(ctx context.Context, result *Result) error {
+		var responses []jolokiaResponse
+		if err := postJSON(ctx, auth, target, jolokiaRequests, &responses); err != nil {
+			return err
+		}
+		if len(responses) != len(jolokiaRequests) {
+			return fmt.Errorf("expected %d responses, got %d", len(jolokiaRequests), len(responses))
+		}
+		for i, r := range responses {
+			if r.Status != 200 {
+				return fmt.Errorf("%s: %s", jolokiaRequests[i].MBean, r.Error)
+			}
+		}
+
+		stats := &JVMStats{}
+		var memory struct {
+			Heap    jmxMemoryUsage `json:"HeapMemoryUsage"`
+			NonHeap jmxMemoryUsage `json:"NonHeapMemoryUsage"`
+		}
+		if err := json.Unmarshal(responses[0].Value, &memory); err != nil {
+			return fmt.Errorf("invalid memory response: %w", err)
+		}
+		stats.HeapUsed = memory.Heap.Used
+		stats.HeapCommitted = memory.Heap.Committed
+		stats.NonHeapUsed = memory.NonHeap.Used
+		// max is -1 when undefined.
+		if memory.Heap.Max > 0 {
+			stats.HeapMax = uint64(memory.Heap.Max)
+			stats.HeapPercent = float64(memory.Heap.Used) / float64(memory.Heap.Max) * 100
+		}
+
+		var collectors map[string]struct {
+			CollectionCount int64 `json:"CollectionCount"`
+			CollectionTime  int64 `json:"CollectionTime"`
+		}
+		if err := json.Unmarshal(responses[1].Value, &collectors); err != nil {
+			return fmt.Errorf("invalid garbage collector response: %w", err)
+		}
+		for mbean, gc := range collectors {
+			if gc.CollectionCount < 0 {
+				continue
+			}
+			stats.Collectors = append(stats.Collectors, GCStats{
+				Name:   mbeanProperty(mbean, "name"),
+				Count:  uint64(gc.CollectionCount),
+				TimeMs: uint64(max(gc.CollectionTime, 0)),
+			})
+			stats.GCCount += uint64(gc.CollectionCount)
+			stats.GCTimeMs += uint64(max(gc.CollectionTime, 0))
+		}
+		sort.Slice(stats.Collectors, func(i, j int) bool {
+			return stats.Collectors[i].Name < stats.Collectors[j].Name
+		})
+
+		var threading struct {
+			ThreadCount       int `json:"ThreadCount"`
+			DaemonThreadCount int `json:"DaemonThreadCount"`
+			PeakThreadCount   int `json:"PeakThreadCount"`
+		}
+		if err := json.Unmarshal(responses[2].Value, &threading); err != nil {
+			return fmt.Errorf("invalid threading response: %w", err)
+		}
+		stats.Threads = threading.ThreadCount
+		stats.DaemonThreads = threading.DaemonThreadCount
+		stats.PeakThreads = threading.PeakThreadCount
+
+		var runtime struct {
+			Uptime    int64  `json:"Uptime"`
+			VmName    string `json:"VmName"`
+			VmVersion string `json:"VmVersion"`
+		}
+		if err := json.Unmarshal(responses[3].Value, &runtime); err != nil {
+			return fmt.Errorf("invalid runtime response: %w", err)
+		}
+		stats.UptimeSeconds = float64(runtime.Uptime) / 1000
+		stats.VMName = runtime.VmName
+		stats.VMVersion = runtime.VmVersion
+
+		result.JVM = stats
+		return nil
+	}
+}
+
+// mbeanProperty returns a key property of an ObjectName such as
+// "java.lang:name=G1 Young Generation,type=GarbageCollector".
+func mbeanProperty(mbean, key string) string {
+	_, props, _ := strings.Cut(mbean, ":")
+	for _, prop := range strings.Split(props, ",") {
+		if k, v, ok := strings.Cut(prop, "="); ok && k == key {
+			return v
+		}
+	}
+	return mbean
+}
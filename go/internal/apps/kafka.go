@@ -0,0 +1,189 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type KafkaStats struct {
+	ClusterID    string `json:"clusterId,omitempty"`
+	ControllerID int32  `json:"controllerId"`
+	Brokers      int    `json:"brokers"`
+	Topics       int    `json:"topics"`
+	Partitions   int    `json:"partitions"`
+	// UnderReplicated partitions have fewer in-sync replicas than replicas;
+	// Offline partitions have no leader.
+	UnderReplicated       int      `json:"underReplicated"`
+	Offline               int      `json:"offline"`
+	UnderReplicatedTopics []string `json:"underReplicatedTopics,omitempty"`
+}
+
+const (
+	kafkaMetadataKey     = 3
+	kafkaMetadataVersion = 4
+)
+
+var errKafkaShort = errors.New("truncated metadata response")
+
+// kafkaCollector sends a Metadata request to a broker (host:port, plaintext
+// listeners only) and derives cluster-wide partition health from the
+// replica and in-sync replica lists.
+func kafkaCollector(target string) collectFunc {
+	return func(ctx context.Context, result *Result) error {
+		conn, err := dial(ctx, target)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var body bytes.Buffer
+		binary.Write(&body, binary.BigEndian, int16(kafkaMetadataKey))
+		binary.Write(&body, binary.BigEndian, int16(kafkaMetadataVersion))
+		binary.Write(&body, binary.BigEndian, int32(1)) // correlation id
+		writeKafkaString(&body, "sentinel-agent")
+		binary.Write(&body, binary.BigEndian, int32(-1)) // all topics
+		body.WriteByte(0)                                // allow_auto_topic_creation
+
+		var request bytes.Buffer
+		binary.Write(&request, binary.BigEndian, int32(body.Len()))
+		request.Write(body.Bytes())
+		if _, err := conn.Write(request.Bytes()); err != nil {
+			return err
+		}
+
+		var size int32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return fmt.Errorf("failed to read metadata response: %w", err)
+		}
+		if size < 4 || size > maxResponseSize {
+			return fmt.Errorf("invalid metadata response size %d", size)
+		}
+		response := make([]byte, size)
+		if _, err := io.ReadFull(conn, response); err != nil {
+			return fmt.Errorf("failed to read metadata response: %w", err)
+		}
+		stats, err := parseKafkaMetadata(response)
+		if err != nil {
+			return err
+		}
+		result.Kafka = stats
+		return nil
+	}
+}
+
+func parseKafkaMetadata(data []byte) (*KafkaStats, error) {
+	r := &kafkaReader{data: data}
+	r.int32() // correlation id
+	r.int32() // throttle_time_ms
+
+	stats := &KafkaStats{}
+	brokers := r.int32()
+	for i := int32(0); i < brokers && r.err == nil; i++ {
+		r.int32()  // node_id
+		r.string() // host
+		r.int32()  // port
+		r.string() // rack
+	}
+	stats.Brokers = int(max(brokers, 0))
+	stats.ClusterID = r.string()
+	stats.ControllerID = r.int32()
+
+	underReplicated := make(map[string]bool)
+	topics := r.int32()
+	for i := int32(0); i < topics && r.err == nil; i++ {
+		r.int16() // error_code
+		name := r.string()
+		r.byte() // is_internal
+		partitions := r.int32()
+		for j := int32(0); j < partitions && r.err == nil; j++ {
+			r.int16() // error_code
+			r.int32() // partition_index
+			leader := r.int32()
+			replicas := r.int32Array()
+			isr := r.int32Array()
+			stats.Partitions++
+			if leader < 0 {
+				stats.Offline++
+			}
+			if isr < replicas {
+				stats.UnderReplicated++
+				underReplicated[name] = true
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	stats.Topics = int(max(topics, 0))
+	for name := range underReplicated {
+		stats.UnderReplicatedTopics = append(stats.UnderReplicatedTopics, name)
+	}
+	sort.Strings(stats.UnderReplicatedTopics)
+	return stats, nil
+}
+
+func writeKafkaString(w *bytes.Buffer, s string) {
+	binary.Write(w, binary.BigEndian, int16(len(s)))
+	w.WriteString(s)
+}
+
+// kafkaReader decodes big-endian protocol fields, remembering the first
+// error so callers can check once at the end.
+type kafkaReader struct {
+	data []byte
+	err  error
+}
+
+func (r *kafkaReader) next(n int) []byte {
+	if r.err != nil || n < 0 || len(r.data) < n {
+		r.err = errKafkaShort
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *kafkaReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *kafkaReader) int16() int16 {
+	if b := r.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if b := r.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+// string reads a nullable string; null is returned as "".
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.next(int(n)))
+}
+
+// int32Array skips an array of int32 and returns its length.
+func (r *kafkaReader) int32Array() int32 {
+	n := r.int32()
+	if n > 0 {
+		r.next(int(n) * 4)
+	}
+	return max(n, 0)
+}
@@ -0,0 +1,94 @@
+package apps
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type MemcachedStats struct {
+	Version       string `json:"version"`
+	UptimeSeconds uint64 `json:"uptimeSeconds"`
+	Connections   uint64 `json:"connections"`
+	Items         uint64 `json:"items"`
+	BytesUsed     uint64 `json:"bytesUsed"`
+	LimitBytes    uint64 `json:"limitBytes"`
+	// MemoryPercent is BytesUsed of LimitBytes.
+	MemoryPercent float64 `json:"memoryPercent"`
+	GetHits       uint64  `json:"getHits"`
+	GetMisses     uint64  `json:"getMisses"`
+	Evictions     uint64  `json:"evictions"`
+	// HitRatio is the share of gets that hit since the previous run, or
+	// since startup on the first run.
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// memcachedCollector sends "stats" over the text protocol to a unix socket
+// or host:port.
+func memcachedCollector(target string) collectFunc {
+	var lastHits, lastMisses uint64
+	return func(ctx context.Context, result *Result) error {
+		conn, err := dial(ctx, target)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("stats\r\n")); err != nil {
+			return err
+		}
+
+		values := make(map[string]string)
+		scanner := bufio.NewScanner(conn)
+		for {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return err
+				}
+				return fmt.Errorf("connection closed before END")
+			}
+			line := strings.TrimSpace(scanner.Text())
+			if line == "END" {
+				break
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 3 && fields[0] == "STAT" {
+				values[fields[1]] = fields[2]
+			} else if strings.Contains(line, "ERROR") {
+				return fmt.Errorf("unexpected reply %q", line)
+			}
+		}
+		number := func(name string) uint64 {
+			v, _ := strconv.ParseUint(values[name], 10, 64)
+			return v
+		}
+
+		stats := &MemcachedStats{
+			Version:       values["version"],
+			UptimeSeconds: number("uptime"),
+			Connections:   number("curr_connections"),
+			Items:         number("curr_items"),
+			BytesUsed:     number("bytes"),
+			LimitBytes:    number("limit_maxbytes"),
+			GetHits:       number("get_hits"),
+			GetMisses:     number("get_misses"),
+			Evictions:     number("evictions"),
+		}
+		if stats.LimitBytes > 0 {
+			stats.MemoryPercent = float64(stats.BytesUsed) / float64(stats.LimitBytes) * 100
+		}
+		hits, misses := stats.GetHits, stats.GetMisses
+		// Counters reset when memcached restarts.
+		if hits >= lastHits && misses >= lastMisses {
+			hits, misses = hits-lastHits, misses-lastMisses
+		}
+		if hits+misses > 0 {
+			stats.HitRatio = float64(hits) / float64(hits+misses)
+		}
+		lastHits, lastMisses = stats.GetHits, stats.GetMisses
+
+		result.Memcached = stats
+		return nil
+	}
+}
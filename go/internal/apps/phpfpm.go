@@ -0,0 +1,174 @@
+package apps
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+type PHPFPMStats struct {
+	Pool                string `json:"pool"`
+	ProcessManager      string `json:"processManager"`
+	AcceptedConnections uint64 `json:"acceptedConnections"`
+	ListenQueue         int    `json:"listenQueue"`
+	MaxListenQueue      int    `json:"maxListenQueue"`
+	ListenQueueLen      int    `json:"listenQueueLen"`
+	IdleProcesses       int    `json:"idleProcesses"`
+	ActiveProcesses     int    `json:"activeProcesses"`
+	TotalProcesses      int    `json:"totalProcesses"`
+	MaxActiveProcesses  int    `json:"maxActiveProcesses"`
+	MaxChildrenReached  int    `json:"maxChildrenReached"`
+	SlowRequests        uint64 `json:"slowRequests"`
+}
+
+type phpFPMStatus struct {
+	Pool                string `json:"pool"`
+	ProcessManager      string `json:"process manager"`
+	AcceptedConnections uint64 `json:"accepted conn"`
+	ListenQueue         int    `json:"listen queue"`
+	MaxListenQueue      int    `json:"max listen queue"`
+	ListenQueueLen      int    `json:"listen queue len"`
+	IdleProcesses       int    `json:"idle processes"`
+	ActiveProcesses     int    `json:"active processes"`
+	TotalProcesses      int    `json:"total processes"`
+	MaxActiveProcesses  int    `json:"max active processes"`
+	MaxChildrenReached  int    `json:"max children reached"`
+	SlowRequests        uint64 `json:"slow requests"`
+}
+
+// phpFPMCollector reads pm.status_path either through a web server (an
+// http:// target) or directly from the pool's FastCGI socket.
+func phpFPMCollector(target, statusPath string, auth credentials) collectFunc {
+	if statusPath == "" {
+		statusPath = "/status"
+	}
+	return func(ctx context.Context, result *Result) error {
+		var status phpFPMStatus
+		if isHTTP(target) {
+			url := target
+			if !strings.Contains(url, "?") {
+				url += "?json"
+			}
+			if err := getJSON(ctx, auth, url, &status); err != nil {
+				return err
+			}
+		} else {
+			body, err := fastCGIGet(ctx, target, statusPath, "json")
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(body, &status); err != nil {
+				return fmt.Errorf("invalid status response: %w", err)
+			}
+		}
+		stats := PHPFPMStats(status)
+		result.PHPFPM = &stats
+		return nil
+	}
+}
+
+const (
+	fcgiBeginRequest = 1
+	fcgiEndRequest   = 3
+	fcgiParams       = 4
+	fcgiStdin        = 5
+	fcgiStdout       = 6
+	fcgiStderr       = 7
+	fcgiResponder    = 1
+)
+
+// fastCGIGet sends a single GET request over FastCGI and returns the
+// response body.
+func fastCGIGet(ctx context.Context, target, path, query string) ([]byte, error) {
+	conn, err := dial(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	params := map[string]string{
+		"REQUEST_METHOD":    "GET",
+		"SCRIPT_NAME":       path,
+		"SCRIPT_FILENAME":   path,
+		"REQUEST_URI":       path + "?" + query,
+		"QUERY_STRING":      query,
+		"SERVER_PROTOCOL":   "HTTP/1.1",
+		"GATEWAY_INTERFACE": "CGI/1.1",
+	}
+	var encoded bytes.Buffer
+	for name, value := range params {
+		writeFastCGILength(&encoded, len(name))
+		writeFastCGILength(&encoded, len(value))
+		encoded.WriteString(name)
+		encoded.WriteString(value)
+	}
+
+	var req bytes.Buffer
+	writeFastCGIRecord(&req, fcgiBeginRequest, []byte{0, fcgiResponder, 0, 0, 0, 0, 0, 0})
+	writeFastCGIRecord(&req, fcgiParams, encoded.Bytes())
+	writeFastCGIRecord(&req, fcgiParams, nil)
+	writeFastCGIRecord(&req, fcgiStdin, nil)
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	reader := bufio.NewReader(io.LimitReader(conn, maxResponseSize))
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return nil, fmt.Errorf("failed to read FastCGI response: %w", err)
+		}
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		content := make([]byte, length+int(header[6]))
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("failed to read FastCGI response: %w", err)
+		}
+		switch header[1] {
+		case fcgiStdout:
+			stdout.Write(content[:length])
+		case fcgiStderr:
+			stderr.Write(content[:length])
+		case fcgiEndRequest:
+			return parseCGIResponse(stdout.Bytes(), stderr.String())
+		}
+	}
+}
+
+func parseCGIResponse(out []byte, stderr string) ([]byte, error) {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(out)))
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("invalid FastCGI response: %w", err)
+	}
+	if status := header.Get("Status"); status != "" {
+		code, _ := strconv.Atoi(strings.Fields(status)[0])
+		if code != 200 {
+			if stderr != "" {
+				return nil, fmt.Errorf("status %s: %s", status, strings.TrimSpace(stderr))
+			}
+			return nil, fmt.Errorf("status %s (is pm.status_path set?)", status)
+		}
+	}
+	return io.ReadAll(tp.R)
+}
+
+func writeFastCGIRecord(w *bytes.Buffer, recordType byte, content []byte) {
+	w.Write([]byte{1, recordType, 0, 1, byte(len(content) >> 8), byte(len(content)), 0, 0})
+	w.Write(content)
+}
+
+func writeFastCGILength(w *bytes.Buffer, n int) {
+	if n < 128 {
+		w.WriteByte(byte(n))
+		return
+	}
+	binary.Write(w, binary.BigEndian, uint32(n)|1<<31)
+}
@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// promSample is one series of the Prometheus text exposition format.
+type promSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// parsePromText parses the Prometheus text format, skipping comments and
+// lines it doesn't understand. Timestamps are ignored.
+func parsePromText(r io.Reader) ([]promSample, error) {
+	var samples []promSample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		sample, ok := parsePromLine(line)
+		if ok {
+			samples = append(samples, sample)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return samples, nil
+}
+
+func parsePromLine(line string) (promSample, bool) {
+	sample := promSample{}
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, false
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+
+	if rest[0] == '{' {
+		labels, n, ok := parsePromLabels(rest[1:])
+		if !ok {
+			return sample, false
+		}
+		sample.Labels = labels
+		rest = rest[1+n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		switch fields[0] {
+		case "+Inf":
+			value = math.Inf(1)
+		case "-Inf":
+			value = math.Inf(-1)
+		default:
+			return sample, false
+		}
+	}
+	sample.Value = value
+	return sample, true
+}
+
+// parsePromLabels parses `name="value",...}` and returns the number of
+// bytes consumed including the closing brace.
+func parsePromLabels(s string) (map[string]string, int, bool) {
+	labels := make(map[string]string)
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, false
+		}
+		if s[i] == '}' {
+			return labels, i + 1, true
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+			return nil, 0, false
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 2
+
+		var value strings.Builder
+		for {
+			if i >= len(s) {
+				return nil, 0, false
+			}
+			c := s[i]
+			i++
+			if c == '"' {
+				break
+			}
+			if c == '\\' && i < len(s) {
+				switch s[i] {
+				case 'n':
+					c = '\n'
+				default:
+					c = s[i]
+				}
+				i++
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,117 @@
+package apps
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// maxQueues bounds the per-queue list to the deepest queues.
+const maxQueues = 20
+
+type RabbitMQStats struct {
+	Messages      uint64          `json:"messages"`
+	Ready         uint64          `json:"ready"`
+	Unacked       uint64          `json:"unacked"`
+	PublishRate   float64         `json:"publishRate"`
+	DeliverRate   float64         `json:"deliverRate"`
+	Queues        int             `json:"queues"`
+	Consumers     int             `json:"consumers"`
+	Connections   int             `json:"connections"`
+	Alarms        []string        `json:"alarms,omitempty"`
+	DeepestQueues []RabbitMQQueue `json:"deepestQueues"`
+}
+
+type RabbitMQQueue struct {
+	Name      string `json:"name"`
+	VHost     string `json:"vhost"`
+	Messages  uint64 `json:"messages"`
+	Ready     uint64 `json:"ready"`
+	Unacked   uint64 `json:"unacked"`
+	Consumers int    `json:"consumers"`
+	State     string `json:"state,omitempty"`
+}
+
+type rabbitRate struct {
+	Rate float64 `json:"rate"`
+}
+
+// rabbitMQCollector queries the management plugin API (target is its base
+// URL, e.g. http://127.0.0.1:15672). The user needs the monitoring tag.
+func rabbitMQCollector(target string, auth credentials) collectFunc {
+	target = strings.TrimSuffix(target, "/")
+	return func(ctx context.Context, result *Result) error {
+		var overview struct {
+			QueueTotals struct {
+				Messages uint64 `json:"messages"`
+				Ready    uint64 `json:"messages_ready"`
+				Unacked  uint64 `json:"messages_unacknowledged"`
+			} `json:"queue_totals"`
+			MessageStats struct {
+				Publish rabbitRate `json:"publish_details"`
+				Deliver rabbitRate `json:"deliver_get_details"`
+			} `json:"message_stats"`
+			ObjectTotals struct {
+				Queues      int `json:"queues"`
+				Consumers   int `json:"consumers"`
+				Connections int `json:"connections"`
+			} `json:"object_totals"`
+		}
+		if err := getJSON(ctx, auth, target+"/api/overview", &overview); err != nil {
+			return err
+		}
+		stats := &RabbitMQStats{
+			Messages:      overview.QueueTotals.Messages,
+			Ready:         overview.QueueTotals.Ready,
+			Unacked:       overview.QueueTotals.Unacked,
+			PublishRate:   overview.MessageStats.Publish.Rate,
+			DeliverRate:   overview.MessageStats.Deliver.Rate,
+			Queues:        overview.ObjectTotals.Queues,
+			Consumers:     overview.ObjectTotals.Consumers,
+			Connections:   overview.ObjectTotals.Connections,
+			DeepestQueues: make([]RabbitMQQueue, 0),
+		}
+
+		var nodes []struct {
+			Name          string `json:"name"`
+			Running       bool   `json:"running"`
+			MemAlarm      bool   `json:"mem_alarm"`
+			DiskFreeAlarm bool   `json:"disk_free_alarm"`
+		}
+		if err := getJSON(ctx, auth, target+"/api/nodes?columns=name,running,mem_alarm,disk_free_alarm", &nodes); err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			switch {
+			case !node.Running:
+				stats.Alarms = append(stats.Alarms, node.Name+": not running")
+			case node.MemAlarm:
+				stats.Alarms = append(stats.Alarms, node.Name+": memory alarm")
+			case node.DiskFreeAlarm:
+				stats.Alarms = append(stats.Alarms, node.Name+": disk free alarm")
+			}
+		}
+
+		var queues []struct {
+			Name      string `json:"name"`
+			VHost     string `json:"vhost"`
+			Messages  uint64 `json:"messages"`
+			Ready     uint64 `json:"messages_ready"`
+			Unacked   uint64 `json:"messages_unacknowledged"`
+			Consumers int    `json:"consumers"`
+			State     string `json:"state"`
+		}
+		if err := getJSON(ctx, auth, target+"/api/queues?columns=name,vhost,messages,messages_ready,messages_unacknowledged,consumers,state", &queues); err != nil {
+			return err
+		}
+		sort.Slice(queues, func(i, j int) bool {
+			return queues[i].Messages > queues[j].Messages
+		})
+		for _, q := range queues[:min(len(queues), maxQueues)] {
+			stats.DeepestQueues = append(stats.DeepestQueues, RabbitMQQueue(q))
+		}
+
+		result.RabbitMQ = stats
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const defaultMaxSeries = 500
+
+// ScrapeResult holds the allowed series of one Prometheus endpoint.
+// Truncated is set when the endpoint had more matching series than
+// max_series.
+type ScrapeResult struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Series    []Series  `json:"series,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+type Series struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+type scrapeTarget struct {
+	cfg       config.ScrapeConfig
+	timeout   time.Duration
+	interval  time.Duration
+	maxSeries int
+	next      time.Time
+}
+
+// Scraper forwards selected series from local Prometheus endpoints.
+type Scraper struct {
+	mu      sync.Mutex
+	targets []*scrapeTarget
+}
+
+func NewScraper(cfgs []config.ScrapeConfig) *Scraper {
+	s := &Scraper{}
+	for _, cfg := range cfgs {
+		t := &scrapeTarget{
+			cfg:       cfg,
+			timeout:   defaultTimeout,
+			interval:  time.Duration(cfg.Interval) * time.Second,
+			maxSeries: defaultMaxSeries,
+		}
+		if cfg.Timeout > 0 {
+			t.timeout = time.Duration(cfg.Timeout) * time.Second
+		}
+		if cfg.MaxSeries > 0 {
+			t.maxSeries = cfg.MaxSeries
+		}
+		s.targets = append(s.targets, t)
+	}
+	return s
+}
+
+// Collect scrapes every due endpoint concurrently.
+func (s *Scraper) Collect(ctx context.Context) []ScrapeResult {
+	s.mu.Lock()
+	now := time.Now()
+	due := make([]*scrapeTarget, 0, len(s.targets))
+	for _, t := range s.targets {
+		if now.Before(t.next) {
+			continue
+		}
+		t.next = now.Add(t.interval)
+		due = append(due, t)
+	}
+	s.mu.Unlock()
+
+	results := make([]ScrapeResult, len(due))
+	var wg sync.WaitGroup
+	for i, t := range due {
+		wg.Add(1)
+		go func(i int, t *scrapeTarget) {
+			defer wg.Done()
+			results[i] = t.scrape(ctx)
+		}(i, t)
+	}
+	wg.Wait()
+	return results
+}
+
+func (t *scrapeTarget) scrape(ctx context.Context) ScrapeResult {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	result := ScrapeResult{
+		Name:      t.cfg.Name,
+		URL:       t.cfg.URL,
+		Status:    StatusUp,
+		Timestamp: time.Now().UTC(),
+	}
+	samples, err := t.fetch(ctx)
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, sample := range samples {
+		// JSON can't encode NaN or infinities, which summaries report
+		// for quantiles without observations.
+		if !t.allowed(sample.Name) || math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			continue
+		}
+		if len(result.Series) >= t.maxSeries {
+			result.Truncated = true
+			break
+		}
+		labels := sample.Labels
+		if len(t.cfg.Labels) > 0 {
+			labels = make(map[string]string, len(sample.Labels)+len(t.cfg.Labels))
+			for k, v := range sample.Labels {
+				labels[k] = v
+			}
+			for k, v := range t.cfg.Labels {
+				labels[k] = v
+			}
+		}
+		result.Series = append(result.Series, Series{Name: sample.Name, Labels: labels, Value: sample.Value})
+	}
+	return result
+}
+
+func (t *scrapeTarget) fetch(ctx context.Context) ([]promSample, error) {
+	data, err := fetch(ctx, credentials{t.cfg.Username, t.cfg.Password}, "GET", t.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := parsePromText(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no metrics in response")
+	}
+	return samples, nil
+}
+
+// allowed matches a metric name against the allowlist, which may contain
+// glob patterns such as "http_requests_*".
+func (t *scrapeTarget) allowed(name string) bool {
+	for _, pattern := range t.cfg.Metrics {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
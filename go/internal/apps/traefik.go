@@ -0,0 +1,102 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type traefikService struct {
+	Name         string            `json:"name"`
+	Status       string            `json:"status"`
+	ServerStatus map[string]string `json:"serverStatus"`
+}
+
+// traefikCollector reads services and server health from the Traefik API
+// (target is the API base URL, e.g. http://127.0.0.1:8080). Request rates
+// come from traefik_service_requests_total on the Prometheus endpoint of
+// the same address, when enabled, as the delta since the previous run.
+func traefikCollector(target string, auth credentials) collectFunc {
+	target = strings.TrimSuffix(target, "/")
+	var lastCounts map[string]float64
+	var lastAt time.Time
+	metricsMissing := false
+
+	return func(ctx context.Context, result *Result) error {
+		var services []traefikService
+		if err := getJSON(ctx, auth, target+"/api/http/services?per_page=1000", &services); err != nil {
+			return err
+		}
+
+		stats := &LoadBalancerStats{Backends: make([]LBBackend, 0, len(services))}
+		for _, svc := range services {
+			b := LBBackend{Name: svc.Name, Status: svc.Status}
+			for _, status := range svc.ServerStatus {
+				if status == "UP" {
+					b.ServersUp++
+				} else {
+					b.ServersDown++
+				}
+			}
+			// Services without health checks have no serverStatus.
+			if b.Status != "disabled" && (b.ServersDown == 0 || b.ServersUp > 0) {
+				stats.BackendsUp++
+			} else {
+				stats.BackendsDown++
+			}
+			stats.ServersUp += b.ServersUp
+			stats.ServersDown += b.ServersDown
+			stats.Backends = append(stats.Backends, b)
+		}
+
+		if !metricsMissing {
+			counts, err := traefikRequestCounts(ctx, target, auth)
+			var status statusError
+			if errors.As(err, &status) && int(status) == http.StatusNotFound {
+				metricsMissing = true
+			}
+			now := time.Now()
+			if err == nil {
+				if elapsed := now.Sub(lastAt).Seconds(); lastCounts != nil && elapsed > 0 {
+					for i := range stats.Backends {
+						b := &stats.Backends[i]
+						if delta := counts[b.Name] - lastCounts[b.Name]; delta > 0 {
+							b.RequestRate = delta / elapsed
+							stats.RequestRate += b.RequestRate
+						}
+					}
+				}
+				lastCounts, lastAt = counts, now
+			}
+		}
+
+		sort.Slice(stats.Backends, func(i, j int) bool {
+			return stats.Backends[i].Name < stats.Backends[j].Name
+		})
+		result.LoadBalancer = stats
+		return nil
+	}
+}
+
+// traefikRequestCounts sums traefik_service_requests_total per service.
+func traefikRequestCounts(ctx context.Context, target string, auth credentials) (map[string]float64, error) {
+	data, err := fetch(ctx, auth, "GET", target+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := parsePromText(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]float64)
+	for _, s := range samples {
+		if s.Name == "traefik_service_requests_total" {
+			counts[s.Labels["service"]] += s.Value
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,114 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+const maxResponseSize = 8 << 20
+
+// httpClient is created on first use so it follows the crypto policy.
+var httpClient = sync.OnceValue(func() *http.Client {
+	transport := cryptopolicy.NewTransport()
+	transport.Proxy = http.ProxyFromEnvironment
+	return &http.Client{Transport: transport}
+})
+
+func isHTTP(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// dial connects to "unix:/path", an absolute socket path, "tcp://host:port"
+// or "host:port".
+func dial(ctx context.Context, target string) (net.Conn, error) {
+	network, address := "tcp", strings.TrimPrefix(target, "tcp://")
+	if path, ok := strings.CutPrefix(target, "unix:"); ok {
+		network, address = "unix", path
+	} else if strings.HasPrefix(target, "/") {
+		network, address = "unix", target
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// credentials are optional HTTP basic auth credentials.
+type credentials struct {
+	username, password string
+}
+
+// getJSON fetches url and decodes a JSON response into v.
+func getJSON(ctx context.Context, auth credentials, url string, v interface{}) error {
+	return doJSON(ctx, auth, "GET", url, nil, v)
+}
+
+// postJSON sends body as JSON and decodes the response into v.
+func postJSON(ctx context.Context, auth credentials, url string, body, v interface{}) error {
+	return doJSON(ctx, auth, "POST", url, body, v)
+}
+
+func doJSON(ctx context.Context, auth credentials, method, url string, body, v interface{}) error {
+	data, err := fetch(ctx, auth, method, url, body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// statusError is a non-200 HTTP response.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("HTTP %d", int(e))
+}
+
+// fetch sends a request with an optional JSON body and returns the
+// response body.
+func fetch(ctx context.Context, auth credentials, method, url string, body interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth.username != "" {
+		req.SetBasicAuth(auth.username, auth.password)
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
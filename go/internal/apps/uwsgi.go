@@ -0,0 +1,86 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type UWSGIStats struct {
+	Version           string  `json:"version,omitempty"`
+	ListenQueue       int     `json:"listenQueue"`
+	ListenQueueErrors int     `json:"listenQueueErrors"`
+	Workers           int     `json:"workers"`
+	BusyWorkers       int     `json:"busyWorkers"`
+	IdleWorkers       int     `json:"idleWorkers"`
+	Requests          uint64  `json:"requests"`
+	Exceptions        uint64  `json:"exceptions"`
+	HarakiriCount     uint64  `json:"harakiriCount"`
+	AvgResponseMs     float64 `json:"avgResponseMs"`
+}
+
+type uwsgiStatus struct {
+	Version           string `json:"version"`
+	ListenQueue       int    `json:"listen_queue"`
+	ListenQueueErrors int    `json:"listen_queue_errors"`
+	Workers           []struct {
+		Status        string  `json:"status"`
+		Requests      uint64  `json:"requests"`
+		Exceptions    uint64  `json:"exceptions"`
+		HarakiriCount uint64  `json:"harakiri_count"`
+		AvgRT         float64 `json:"avg_rt"`
+	} `json:"workers"`
+}
+
+// uwsgiCollector reads the stats server (--stats), which writes a JSON
+// document and closes the connection, or its HTTP variant (--stats-http).
+func uwsgiCollector(target string, auth credentials) collectFunc {
+	return func(ctx context.Context, result *Result) error {
+		var status uwsgiStatus
+		if isHTTP(target) {
+			if err := getJSON(ctx, auth, target, &status); err != nil {
+				return err
+			}
+		} else {
+			conn, err := dial(ctx, target)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			data, err := io.ReadAll(io.LimitReader(conn, maxResponseSize))
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, &status); err != nil {
+				return fmt.Errorf("invalid stats response: %w", err)
+			}
+		}
+
+		stats := &UWSGIStats{
+			Version:           status.Version,
+			ListenQueue:       status.ListenQueue,
+			ListenQueueErrors: status.ListenQueueErrors,
+			Workers:           len(status.Workers),
+		}
+		var totalRT float64
+		for _, w := range status.Workers {
+			switch w.Status {
+			case "idle":
+				stats.IdleWorkers++
+			case "busy":
+				stats.BusyWorkers++
+			}
+			stats.Requests += w.Requests
+			stats.Exceptions += w.Exceptions
+			stats.HarakiriCount += w.HarakiriCount
+			totalRT += w.AvgRT
+		}
+		if len(status.Workers) > 0 {
+			// avg_rt is in microseconds.
+			stats.AvgResponseMs = totalRT / float64(len(status.Workers)) / 1000
+		}
+		result.UWSGI = stats
+		return nil
+	}
+}
@@ -0,0 +1,256 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusPending = "pending"
+)
+
+const defaultTimeout = 5 * time.Second
+
+type Result struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latencyMs"`
+	Message   string    `json:"message,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Status only changes after FailureThreshold consecutive failures;
+	// the fields below describe the check's recent history.
+	Since               time.Time `json:"since"`
+	ConsecutiveFailures int       `json:"consecutiveFailures,omitempty"`
+	FlapCount           int       `json:"flapCount,omitempty"`
+	Flapping            bool      `json:"flapping,omitempty"`
+
+	LastModified *time.Time `json:"lastModified,omitempty"`
+
+	HTTP  *HTTPTiming  `json:"http,omitempty"`
+	Steps []StepResult `json:"steps,omitempty"`
+}
+
+// probe performs one check attempt. It may fill in result details such as
+// Message (e.g. "HTTP 200"); a returned error marks the check down.
+type probe func(ctx context.Context, result *Result) error
+
+type check struct {
+	cfg      config.CheckConfig
+	probe    probe
+	timeout  time.Duration
+	interval time.Duration
+	next     time.Time
+	state    *checkState
+}
+
+// Runner executes configured checks on their own intervals.
+type Runner struct {
+	history *state.Bucket
+	events  *collector.EventBuffer
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+// NewRunner creates a runner. history may be nil, in which case check
+// state starts over on every restart.
+func NewRunner(cfgs []config.CheckConfig, history *state.Bucket, events *collector.EventBuffer) (*Runner, error) {
+	r := &Runner{history: history, events: events}
+	for _, cfg := range cfgs {
+		if err := r.Add(cfg); err != nil {
+			return nil, err
+		}
+	}
+	r.pruneHistory(time.Now())
+	return r, nil
+}
+
+// Add registers a check. Names must be unique.
+func (r *Runner) Add(cfg config.CheckConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cfg.Name == "" {
+		return fmt.Errorf("check without name")
+	}
+	for _, c := range r.checks {
+		if c.cfg.Name == cfg.Name {
+			return fmt.Errorf("duplicate check name %q", cfg.Name)
+		}
+	}
+
+	p, err := newProbe(cfg)
+	if err != nil {
+		return fmt.Errorf("check %s: %w", cfg.Name, err)
+	}
+
+	if cfg.Target == "" && len(cfg.Steps) > 0 {
+		cfg.Target = cfg.Steps[0].URL
+	}
+	c := &check{cfg: cfg, probe: p, timeout: defaultTimeout, state: r.loadState(cfg.Name)}
+	if cfg.Timeout > 0 {
+		c.timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	// The timeout applies to each sample.
+	if cfg.Samples > 1 {
+		c.timeout *= time.Duration(cfg.Samples)
+	}
+	c.interval = time.Duration(cfg.Interval) * time.Second
+	r.checks = append(r.checks, c)
+	return nil
+}
+
+// Replace swaps the checks added from source for cfgs, e.g. when container
+// labels change. Checks whose configuration is unchanged keep their schedule.
+func (r *Runner) Replace(source string, cfgs []config.CheckConfig) {
+	r.mu.Lock()
+	current := make(map[string]*check)
+	kept := r.checks[:0]
+	for _, c := range r.checks {
+		if c.cfg.Source == source {
+			current[c.cfg.Name] = c
+			continue
+		}
+		kept = append(kept, c)
+	}
+	r.checks = kept
+
+	added := make([]config.CheckConfig, 0)
+	for _, cfg := range cfgs {
+		cfg.Source = source
+		if c, ok := current[cfg.Name]; ok && reflect.DeepEqual(c.cfg, cfg) {
+			r.checks = append(r.checks, c)
+			continue
+		}
+		added = append(added, cfg)
+	}
+	for name := range current {
+		if !containsCheck(r.checks, name) && r.history != nil {
+			r.history.Delete(name)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, cfg := range added {
+		if err := r.Add(cfg); err != nil {
+			log.Printf("Ignoring %s check: %v", source, err)
+		}
+	}
+}
+
+func containsCheck(checks []*check, name string) bool {
+	for _, c := range checks {
+		if c.cfg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.checks)
+}
+
+func newProbe(cfg config.CheckConfig) (probe, error) {
+	if cfg.Type == "transaction" {
+		return transactionProbe(cfg.Steps)
+	}
+	if cfg.Target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+	switch cfg.Type {
+	case "tcp":
+		return tcpProbe(cfg.Target), nil
+	case "http":
+		return httpProbe(cfg.Target, cfg.ExpectStatus, cfg.Samples), nil
+	case "redis":
+		return redisProbe(cfg.Target), nil
+	case "docker":
+		return dockerProbe(cfg.Target), nil
+	case "grpc":
+		return grpcProbe(cfg.Target, cfg.Service, cfg.TLS), nil
+	case "freshness":
+		if cfg.MaxAge <= 0 {
+			return nil, fmt.Errorf("max_age is required")
+		}
+		return freshnessProbe(cfg.Target, time.Duration(cfg.MaxAge)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown check type %q", cfg.Type)
+	}
+}
+
+// Collect runs every due check concurrently and returns their results.
+// Checks without an interval run on every heartbeat.
+func (r *Runner) Collect(ctx context.Context) []Result {
+	r.mu.Lock()
+	now := time.Now()
+	due := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if now.Before(c.next) {
+			continue
+		}
+		c.next = now.Add(c.interval)
+		due = append(due, c)
+	}
+	r.mu.Unlock()
+
+	results := make([]Result, len(due))
+	var wg sync.WaitGroup
+	for i, c := range due {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	for i, c := range due {
+		r.record(c, &results[i])
+	}
+	r.mu.Unlock()
+	return results
+}
+
+func (c *check) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Result{
+		Name:      c.cfg.Name,
+		Type:      c.cfg.Type,
+		Target:    c.cfg.Target,
+		Status:    StatusUp,
+		Source:    c.cfg.Source,
+		Timestamp: start.UTC(),
+	}
+	err := c.probe(ctx, &result)
+	result.LatencyMs = milliseconds(time.Since(start))
+	if err != nil {
+		result.Status = StatusDown
+		result.Message = err.Error()
+	}
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
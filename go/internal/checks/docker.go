@@ -0,0 +1,39 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+// dockerProbe pings the Docker Engine API on a unix socket.
+func dockerProbe(socket string) probe {
+	transport := cryptopolicy.NewTransport()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+	client := &http.Client{Transport: transport}
+	return func(ctx context.Context, result *Result) error {
+		req, err := http.NewRequestWithContext(ctx, "GET", "http://docker/_ping", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64))
+		if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "OK" {
+			return fmt.Errorf("ping returned HTTP %d", resp.StatusCode)
+		}
+		result.Message = "OK"
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxFreshnessEntries bounds the directory walk for huge backup trees.
+const maxFreshnessEntries = 100000
+
+// freshnessProbe fails when target was last modified more than maxAge ago.
+// target is a file, a directory (its newest entry counts) or an http(s)
+// URL whose Last-Modified header is read with HEAD, e.g. an S3 object.
+func freshnessProbe(target string, maxAge time.Duration) probe {
+	return func(ctx context.Context, result *Result) error {
+		var modified time.Time
+		var err error
+		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			modified, err = remoteModTime(ctx, target)
+		} else {
+			modified, err = localModTime(ctx, target)
+		}
+		if err != nil {
+			return err
+		}
+
+		modified = modified.UTC()
+		result.LastModified = &modified
+		age := time.Since(modified).Round(time.Second)
+		result.Message = fmt.Sprintf("last modified %s ago", age)
+		if age > maxAge {
+			return fmt.Errorf("backup stale: last modified %s ago (limit %s)", age, maxAge)
+		}
+		return nil
+	}
+}
+
+func localModTime(ctx context.Context, path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !fi.IsDir() {
+		return fi.ModTime(), nil
+	}
+
+	var newest time.Time
+	entries := 0
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entries++; entries > maxFreshnessEntries {
+			return filepath.SkipAll
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() && p != path {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return newest, nil
+}
+
+func remoteModTime(ctx context.Context, url string) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("HEAD returned HTTP %d", resp.StatusCode)
+	}
+	header := resp.Header.Get("Last-Modified")
+	if header == "" {
+		return time.Time{}, fmt.Errorf("response has no Last-Modified header")
+	}
+	return http.ParseTime(header)
+}
@@ -0,0 +1,160 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/net/http2"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// grpc.health.v1.HealthCheckResponse.ServingStatus
+var grpcServingStatus = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// grpcProbe calls grpc.health.v1.Health/Check for service ("" asks about
+// the server as a whole). The protocol is small enough to speak directly
+// over HTTP/2 without pulling in the gRPC runtime.
+func grpcProbe(address, service string, useTLS bool) probe {
+	scheme := "https"
+	transport := &http2.Transport{TLSClientConfig: cryptopolicy.TLSConfig()}
+	if !useTLS {
+		scheme = "http"
+		transport.AllowHTTP = true
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+	client := &http.Client{Transport: transport}
+	url := scheme + "://" + address + grpcHealthPath
+
+	return func(ctx context.Context, result *Result) error {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(grpcFrame(healthCheckRequest(service))))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("TE", "trailers")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if err != nil {
+			return err
+		}
+
+		// Errors arrive in trailers, or in headers for trailers-only responses.
+		status := resp.Trailer.Get("Grpc-Status")
+		message := resp.Trailer.Get("Grpc-Message")
+		if status == "" {
+			status = resp.Header.Get("Grpc-Status")
+			message = resp.Header.Get("Grpc-Message")
+		}
+		if status != "" && status != "0" {
+			if status == "12" {
+				return fmt.Errorf("health service is not implemented")
+			}
+			code, _ := strconv.Atoi(status)
+			if message == "" {
+				return fmt.Errorf("gRPC status %d", code)
+			}
+			return fmt.Errorf("gRPC status %d: %s", code, message)
+		}
+
+		serving, err := parseHealthCheckResponse(body)
+		if err != nil {
+			return err
+		}
+		result.Message = serving
+		if serving != "SERVING" {
+			return fmt.Errorf("%s", serving)
+		}
+		return nil
+	}
+}
+
+// healthCheckRequest encodes HealthCheckRequest{service = 1}.
+func healthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	msg := []byte{0x0a}
+	msg = binary.AppendUvarint(msg, uint64(len(service)))
+	return append(msg, service...)
+}
+
+// grpcFrame prefixes an uncompressed message with its length.
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+func parseHealthCheckResponse(body []byte) (string, error) {
+	if len(body) < 5 {
+		return "", fmt.Errorf("empty gRPC response")
+	}
+	if body[0] != 0 {
+		return "", fmt.Errorf("compressed gRPC responses are not supported")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if uint32(len(body)-5) < size {
+		return "", fmt.Errorf("truncated gRPC response")
+	}
+	msg := body[5 : 5+size]
+
+	// The response has a single field: status = 1 (varint). An absent
+	// field is the proto3 default, UNKNOWN.
+	status := uint64(0)
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return "", fmt.Errorf("malformed health response")
+		}
+		msg = msg[n:]
+		switch tag & 7 {
+		case 0:
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return "", fmt.Errorf("malformed health response")
+			}
+			if tag>>3 == 1 {
+				status = v
+			}
+			msg = msg[n:]
+		case 2:
+			l, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < l {
+				return "", fmt.Errorf("malformed health response")
+			}
+			msg = msg[n+int(l):]
+		default:
+			return "", fmt.Errorf("malformed health response")
+		}
+	}
+	if name, ok := grpcServingStatus[status]; ok {
+		return name, nil
+	}
+	return fmt.Sprintf("status %d", status), nil
+}
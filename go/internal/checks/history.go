@@ -0,0 +1,156 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"sentinel-agent/internal/collector"
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultFlapThreshold    = 5
+
+	// historySize is the number of recent outcomes flap detection looks at.
+	historySize = 20
+
+	// stateRetention bounds how long state of checks that are no longer
+	// configured is kept, e.g. for containers that went away while the
+	// agent was stopped.
+	stateRetention = 7 * 24 * time.Hour
+)
+
+// checkState is persisted per check so a restart neither resets the
+// failure count nor re-announces a status the backend already knows.
+type checkState struct {
+	Status              string    `json:"status"`
+	Since               time.Time `json:"since"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	History             []bool    `json:"history"`
+	Flapping            bool      `json:"flapping"`
+	LastRun             time.Time `json:"lastRun"`
+}
+
+func (r *Runner) loadState(name string) *checkState {
+	st := &checkState{Status: StatusPending}
+	if r.history != nil {
+		r.history.Get(name, st)
+	}
+	return st
+}
+
+func (r *Runner) pruneHistory(now time.Time) {
+	if r.history == nil {
+		return
+	}
+	for _, name := range r.history.Keys() {
+		if containsCheck(r.checks, name) {
+			continue
+		}
+		var st checkState
+		if !r.history.Get(name, &st) || now.Sub(st.LastRun) > stateRetention {
+			r.history.Delete(name)
+		}
+	}
+}
+
+// record applies a probe outcome to the check's state and rewrites the
+// result's status to the confirmed one: a check goes down only after
+// FailureThreshold consecutive failures and comes back up on the first
+// success. While a check flaps, status change events are suppressed.
+func (r *Runner) record(c *check, result *Result) {
+	st := c.state
+	success := result.Status == StatusUp
+
+	st.History = append(st.History, success)
+	if len(st.History) > historySize {
+		st.History = st.History[len(st.History)-historySize:]
+	}
+	st.LastRun = result.Timestamp
+	if success {
+		st.ConsecutiveFailures = 0
+	} else {
+		st.ConsecutiveFailures++
+	}
+
+	threshold := c.cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	flapThreshold := c.cfg.FlapThreshold
+	if flapThreshold <= 0 {
+		flapThreshold = defaultFlapThreshold
+	}
+
+	flaps := transitions(st.History)
+	flapping := flaps >= flapThreshold
+	if flapping != st.Flapping {
+		st.Flapping = flapping
+		if flapping {
+			r.addEvent("check_flapping", collector.SeverityWarning,
+				fmt.Sprintf("Check %s is flapping (%d state changes in the last %d runs)", c.cfg.Name, flaps, len(st.History)), c)
+		} else {
+			r.addEvent("check_flapping_stopped", collector.SeverityInfo,
+				fmt.Sprintf("Check %s is no longer flapping", c.cfg.Name), c)
+		}
+	}
+
+	status := st.Status
+	switch {
+	case success:
+		status = StatusUp
+	case st.ConsecutiveFailures >= threshold:
+		status = StatusDown
+	}
+	if status != st.Status {
+		if !flapping {
+			switch {
+			case status == StatusDown:
+				r.addEvent("check_down", collector.SeverityCritical,
+					fmt.Sprintf("Check %s is down: %s", c.cfg.Name, result.Message), c)
+			case st.Status == StatusDown:
+				r.addEvent("check_up", collector.SeverityInfo,
+					fmt.Sprintf("Check %s recovered after %s", c.cfg.Name, result.Timestamp.Sub(st.Since).Round(time.Second)), c)
+			}
+		}
+		st.Status = status
+		st.Since = result.Timestamp
+	}
+	if st.Since.IsZero() {
+		st.Since = result.Timestamp
+	}
+
+	result.Status = st.Status
+	result.Since = st.Since
+	result.ConsecutiveFailures = st.ConsecutiveFailures
+	result.FlapCount = flaps
+	result.Flapping = st.Flapping
+
+	if r.history != nil {
+		if err := r.history.Put(c.cfg.Name, st); err != nil {
+			log.Printf("Error saving state of check %s: %v", c.cfg.Name, err)
+		}
+	}
+}
+
+func (r *Runner) addEvent(eventType, severity, message string, c *check) {
+	if r.events == nil {
+		return
+	}
+	r.events.Add(eventType, severity, message, map[string]string{
+		"check":  c.cfg.Name,
+		"type":   c.cfg.Type,
+		"target": c.cfg.Target,
+	})
+}
+
+func transitions(history []bool) int {
+	n := 0
+	for i := 1; i < len(history); i++ {
+		if history[i] != history[i-1] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,155 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+// Keep-alives are disabled so every sample pays for DNS, connect and TLS
+// and the timing breakdown reflects what a new client sees.
+// It is created on first use so it follows the crypto policy.
+var httpClient = sync.OnceValue(func() *http.Client {
+	transport := cryptopolicy.NewTransport()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.DisableKeepAlives = true
+	return &http.Client{Transport: transport}
+})
+
+// HTTPTiming summarizes the samples of an http check. Percentiles are over
+// successful samples; the phase timings are their averages.
+type HTTPTiming struct {
+	Samples   int     `json:"samples"`
+	Failed    int     `json:"failed,omitempty"`
+	P50Ms     float64 `json:"p50Ms"`
+	P95Ms     float64 `json:"p95Ms"`
+	P99Ms     float64 `json:"p99Ms"`
+	DNSMs     float64 `json:"dnsMs"`
+	ConnectMs float64 `json:"connectMs"`
+	TLSMs     float64 `json:"tlsMs,omitempty"`
+	TTFBMs    float64 `json:"ttfbMs"`
+}
+
+type httpSample struct {
+	total, dns, connect, tls, ttfb time.Duration
+}
+
+// httpProbe expects the given status, or any status below 500 when
+// expectStatus is 0: a 404 still proves the server is answering. With
+// several samples the check fails if any sample fails.
+func httpProbe(url string, expectStatus, samples int) probe {
+	samples = max(samples, 1)
+	return func(ctx context.Context, result *Result) error {
+		timings := make([]httpSample, 0, samples)
+		var firstErr error
+		for i := 0; i < samples; i++ {
+			sample, status, err := httpSampleOnce(ctx, url)
+			if err == nil {
+				result.Message = fmt.Sprintf("HTTP %d", status)
+				err = checkStatus(status, expectStatus)
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				if ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+			timings = append(timings, sample)
+		}
+		result.HTTP = summarizeHTTP(timings, samples)
+
+		if firstErr != nil && samples > 1 {
+			return fmt.Errorf("%d/%d samples failed: %w", result.HTTP.Failed, samples, firstErr)
+		}
+		return firstErr
+	}
+}
+
+func httpSampleOnce(ctx context.Context, url string) (httpSample, int, error) {
+	var sample httpSample
+	var dnsStart, connectStart, tlsStart time.Time
+	start := time.Now()
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			sample.dns = time.Since(dnsStart)
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(string, string, error) {
+			sample.connect = time.Since(connectStart)
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			sample.tls = time.Since(tlsStart)
+		},
+		GotFirstResponseByte: func() { sample.ttfb = time.Since(start) },
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", url, nil)
+	if err != nil {
+		return sample, 0, err
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return sample, 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	sample.total = time.Since(start)
+	return sample, resp.StatusCode, nil
+}
+
+func summarizeHTTP(samples []httpSample, attempted int) *HTTPTiming {
+	timing := &HTTPTiming{Samples: attempted, Failed: attempted - len(samples)}
+	if len(samples) == 0 {
+		return timing
+	}
+
+	totals := make([]time.Duration, len(samples))
+	var dns, connect, tlsTime, ttfb time.Duration
+	for i, s := range samples {
+		totals[i] = s.total
+		dns += s.dns
+		connect += s.connect
+		tlsTime += s.tls
+		ttfb += s.ttfb
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i] < totals[j] })
+
+	n := time.Duration(len(samples))
+	timing.P50Ms = milliseconds(percentile(totals, 50))
+	timing.P95Ms = milliseconds(percentile(totals, 95))
+	timing.P99Ms = milliseconds(percentile(totals, 99))
+	timing.DNSMs = milliseconds(dns / n)
+	timing.ConnectMs = milliseconds(connect / n)
+	timing.TLSMs = milliseconds(tlsTime / n)
+	timing.TTFBMs = milliseconds(ttfb / n)
+	return timing
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func checkStatus(status, expect int) error {
+	if expect != 0 && status != expect {
+		return fmt.Errorf("HTTP %d, expected %d", status, expect)
+	}
+	if expect == 0 && status >= 500 {
+		return fmt.Errorf("HTTP %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// redisProbe sends PING. A NOAUTH error still means the server is healthy.
+func redisProbe(address string) probe {
+	return func(ctx context.Context, result *Result) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+
+		if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+			return err
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "+PONG":
+			result.Message = "PONG"
+			return nil
+		case strings.HasPrefix(line, "-NOAUTH"):
+			result.Message = "authentication required"
+			return nil
+		default:
+			return fmt.Errorf("unexpected reply %q", line)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package checks
+
+import (
+	"context"
+	"net"
+)
+
+func tcpProbe(address string) probe {
+	return func(ctx context.Context, _ *Result) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
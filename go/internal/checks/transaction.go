@@ -0,0 +1,145 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const maxStepBody = 1 << 20
+
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+type StepResult struct {
+	Name       string  `json:"name"`
+	StatusCode int     `json:"statusCode,omitempty"`
+	LatencyMs  float64 `json:"latencyMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type step struct {
+	cfg       config.HTTPStepConfig
+	bodyRegex *regexp.Regexp
+	extract   map[string]*regexp.Regexp
+}
+
+// transactionProbe runs the steps in order with a shared cookie jar,
+// stopping at the first failed step.
+func transactionProbe(cfgs []config.HTTPStepConfig) (probe, error) {
+	steps := make([]step, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if cfg.Method == "" {
+			cfg.Method = "GET"
+		}
+		s := step{cfg: cfg, extract: make(map[string]*regexp.Regexp)}
+		if cfg.BodyRegex != "" {
+			re, err := regexp.Compile(cfg.BodyRegex)
+			if err != nil {
+				return nil, fmt.Errorf("step %s: body_regex: %w", cfg.Name, err)
+			}
+			s.bodyRegex = re
+		}
+		for name, pattern := range cfg.Extract {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("step %s: extract %s: %w", cfg.Name, name, err)
+			}
+			if re.NumSubexp() < 1 {
+				return nil, fmt.Errorf("step %s: extract %s needs a capture group", cfg.Name, name)
+			}
+			s.extract[name] = re
+		}
+		steps[i] = s
+	}
+
+	return func(ctx context.Context, result *Result) error {
+		jar, _ := cookiejar.New(nil)
+		client := &http.Client{Jar: jar}
+		vars := make(map[string]string)
+
+		for _, s := range steps {
+			start := time.Now()
+			status, err := s.run(ctx, client, vars)
+			stepResult := StepResult{
+				Name:       s.cfg.Name,
+				StatusCode: status,
+				LatencyMs:  milliseconds(time.Since(start)),
+			}
+			if err == nil && s.cfg.MaxLatencyMs > 0 && stepResult.LatencyMs > float64(s.cfg.MaxLatencyMs) {
+				err = fmt.Errorf("took %.0fms, limit %dms", stepResult.LatencyMs, s.cfg.MaxLatencyMs)
+			}
+			if err != nil {
+				stepResult.Error = err.Error()
+			}
+			result.Steps = append(result.Steps, stepResult)
+			if err != nil {
+				return fmt.Errorf("step %s: %w", s.cfg.Name, err)
+			}
+		}
+		result.Message = fmt.Sprintf("%d steps passed", len(steps))
+		return nil
+	}, nil
+}
+
+func (s *step) run(ctx context.Context, client *http.Client, vars map[string]string) (int, error) {
+	var body io.Reader
+	if s.cfg.Body != "" {
+		body = strings.NewReader(expand(s.cfg.Body, vars))
+	}
+	req, err := http.NewRequestWithContext(ctx, s.cfg.Method, expand(s.cfg.URL, vars), body)
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, expand(value, vars))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxStepBody))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if err := checkStatus(resp.StatusCode, s.cfg.ExpectStatus); err != nil {
+		return resp.StatusCode, err
+	}
+	if s.cfg.BodyContains != "" && !strings.Contains(string(data), s.cfg.BodyContains) {
+		return resp.StatusCode, fmt.Errorf("body does not contain %q", s.cfg.BodyContains)
+	}
+	if s.bodyRegex != nil && !s.bodyRegex.Match(data) {
+		return resp.StatusCode, fmt.Errorf("body does not match %q", s.cfg.BodyRegex)
+	}
+	for name, re := range s.extract {
+		match := re.FindSubmatch(data)
+		if match == nil {
+			return resp.StatusCode, fmt.Errorf("could not extract %s", name)
+		}
+		vars[name] = string(match[1])
+	}
+	return resp.StatusCode, nil
+}
+
+// expand substitutes ${name} with extracted variables, leaving unknown
+// references untouched.
+func expand(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[ref[2:len(ref)-1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
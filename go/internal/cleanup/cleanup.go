@@ -0,0 +1,164 @@
+// Package cleanup removes old files under explicitly configured paths, on
+// a schedule or when the backend asks for it, to reclaim disk space.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+// Report is the outcome of one run of a rule. In a dry run, Files and
+// Bytes are what would have been removed.
+type Report struct {
+	Rule       string    `json:"rule"`
+	DryRun     bool      `json:"dryRun"`
+	Files      int       `json:"files"`
+	Bytes      uint64    `json:"bytes"`
+	Errors     int       `json:"errors,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+func (r Report) String() string {
+	verb := "removed"
+	if r.DryRun {
+		verb = "would remove"
+	}
+	return fmt.Sprintf("cleanup %s %s %d file(s), %d bytes (%d error(s))", r.Rule, verb, r.Files, r.Bytes, r.Errors)
+}
+
+type Cleaner struct {
+	rules    map[string]config.CleanupRuleConfig
+	order    []string
+	dryRun   bool
+	interval time.Duration
+
+	// runMu keeps scheduled and requested runs from overlapping.
+	runMu   sync.Mutex
+	mu      sync.Mutex
+	reports []Report
+}
+
+func New(cfg config.CleanupConfig) *Cleaner {
+	c := &Cleaner{
+		rules:    make(map[string]config.CleanupRuleConfig),
+		dryRun:   cfg.DryRun,
+		interval: time.Duration(cfg.Interval) * time.Second,
+	}
+	for _, rule := range cfg.Rules {
+		c.rules[rule.Name] = rule
+		c.order = append(c.order, rule.Name)
+	}
+	return c
+}
+
+// Start runs every rule each interval until ctx is canceled. Without an
+// interval, rules only run on request.
+func (c *Cleaner) Start(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				for _, name := range c.order {
+					report, _ := c.Clean(ctx, name, false)
+					log.Print(report)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cleaner) HasRule(name string) bool {
+	_, ok := c.rules[name]
+	return ok
+}
+
+// Clean runs a rule. dryRun can only make a run dry: a cleaner configured
+// for dry runs never removes anything.
+func (c *Cleaner) Clean(ctx context.Context, name string, dryRun bool) (Report, error) {
+	rule, ok := c.rules[name]
+	if !ok {
+		return Report{}, fmt.Errorf("unknown cleanup rule %q", name)
+	}
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	start := time.Now()
+	report := Report{Rule: name, DryRun: c.dryRun || dryRun, StartedAt: start.UTC()}
+	cutoff := start.Add(-time.Duration(rule.MaxAge) * time.Hour)
+	for _, root := range rule.Paths {
+		c.cleanPath(ctx, root, rule.Pattern, cutoff, &report)
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	c.mu.Lock()
+	c.reports = append(c.reports, report)
+	c.mu.Unlock()
+	return report, ctx.Err()
+}
+
+// cleanPath removes files and symlinks below root that are older than
+// cutoff and match pattern. Directories are kept and symlinks are not
+// followed.
+func (c *Cleaner) cleanPath(ctx context.Context, root, pattern string, cutoff time.Time, report *Report) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if !os.IsNotExist(err) {
+				report.Errors++
+			}
+			return nil
+		}
+		if d.IsDir() || path == root {
+			// A root that is a symlink or file is never removed itself.
+			return nil
+		}
+		if pattern != "" {
+			if match, _ := filepath.Match(pattern, d.Name()); !match {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !report.DryRun {
+			if err := os.Remove(path); err != nil {
+				report.Errors++
+				return nil
+			}
+		}
+		report.Files++
+		report.Bytes += uint64(info.Size())
+		return nil
+	})
+}
+
+// DrainReports returns the runs since the last call, for the heartbeat.
+func (c *Cleaner) DrainReports() []Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reports := c.reports
+	c.reports = nil
+	return reports
+}
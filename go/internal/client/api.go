@@ -2,13 +2,25 @@ package client
 
 import (
         "bytes"
+        "context"
         "encoding/json"
         "fmt"
         "io"
+        "net"
+        "net/url"
         "net/http"
+        "strings"
+        "sync"
+        "sync/atomic"
         "time"
 
+        "sentinel-agent/internal/apps"
+        "sentinel-agent/internal/checks"
+        "sentinel-agent/internal/cleanup"
         "sentinel-agent/internal/collector"
+        "sentinel-agent/internal/config"
+        "sentinel-agent/internal/logship"
+        "sentinel-agent/internal/tasks"
 )
 
 type APIClient struct {
@@ -16,22 +28,129 @@ type APIClient struct {
         orgSlug     string
         apiKey      string
         hostID      string
+        hostIDMu    sync.RWMutex
         httpClient  *http.Client
+        // timeout bounds each request, including reading the response.
+        timeout time.Duration
+
+        heartbeatPath string
+        headers       map[string]string
+        signer        *sigv4Signer
+
+        environment string
+        datacenter  string
+        group       string
+
+        // capsMu guards the negotiation result; heartbeats may be sent
+        // concurrently.
+        capsMu       sync.RWMutex
+        capabilities *Capabilities
+        legacy       bool
+
+        // include and exclude route optional sections; see Route.
+        include map[string]bool
+        exclude map[string]bool
+
+        // bytesSent counts request bodies that reached the backend.
+        bytesSent atomic.Uint64
 }
 
 type Heartbeat struct {
+        ID           string                   `json:"id"`
+        Sequence     uint64                   `json:"sequence"`
         Hostname     string                   `json:"hostname"`
         AgentVersion string                   `json:"agentVersion"`
         AgentStatus  string                   `json:"agentStatus"`
         Uptime       uint64                   `json:"uptime"`
+        CollectedAt  time.Time                `json:"collectedAt"`
+        Replayed     bool                     `json:"replayed,omitempty"`
+        // QuietHours is set within a configured quiet window; the backend
+        // should not alert on this heartbeat.
+        QuietHours   bool                     `json:"quietHours,omitempty"`
+        // Maintenance is set within a maintenance window scheduled by the
+        // backend.
+        Maintenance  bool                     `json:"maintenance,omitempty"`
+        // Burst is set while a local threshold breach raises the detail of
+        // heartbeats; Processes is only sent then.
+        Burst        *BurstInfo               `json:"burst,omitempty"`
+        Processes    []collector.ProcessEntry `json:"processes,omitempty"`
+        Anomalies    []collector.Anomaly      `json:"anomalies,omitempty"`
         Network      *collector.NetworkInfo   `json:"network,omitempty"`
         Metrics      MetricsPayload           `json:"metrics"`
+        Facts        map[string]interface{}   `json:"facts,omitempty"`
+
+        // CollectorErrors lists the collectors (or system metric sections)
+        // that failed; their data is missing or zero in this heartbeat.
+        CollectorErrors []CollectorError `json:"collectorErrors,omitempty"`
+
+        Traceroute []collector.TracerouteResult `json:"traceroute,omitempty"`
+        PublicIP   *collector.PublicIPInfo      `json:"publicIp,omitempty"`
+        Neighbors  *collector.NeighborInfo      `json:"neighbors,omitempty"`
+        VPN        *collector.VPNInfo           `json:"vpn,omitempty"`
+        TCPStats   *collector.TCPStats          `json:"tcpStats,omitempty"`
+
+        ProcessTCP *collector.ProcessTCPInfo `json:"processTcp,omitempty"`
+        TopTalkers *collector.TopTalkersInfo `json:"topTalkers,omitempty"`
+
+        Firewall *collector.FirewallInfo `json:"firewall,omitempty"`
+        Security *collector.SecurityInfo `json:"security,omitempty"`
+
+        Compliance *collector.ComplianceInfo `json:"compliance,omitempty"`
+        Windows    *collector.WindowsInfo    `json:"windows,omitempty"`
+        MacOS      *collector.MacOSInfo      `json:"macos,omitempty"`
+
+        WMIQueries []collector.WMIQueryResult `json:"wmiQueries,omitempty"`
+        Domain     *collector.DomainInfo      `json:"domain,omitempty"`
+
+        Sessions       *collector.SessionsInfo       `json:"sessions,omitempty"`
+        AuthorizedKeys *collector.AuthorizedKeysInfo `json:"authorizedKeys,omitempty"`
+        LocalUsers     *collector.LocalUsersInfo     `json:"localUsers,omitempty"`
+        Sysctl         *collector.SysctlInfo         `json:"sysctl,omitempty"`
+        WatchedFiles   *collector.WatchedFilesInfo   `json:"watchedFiles,omitempty"`
+
+        Hardware *collector.HardwareInfo `json:"hardware,omitempty"`
+
+        Certificates  *collector.CertificatesInfo  `json:"certificates,omitempty"`
+        ScheduledJobs *collector.ScheduledJobsInfo `json:"scheduledJobs,omitempty"`
+        Kernel        *collector.KernelInfo        `json:"kernel,omitempty"`
+        Accounting    *collector.AccountingInfo    `json:"accounting,omitempty"`
+        Quotas        *collector.QuotaInfo         `json:"quotas,omitempty"`
+        DiskUsage     *collector.DiskUsageInfo     `json:"diskUsage,omitempty"`
+        NetworkMounts *collector.NetworkMountsInfo `json:"networkMounts,omitempty"`
+        LVM           *collector.LVMInfo           `json:"lvm,omitempty"`
+        Power         *collector.PowerInfo         `json:"power,omitempty"`
+
+        Apps             []apps.Result       `json:"apps,omitempty"`
+        PrometheusScrape []apps.ScrapeResult `json:"prometheusScrape,omitempty"`
+
+        Docker *collector.DockerInfo `json:"docker,omitempty"`
+        Checks []checks.Result       `json:"checks,omitempty"`
+
+        // Cluster requests leadership of the named cluster; ClusterChecks
+        // are only reported by the current leader.
+        Cluster       string          `json:"cluster,omitempty"`
+        ClusterChecks []checks.Result `json:"clusterChecks,omitempty"`
+
+        LogShipper *logship.Stats `json:"logShipper,omitempty"`
+
+        TaskResults []tasks.Result `json:"taskResults,omitempty"`
+        Cleanup     []cleanup.Report `json:"cleanup,omitempty"`
+        Events     []collector.Event            `json:"events,omitempty"`
+}
+
+// BurstInfo says why burst mode is on and until when, extended by every
+// further breach.
+type BurstInfo struct {
+        Reason string    `json:"reason"`
+        Until  time.Time `json:"until"`
 }
 
+// MetricsPayload sections are null when they could not be collected; the
+// heartbeat's collectorErrors says why.
 type MetricsPayload struct {
-        CPU    CPUMetrics    `json:"cpu"`
-        Memory MemoryMetrics `json:"memory"`
-        Disk   DiskMetrics   `json:"disk"`
+        CPU    *CPUMetrics    `json:"cpu"`
+        Memory *MemoryMetrics `json:"memory"`
+        Disk   *DiskMetrics   `json:"disk"`
 }
 
 type CPUMetrics struct {
@@ -60,64 +179,184 @@ type DiskMetrics struct {
         MountPoint   string  `json:"mountPoint"`
 }
 
+type CollectorError struct {
+        Collector string `json:"collector"`
+        Error     string `json:"error"`
+}
+
 type HeartbeatRequest struct {
-        OrganizationSlug string    `json:"organizationSlug"`
-        HostID           string    `json:"hostId"`
-        Heartbeat        Heartbeat `json:"heartbeat"`
+        OrganizationSlug string          `json:"organizationSlug"`
+        HostID           string          `json:"hostId"`
+        SchemaVersion    int             `json:"schemaVersion"`
+        Environment      string          `json:"environment,omitempty"`
+        Datacenter       string          `json:"datacenter,omitempty"`
+        Group            string          `json:"group,omitempty"`
+        Heartbeat        json.RawMessage `json:"heartbeat"`
 }
 
 type HeartbeatResponse struct {
         Success bool   `json:"success"`
         HostID  string `json:"hostId"`
         Message string `json:"message,omitempty"`
+
+        Tasks []tasks.SignedTask `json:"tasks,omitempty"`
+
+        // HostIDConflict is set when another host already reports this ID.
+        HostIDConflict bool `json:"hostIdConflict,omitempty"`
+        // SentHostID is the host ID the heartbeat was sent with, which the
+        // conflict refers to; the client may have switched IDs since.
+        SentHostID string `json:"-"`
+
+        // ClusterLeader grants this host leadership of its cluster for
+        // ClusterLeaseSeconds, renewed by every heartbeat response.
+        ClusterLeader       bool `json:"clusterLeader,omitempty"`
+        ClusterLeaseSeconds int  `json:"clusterLeaseSeconds,omitempty"`
+
+        // DesiredInterval, in seconds, replaces the configured heartbeat
+        // interval while responses keep asking for it.
+        DesiredInterval int `json:"desiredInterval,omitempty"`
+
+        // MaintenanceWindows replaces the host's maintenance schedule. It is
+        // left unchanged when omitted; an empty list clears it.
+        MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows"`
+}
+
+// MaintenanceWindow is scheduled downtime from the backend's calendar.
+// Interval, in seconds, replaces the heartbeat interval during the window.
+type MaintenanceWindow struct {
+        ID       string    `json:"id"`
+        Start    time.Time `json:"start"`
+        End      time.Time `json:"end"`
+        Interval int       `json:"interval,omitempty"`
 }
 
 func New(endpoint, orgSlug, apiKey, hostID string) *APIClient {
         return &APIClient{
-                endpoint: endpoint,
-                orgSlug:  orgSlug,
-                apiKey:   apiKey,
-                hostID:   hostID,
-                httpClient: &http.Client{
-                        Timeout: 30 * time.Second,
-                },
+                endpoint:      endpoint,
+                orgSlug:       orgSlug,
+                apiKey:        apiKey,
+                hostID:        hostID,
+                httpClient:    &http.Client{},
+                timeout:       30 * time.Second,
+                heartbeatPath: "/api/v2/heartbeat",
+        }
+}
+
+// NewFromConfig creates a client with the endpoint path and extra headers
+// from the agent configuration.
+func NewFromConfig(cfg *config.Config, hostID string) *APIClient {
+        c := New(cfg.APIEndpoint, cfg.OrganizationSlug, cfg.APIKey, hostID)
+        c.heartbeatPath = cfg.HeartbeatPath
+        c.headers = cfg.Headers
+        c.environment = cfg.Environment
+        c.datacenter = cfg.Datacenter
+        c.group = cfg.Group
+        c.timeout = time.Duration(cfg.HTTPTimeout) * time.Second
+        c.httpClient = &http.Client{Transport: newTransport(cfg)}
+        if cfg.AWSSigV4.Enabled {
+                c.signer = &sigv4Signer{
+                        region:  cfg.AWSSigV4.Region,
+                        service: cfg.AWSSigV4.Service,
+                        creds:   newAWSCredentialProvider(),
+                }
+        }
+        return c
+}
+
+// newTransport is the default transport, including proxy settings from the
+// environment, with the configured timeouts and egress. Dialing falls back
+// to the last resolved addresses when DNS fails.
+func newTransport(cfg *config.Config) *http.Transport {
+        t := http.DefaultTransport.(*http.Transport).Clone()
+        t.TLSHandshakeTimeout = time.Duration(cfg.TLSHandshakeTimeout) * time.Second
+        dialer := &net.Dialer{Timeout: time.Duration(cfg.ConnectTimeout) * time.Second, KeepAlive: 30 * time.Second}
+
+        if cfg.APISocket != "" {
+                // Every request goes to the sidecar; api_endpoint only
+                // provides the Host header, TLS server name and paths.
+                t.Proxy = nil
+                t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+                        return dialer.DialContext(ctx, "unix", cfg.APISocket)
+                }
+                return t
+        }
+
+        var source *sourceBinding
+        if cfg.SourceAddress != "" || cfg.SourceInterface != "" {
+                source = &sourceBinding{address: net.ParseIP(cfg.SourceAddress), iface: cfg.SourceInterface}
+        }
+        t.DialContext = newCachingDialer(dialer, source).DialContext
+        if cfg.SOCKS5Proxy != "" {
+                // The proxy resolves the endpoint's host name, as Tor
+                // requires.
+                proxy, _ := url.Parse(cfg.SOCKS5Proxy)
+                t.Proxy = http.ProxyURL(proxy)
         }
+        return t
 }
 
-func (c *APIClient) SendHeartbeat(heartbeat Heartbeat) error {
+func (c *APIClient) SendHeartbeat(ctx context.Context, heartbeat Heartbeat) (*HeartbeatResponse, error) {
+        if caps, legacy := c.negotiated(); caps == nil && !legacy {
+                // Retried on every send until the backend answers.
+                c.Negotiate(ctx)
+        }
+
+        payload, err := c.encodeHeartbeat(heartbeat)
+        if err != nil {
+                return nil, fmt.Errorf("failed to marshal heartbeat: %w", err)
+        }
+
+        hostID := c.GetHostID()
         request := HeartbeatRequest{
                 OrganizationSlug: c.orgSlug,
-                HostID:           c.hostID,
-                Heartbeat:        heartbeat,
+                HostID:           hostID,
+                SchemaVersion:    c.schemaVersion(),
+                Environment:      c.environment,
+                Datacenter:       c.datacenter,
+                Group:            c.group,
+                Heartbeat:        payload,
         }
 
         jsonData, err := json.Marshal(request)
         if err != nil {
-                return fmt.Errorf("failed to marshal heartbeat: %w", err)
+                return nil, fmt.Errorf("failed to marshal heartbeat: %w", err)
         }
 
-        url := fmt.Sprintf("%s/api/v2/heartbeat", c.endpoint)
-        req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+        ctx, cancel := context.WithTimeout(ctx, c.timeout)
+        defer cancel()
+
+        url := c.endpoint + c.heartbeatPath
+        req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
         if err != nil {
-                return fmt.Errorf("failed to create request: %w", err)
+                return nil, fmt.Errorf("failed to create request: %w", err)
         }
 
+        c.setCustomHeaders(req)
         req.Header.Set("Content-Type", "application/json")
         req.Header.Set("User-Agent", fmt.Sprintf("Sentinel-Agent/%s", heartbeat.AgentVersion))
+        if heartbeat.ID != "" {
+                req.Header.Set("Idempotency-Key", heartbeat.ID)
+        }
         
         if c.apiKey != "" {
                 req.Header.Set("X-API-Key", c.apiKey)
         }
+        if c.signer != nil {
+                if err := c.signer.sign(req, jsonData, time.Now()); err != nil {
+                        return nil, fmt.Errorf("failed to sign request: %w", err)
+                }
+        }
 
         resp, err := c.httpClient.Do(req)
         if err != nil {
-                return fmt.Errorf("failed to send request: %w", err)
+                return nil, fmt.Errorf("failed to send request: %w", err)
         }
         defer resp.Body.Close()
+        c.bytesSent.Add(uint64(len(jsonData)))
 
         body, err := io.ReadAll(resp.Body)
         if err != nil {
-                return fmt.Errorf("failed to read response: %w", err)
+                return nil, fmt.Errorf("failed to read response: %w", err)
         }
 
         if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -126,27 +365,113 @@ func (c *APIClient) SendHeartbeat(heartbeat Heartbeat) error {
                 if len(preview) > 200 {
                         preview = preview[:200] + "..."
                 }
-                return fmt.Errorf("server returned status %d: %s", resp.StatusCode, preview)
+                return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, preview)
         }
 
         // Check if response is JSON (not HTML)
         contentType := resp.Header.Get("Content-Type")
         if len(body) > 0 && body[0] == '<' {
-                return fmt.Errorf("server returned HTML instead of JSON. Check your api_endpoint URL. Response: %.100s...", string(body))
+                return nil, fmt.Errorf("server returned HTML instead of JSON. Check your api_endpoint URL. Response: %.100s...", string(body))
         }
 
         var response HeartbeatResponse
         if err := json.Unmarshal(body, &response); err != nil {
-                return fmt.Errorf("failed to parse response (Content-Type: %s): %w. Body: %.100s", contentType, err, string(body))
+                return nil, fmt.Errorf("failed to parse response (Content-Type: %s): %w. Body: %.100s", contentType, err, string(body))
         }
 
         if !response.Success {
-                return fmt.Errorf("heartbeat failed: %s", response.Message)
+                return nil, fmt.Errorf("heartbeat failed: %s", response.Message)
+        }
+
+        response.SentHostID = hostID
+        return &response, nil
+}
+
+// Get fetches a backend path (or absolute URL) with the agent's credentials.
+func (c *APIClient) Get(ctx context.Context, path string) ([]byte, error) {
+        target := path
+        if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+                target = c.endpoint + path
+        }
+
+        ctx, cancel := context.WithTimeout(ctx, c.timeout)
+        defer cancel()
+        req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+        if err != nil {
+                return nil, fmt.Errorf("failed to create request: %w", err)
+        }
+        // Absolute URLs, e.g. scripts on a CDN, get no credentials unless
+        // they point at the configured endpoint.
+        if c.ownsURL(req.URL) {
+                c.setCustomHeaders(req)
+                req.Header.Set("X-Organization-Slug", c.orgSlug)
+                req.Header.Set("X-Host-ID", c.GetHostID())
+                if c.apiKey != "" {
+                        req.Header.Set("X-API-Key", c.apiKey)
+                }
+                if c.signer != nil {
+                        if err := c.signer.sign(req, nil, time.Now()); err != nil {
+                                return nil, fmt.Errorf("failed to sign request: %w", err)
+                        }
+                }
+        }
+
+        resp, err := c.httpClient.Do(req)
+        if err != nil {
+                return nil, fmt.Errorf("failed to send request: %w", err)
         }
+        defer resp.Body.Close()
+
+        body, err := io.ReadAll(resp.Body)
+        if err != nil {
+                return nil, fmt.Errorf("failed to read response: %w", err)
+        }
+        if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+                return nil, &StatusError{StatusCode: resp.StatusCode, Path: path}
+        }
+        return body, nil
+}
+
+// ownsURL reports whether u has the scheme and host of the configured
+// endpoint.
+func (c *APIClient) ownsURL(u *url.URL) bool {
+        endpoint, err := url.Parse(c.endpoint)
+        return err == nil && strings.EqualFold(u.Scheme, endpoint.Scheme) && strings.EqualFold(u.Host, endpoint.Host)
+}
+
+// BytesSent returns the request bytes delivered to the backend so far.
+func (c *APIClient) BytesSent() uint64 {
+        return c.bytesSent.Load()
+}
+
+// setCustomHeaders applies configured static headers. They are set first so
+// they can never override the agent's own authentication headers.
+func (c *APIClient) setCustomHeaders(req *http.Request) {
+        for name, value := range c.headers {
+                req.Header.Set(name, value)
+        }
+}
+
+// StatusError reports a non-2xx response from the backend.
+type StatusError struct {
+        StatusCode int
+        Path       string
+}
+
+func (e *StatusError) Error() string {
+        return fmt.Sprintf("server returned status %d for %s", e.StatusCode, e.Path)
+}
 
-        return nil
+// SetHostID switches the client to a new host ID, e.g. after the backend
+// reported a duplicate.
+func (c *APIClient) SetHostID(hostID string) {
+        c.hostIDMu.Lock()
+        defer c.hostIDMu.Unlock()
+        c.hostID = hostID
 }
 
 func (c *APIClient) GetHostID() string {
+        c.hostIDMu.RLock()
+        defer c.hostIDMu.RUnlock()
         return c.hostID
 }
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	imdsEndpoint    = "http://169.254.169.254"
+	imdsTokenTTL    = "21600"
+	credentialSkew  = 5 * time.Minute
+	imdsHTTPTimeout = 2 * time.Second
+)
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// awsCredentialProvider resolves credentials from the standard environment
+// variables, falling back to the EC2 instance role via IMDSv2. Instance
+// role credentials are cached until shortly before they expire.
+type awsCredentialProvider struct {
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	cached *awsCredentials
+}
+
+func newAWSCredentialProvider() *awsCredentialProvider {
+	return &awsCredentialProvider{
+		httpClient: &http.Client{
+			Timeout: imdsHTTPTimeout,
+			// The metadata service must never be reached through a proxy.
+			Transport: &http.Transport{Proxy: nil},
+		},
+	}
+}
+
+func (p *awsCredentialProvider) get() (*awsCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cached != nil && time.Until(p.cached.Expiration) > credentialSkew {
+		return p.cached, nil
+	}
+
+	token, err := p.imdsToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IMDSv2 token: %w", err)
+	}
+	roles, err := p.imdsGet(token, "/latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return nil, fmt.Errorf("no instance role available: %w", err)
+	}
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if role == "" {
+		return nil, fmt.Errorf("no instance role attached")
+	}
+	data, err := p.imdsGet(token, "/latest/meta-data/iam/security-credentials/"+role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for role %s: %w", role, err)
+	}
+
+	var resp struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("invalid instance role credentials: %w", err)
+	}
+
+	p.cached = &awsCredentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+		Expiration:      resp.Expiration,
+	}
+	return p.cached, nil
+}
+
+// region returns the instance's region from instance metadata.
+func (p *awsCredentialProvider) region() (string, error) {
+	token, err := p.imdsToken()
+	if err != nil {
+		return "", err
+	}
+	data, err := p.imdsGet(token, "/latest/meta-data/placement/region")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (p *awsCredentialProvider) imdsToken() (string, error) {
+	req, err := http.NewRequest("PUT", imdsEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", imdsTokenTTL)
+	body, err := p.do(req)
+	return string(body), err
+}
+
+func (p *awsCredentialProvider) imdsGet(token, path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", imdsEndpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return p.do(req)
+}
+
+func (p *awsCredentialProvider) do(req *http.Request) ([]byte, error) {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instance metadata returned status %d for %s", resp.StatusCode, req.URL.Path)
+	}
+	return body, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"net"
+)
+
+// sourceBinding pins outbound connections to a source address or network
+// interface, e.g. a management VLAN on multi-homed hosts.
+type sourceBinding struct {
+	address net.IP
+	iface   string
+}
+
+// dialer returns a copy of base that connects to remote from the bound
+// source. An interface's address is looked up on every dial because it may
+// only be assigned after the agent starts.
+func (b *sourceBinding) dialer(base *net.Dialer, remote net.IP) (*net.Dialer, error) {
+	d := *base
+	if b.iface != "" {
+		bindToDevice(&d, b.iface)
+	}
+	local := b.address
+	if local == nil && b.iface != "" {
+		var err error
+		if local, err = interfaceAddress(b.iface, remote.To4() != nil); err != nil {
+			return nil, err
+		}
+	}
+	if local != nil {
+		if (local.To4() != nil) != (remote.To4() != nil) {
+			return nil, fmt.Errorf("source address %s cannot reach %s", local, remote)
+		}
+		d.LocalAddr = &net.TCPAddr{IP: local}
+	}
+	return &d, nil
+}
+
+// interfaceAddress returns the interface's first global unicast address of
+// the requested family.
+func interfaceAddress(name string, ipv4 bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("source interface: %w", err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("source interface %s: %w", name, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && ipNet.IP.IsGlobalUnicast() && (ipNet.IP.To4() != nil) == ipv4 {
+			return ipNet.IP, nil
+		}
+	}
+	family := "IPv6"
+	if ipv4 {
+		family = "IPv4"
+	}
+	return nil, fmt.Errorf("source interface %s has no %s address", name, family)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// bindToDevice makes the kernel send through the interface regardless of
+// the routing table. It requires CAP_NET_RAW.
+func bindToDevice(d *net.Dialer, name string) {
+	d.Control = func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, name)
+		})
+		if err != nil {
+			return err
+		}
+		if sockErr != nil {
+			return fmt.Errorf("bind to interface %s: %w", name, sockErr)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package client
+
+import "net"
+
+// bindToDevice is a no-op; binding to the interface's address is what
+// selects it on these platforms.
+func bindToDevice(d *net.Dialer, name string) {}
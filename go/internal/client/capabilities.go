@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// SchemaVersion is the heartbeat payload schema this agent produces.
+const SchemaVersion = 2
+
+const capabilitiesPath = "/api/v2/capabilities"
+
+// coreSections are understood by every backend and never stripped.
+var coreSections = map[string]bool{
+	"id":           true,
+	"sequence":     true,
+	"hostname":     true,
+	"agentVersion": true,
+	"agentStatus":  true,
+	"uptime":       true,
+	"collectedAt":  true,
+	"network":      true,
+	"metrics":      true,
+}
+
+// Capabilities is what the backend reports it accepts. Features lists the
+// optional heartbeat sections by their JSON name (e.g. "traceroute").
+type Capabilities struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Features      []string `json:"features"`
+}
+
+// Negotiate fetches the backend's capabilities. Backends that predate the
+// endpoint answer 404; they are sent the full payload as before.
+func (c *APIClient) Negotiate(ctx context.Context) error {
+	body, err := c.Get(ctx, capabilitiesPath)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		c.capsMu.Lock()
+		c.legacy = true
+		c.capsMu.Unlock()
+		log.Printf("Backend does not advertise capabilities; sending full payload")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("capabilities handshake failed: %w", err)
+	}
+
+	var caps Capabilities
+	if err := json.Unmarshal(body, &caps); err != nil {
+		return fmt.Errorf("invalid capabilities response: %w", err)
+	}
+	c.capsMu.Lock()
+	c.capabilities = &caps
+	c.capsMu.Unlock()
+
+	if caps.SchemaVersion < SchemaVersion {
+		log.Printf("Backend supports heartbeat schema v%d (agent v%d); unsupported sections will be omitted",
+			caps.SchemaVersion, SchemaVersion)
+	}
+	return nil
+}
+
+func (c *APIClient) negotiated() (*Capabilities, bool) {
+	c.capsMu.RLock()
+	defer c.capsMu.RUnlock()
+	return c.capabilities, c.legacy
+}
+
+func (c *APIClient) schemaVersion() int {
+	if caps, _ := c.negotiated(); caps != nil && caps.SchemaVersion > 0 {
+		return min(caps.SchemaVersion, SchemaVersion)
+	}
+	return SchemaVersion
+}
+
+// encodeHeartbeat marshals the heartbeat, dropping optional sections the
+// backend did not advertise so older backends don't reject the payload, and
+// sections routed elsewhere.
+func (c *APIClient) encodeHeartbeat(heartbeat Heartbeat) (json.RawMessage, error) {
+	data, err := json.Marshal(heartbeat)
+	caps, _ := c.negotiated()
+	if err != nil || (caps == nil && c.include == nil && c.exclude == nil) {
+		return data, err
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, err
+	}
+
+	var supported map[string]bool
+	if caps != nil {
+		supported = make(map[string]bool, len(caps.Features))
+		for _, feature := range caps.Features {
+			supported[feature] = true
+		}
+	}
+	for name := range sections {
+		if coreSections[name] {
+			continue
+		}
+		if (supported != nil && !supported[name]) || (c.include != nil && !c.include[name]) || c.exclude[name] {
+			delete(sections, name)
+		}
+	}
+	return json.Marshal(sections)
+}
+
+// Route limits the optional heartbeat sections this client sends to include
+// (all when nil) minus exclude, by JSON name.
+func (c *APIClient) Route(include, exclude []string) {
+	if include != nil {
+		c.include = make(map[string]bool, len(include))
+		for _, name := range include {
+			c.include[name] = true
+		}
+	}
+	if len(exclude) > 0 {
+		c.exclude = make(map[string]bool, len(exclude))
+		for _, name := range exclude {
+			c.exclude[name] = true
+		}
+	}
+}
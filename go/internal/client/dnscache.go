@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+)
+
+// cachingDialer remembers the addresses each host last resolved to and
+// dials those when DNS fails, so an outage of the host's resolver does not
+// cut the agent off from the backend. TLS still verifies the certificate
+// against the request's host name, which is also sent as SNI.
+type cachingDialer struct {
+	dialer   *net.Dialer
+	resolver *net.Resolver
+	source   *sourceBinding
+
+	mu    sync.Mutex
+	addrs map[string][]net.IPAddr
+}
+
+func newCachingDialer(dialer *net.Dialer, source *sourceBinding) *cachingDialer {
+	return &cachingDialer{dialer: dialer, resolver: net.DefaultResolver, source: source, addrs: make(map[string][]net.IPAddr)}
+}
+
+func (d *cachingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs := []net.IPAddr{{IP: net.ParseIP(host)}}
+	if addrs[0].IP == nil {
+		if addrs, err = d.lookup(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+	var lastErr error
+	for _, addr := range addrs {
+		dialer := d.dialer
+		if d.source != nil {
+			if dialer, err = d.source.dialer(d.dialer, addr.IP); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (d *cachingDialer) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = &net.DNSError{Err: "no addresses", Name: host}
+	}
+	if err == nil {
+		d.mu.Lock()
+		d.addrs[host] = addrs
+		d.mu.Unlock()
+		return addrs, nil
+	}
+
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || ctx.Err() != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	cached := d.addrs[host]
+	d.mu.Unlock()
+	if len(cached) == 0 {
+		return nil, err
+	}
+	ips := make([]string, len(cached))
+	for i, addr := range cached {
+		ips[i] = addr.String()
+	}
+	log.Printf("DNS lookup for %s failed (%v); using last known addresses %s", host, err, strings.Join(ips, ", "))
+	return cached, nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const enrollPath = "/api/v2/enroll"
+
+type EnrollRequest struct {
+	Token        string `json:"token"`
+	Hostname     string `json:"hostname"`
+	Fingerprint  string `json:"fingerprint"`
+	HostID       string `json:"hostId,omitempty"`
+	OS           string `json:"os"`
+	Arch         string `json:"arch"`
+	AgentVersion string `json:"agentVersion"`
+}
+
+// EnrollResponse carries the identity assigned by the backend. APIEndpoint
+// is optional and lets the backend move the host to a regional collector.
+type EnrollResponse struct {
+	OrganizationSlug string `json:"organizationSlug"`
+	APIKey           string `json:"apiKey"`
+	HostID           string `json:"hostId"`
+	APIEndpoint      string `json:"apiEndpoint,omitempty"`
+}
+
+// Enroll exchanges a one-time enrollment token for credentials.
+func Enroll(ctx context.Context, endpoint string, request EnrollRequest) (*EnrollResponse, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint+enrollPath, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("Sentinel-Agent/%s", request.AgentVersion))
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Path: enrollPath}
+	}
+
+	var response EnrollResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse enrollment response: %w", err)
+	}
+	if response.OrganizationSlug == "" || response.APIKey == "" {
+		return nil, fmt.Errorf("enrollment response is missing organizationSlug or apiKey")
+	}
+	return &response, nil
+}
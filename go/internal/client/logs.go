@@ -0,0 +1,45 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SendLogs posts a gzip-compressed JSON batch of log lines to path.
+func (c *APIClient) SendLogs(ctx context.Context, path string, gzipped []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+path, bytes.NewReader(gzipped))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setCustomHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-Organization-Slug", c.orgSlug)
+	req.Header.Set("X-Host-ID", c.GetHostID())
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.signer != nil {
+		if err := c.signer.sign(req, gzipped, time.Now()); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send logs: %w", err)
+	}
+	defer resp.Body.Close()
+	c.bytesSent.Add(uint64(len(gzipped)))
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Path: path}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"context"
+	"os/user"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"sentinel-agent/internal/config"
+)
+
+type AccountingInfo struct {
+	IntervalSeconds float64      `json:"intervalSeconds"`
+	Users           []UsageEntry `json:"users"`
+	Cgroups         []UsageEntry `json:"cgroups,omitempty"`
+}
+
+// UsageEntry is the consumption of one user or cgroup. CPUPercent is
+// relative to a single core, as in top.
+type UsageEntry struct {
+	Name        string  `json:"name"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryBytes uint64  `json:"memoryBytes"`
+	Processes   int     `json:"processes,omitempty"`
+}
+
+type AccountingCollector struct {
+	topN        int
+	cgroupDepth int
+
+	previousTime   time.Time
+	previousCPU    map[int32]float64
+	previousCgroup map[string]uint64
+	usernames      usernameCache
+}
+
+func NewAccountingCollector(cfg config.AccountingConfig) *AccountingCollector {
+	return &AccountingCollector{
+		topN:        cfg.TopN,
+		cgroupDepth: cfg.CgroupDepth,
+		usernames:   make(usernameCache),
+	}
+}
+
+// Rebaseline discards the previous CPU times, e.g. after a resume from
+// suspend, so the next call establishes a new baseline.
+func (c *AccountingCollector) Rebaseline() {
+	c.previousCPU, c.previousCgroup = nil, nil
+}
+
+// Collect aggregates CPU and memory by user and by cgroup. CPU usage is
+// measured between calls, so the first call establishes a baseline and
+// returns nil.
+func (c *AccountingCollector) Collect(ctx context.Context) (*AccountingInfo, error) {
+	now := time.Now()
+	users, cpuByPID, err := c.collectUsers()
+	if err != nil {
+		return nil, err
+	}
+	cgroups, cpuByCgroup := readCgroupUsage(c.cgroupDepth)
+
+	prevTime, prevCPU, prevCgroup := c.previousTime, c.previousCPU, c.previousCgroup
+	c.previousTime, c.previousCPU, c.previousCgroup = now, cpuByPID.total, cpuByCgroup
+	if prevCPU == nil {
+		return nil, nil
+	}
+	elapsed := now.Sub(prevTime).Seconds()
+
+	info := &AccountingInfo{IntervalSeconds: elapsed}
+	for name, entry := range users {
+		var cpu float64
+		for _, pid := range cpuByPID.pids[name] {
+			// Processes started during the interval are counted from the
+			// next call, as their lifetime CPU cannot be split reliably.
+			if prev, ok := prevCPU[pid]; ok {
+				cpu += cpuByPID.total[pid] - prev
+			}
+		}
+		entry.CPUPercent = max(cpu, 0) / elapsed * 100
+		info.Users = append(info.Users, *entry)
+	}
+	for name, entry := range cgroups {
+		if prev, ok := prevCgroup[name]; ok && cpuByCgroup[name] >= prev {
+			entry.CPUPercent = float64(cpuByCgroup[name]-prev) / 1e6 / elapsed * 100
+		}
+		info.Cgroups = append(info.Cgroups, *entry)
+	}
+	info.Users = topUsage(info.Users, c.topN)
+	info.Cgroups = topUsage(info.Cgroups, c.topN)
+	return info, nil
+}
+
+type processCPU struct {
+	total map[int32]float64
+	pids  map[string][]int32
+}
+
+func (c *AccountingCollector) collectUsers() (map[string]*UsageEntry, processCPU, error) {
+	cpu := processCPU{total: make(map[int32]float64), pids: make(map[string][]int32)}
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, cpu, err
+	}
+
+	users := make(map[string]*UsageEntry)
+	for _, p := range procs {
+		uids, err := p.Uids()
+		if err != nil || len(uids) == 0 {
+			continue
+		}
+		name := c.usernames.lookup(uids[0])
+		entry, ok := users[name]
+		if !ok {
+			entry = &UsageEntry{Name: name}
+			users[name] = entry
+		}
+		entry.Processes++
+		if mem, err := p.MemoryInfo(); err == nil {
+			entry.MemoryBytes += mem.RSS
+		}
+		if times, err := p.Times(); err == nil {
+			cpu.total[p.Pid] = times.User + times.System
+			cpu.pids[name] = append(cpu.pids[name], p.Pid)
+		}
+	}
+	return users, cpu, nil
+}
+
+// usernameCache maps UIDs to user names, falling back to the UID.
+type usernameCache map[string]string
+
+func (c usernameCache) lookup(uid int32) string {
+	id := strconv.Itoa(int(uid))
+	if name, ok := c[id]; ok {
+		return name
+	}
+	name := id
+	if u, err := user.LookupId(id); err == nil {
+		name = u.Username
+	}
+	c[id] = name
+	return name
+}
+
+func topUsage(entries []UsageEntry, n int) []UsageEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CPUPercent != entries[j].CPUPercent {
+			return entries[i].CPUPercent > entries[j].CPUPercent
+		}
+		return entries[i].MemoryBytes > entries[j].MemoryBytes
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// readCgroupUsage reads memory.current and the CPU time (microseconds)
+// of cgroup v2 groups up to depth levels below the root, e.g.
+// system.slice and system.slice/nginx.service for depth 2.
+func readCgroupUsage(depth int) (map[string]*UsageEntry, map[string]uint64) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, nil
+	}
+
+	entries := make(map[string]*UsageEntry)
+	cpu := make(map[string]uint64)
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, child := range children {
+			if !child.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, child.Name())
+			name := strings.TrimPrefix(path, cgroupRoot+"/")
+
+			entry := &UsageEntry{Name: name}
+			if data, err := os.ReadFile(filepath.Join(path, "memory.current")); err == nil {
+				entry.MemoryBytes, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+			}
+			if usage, ok := cgroupCPUUsage(path); ok {
+				cpu[name] = usage
+			}
+			entries[name] = entry
+
+			if level < depth {
+				walk(path, level+1)
+			}
+		}
+	}
+	walk(cgroupRoot, 1)
+	return entries, cpu
+}
+
+func cgroupCPUUsage(path string) (uint64, bool) {
+	f, err := os.Open(filepath.Join(path, "cpu.stat"))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "usage_usec "); ok {
+			usage, err := strconv.ParseUint(value, 10, 64)
+			return usage, err == nil
+		}
+	}
+	return 0, false
+}
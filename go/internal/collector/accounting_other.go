@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+func readCgroupUsage(depth int) (map[string]*UsageEntry, map[string]uint64) {
+	return nil, nil
+}
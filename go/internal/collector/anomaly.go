@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"math"
+	"sort"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+// minStddev keeps metrics that hardly ever move, such as an idle disk's
+// usage, from flagging tiny changes.
+const minStddev = 0.5
+
+// Anomaly is a value Score standard deviations away from its baseline.
+type Anomaly struct {
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+	Mean   float64 `json:"mean"`
+	Stddev float64 `json:"stddev"`
+	Score  float64 `json:"score"`
+}
+
+// baseline is an exponentially weighted mean and variance.
+type baseline struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Samples  int     `json:"samples"`
+}
+
+// AnomalyDetector keeps a rolling baseline per metric in the state store,
+// so it survives restarts, and flags values that deviate from it.
+type AnomalyDetector struct {
+	alpha     float64
+	threshold float64
+	warmup    int
+	state     *state.Bucket
+}
+
+func NewAnomalyDetector(cfg config.AnomaliesConfig, bucket *state.Bucket) *AnomalyDetector {
+	return &AnomalyDetector{alpha: cfg.Alpha, threshold: cfg.Threshold, warmup: cfg.Warmup, state: bucket}
+}
+
+// Observe checks each value against its baseline, then folds it in. Nothing
+// is flagged until a metric has seen the warmup number of samples.
+func (d *AnomalyDetector) Observe(values map[string]float64) []Anomaly {
+	metrics := make([]string, 0, len(values))
+	for metric := range values {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	var anomalies []Anomaly
+	for _, metric := range metrics {
+		value := values[metric]
+		var b baseline
+		if !d.state.Get(metric, &b) {
+			b.Mean = value
+		}
+
+		stddev := math.Sqrt(b.Variance)
+		if b.Samples >= d.warmup {
+			if score := (value - b.Mean) / math.Max(stddev, minStddev); math.Abs(score) >= d.threshold {
+				anomalies = append(anomalies, Anomaly{Metric: metric, Value: value, Mean: b.Mean, Stddev: stddev, Score: score})
+			}
+		}
+
+		diff := value - b.Mean
+		increment := d.alpha * diff
+		b.Mean += increment
+		b.Variance = (1 - d.alpha) * (b.Variance + diff*increment)
+		b.Samples++
+		d.state.Put(metric, b)
+	}
+	return anomalies
+}
@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+type AuthorizedKeysInfo struct {
+	Keys []AuthorizedKey `json:"keys"`
+}
+
+// AuthorizedKey identifies a key by fingerprint only. Restricted is set
+// when the entry carries options such as command= or from=.
+type AuthorizedKey struct {
+	User        string `json:"user"`
+	File        string `json:"file"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Restricted  bool   `json:"restricted,omitempty"`
+}
+
+// AuthorizedKeysCollector inventories SSH authorized_keys files. The
+// previous inventory is kept in the state store so keys added while the
+// agent was stopped are reported too.
+type AuthorizedKeysCollector struct {
+	schedule schedule
+	events   *EventBuffer
+	state    *state.Bucket
+}
+
+func NewAuthorizedKeysCollector(cfg config.AuthorizedKeysConfig, events *EventBuffer, bucket *state.Bucket) *AuthorizedKeysCollector {
+	return &AuthorizedKeysCollector{
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		state:    bucket,
+	}
+}
+
+func (c *AuthorizedKeysCollector) Collect(ctx context.Context) (*AuthorizedKeysInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	files, err := authorizedKeyFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AuthorizedKeysInfo{Keys: []AuthorizedKey{}}
+	users := make([]string, 0, len(files))
+	for user := range files {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	for _, user := range users {
+		for _, path := range files[user] {
+			keys, err := readAuthorizedKeys(user, path)
+			if err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			info.Keys = append(info.Keys, keys...)
+		}
+	}
+
+	current := make(map[string]AuthorizedKey, len(info.Keys))
+	for _, key := range info.Keys {
+		current[key.User+" "+key.Fingerprint] = key
+	}
+	var previous map[string]AuthorizedKey
+	if c.state.Get("keys", &previous) {
+		for id, key := range current {
+			if _, ok := previous[id]; !ok {
+				c.events.Add("ssh_key_added", SeverityWarning,
+					fmt.Sprintf("SSH key %s was authorized for %s", key.Fingerprint, key.User), keyAttributes(key))
+			}
+		}
+		for id, key := range previous {
+			if _, ok := current[id]; !ok {
+				c.events.Add("ssh_key_removed", SeverityInfo,
+					fmt.Sprintf("SSH key %s is no longer authorized for %s", key.Fingerprint, key.User), keyAttributes(key))
+			}
+		}
+	}
+	if err := c.state.Put("keys", current); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func keyAttributes(key AuthorizedKey) map[string]string {
+	return map[string]string{"user": key.User, "file": key.File, "type": key.Type, "fingerprint": key.Fingerprint}
+}
+
+func readAuthorizedKeys(user, path string) ([]AuthorizedKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []AuthorizedKey
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pub, _, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, AuthorizedKey{
+			User:        user,
+			File:        path,
+			Type:        pub.Type(),
+			Fingerprint: ssh.FingerprintSHA256(pub),
+			Restricted:  len(options) > 0,
+		})
+	}
+	return keys, scanner.Err()
+}
+
+// sshdKeyFiles returns the AuthorizedKeysFile patterns from sshd_config,
+// or fallback when the option is not set.
+func sshdKeyFiles(configPath string, fallback []string) []string {
+	f, err := os.Open(configPath)
+	if err != nil {
+		return fallback
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if strings.EqualFold(fields[0], "Match") {
+			// Only the global section applies to every user.
+			break
+		}
+		if strings.EqualFold(fields[0], "AuthorizedKeysFile") {
+			if len(fields) == 2 && fields[1] == "none" {
+				return nil
+			}
+			return fields[1:]
+		}
+	}
+	return fallback
+}
+
+// expandKeyFile applies the sshd_config %h, %u and %% tokens. Relative
+// paths are relative to the home directory.
+func expandKeyFile(pattern, user, home string) string {
+	path := strings.NewReplacer("%%", "%", "%h", home, "%u", user).Replace(pattern)
+	if !strings.HasPrefix(path, "/") {
+		path = strings.TrimSuffix(home, "/") + "/" + path
+	}
+	return path
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package collector
+
+// authorizedKeyFiles maps local users with a home directory to their
+// authorized_keys files.
+func authorizedKeyFiles() (map[string][]string, error) {
+	entries, err := readPasswd("/etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+	patterns := sshdKeyFiles("/etc/ssh/sshd_config", []string{".ssh/authorized_keys", ".ssh/authorized_keys2"})
+	files := make(map[string][]string)
+	for _, e := range entries {
+		if e.Home == "" || e.Home == "/" || e.Home == "/nonexistent" {
+			continue
+		}
+		for _, pattern := range patterns {
+			files[e.Name] = append(files[e.Name], expandKeyFile(pattern, e.Name, e.Home))
+		}
+	}
+	return files, nil
+}
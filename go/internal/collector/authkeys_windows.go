@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// authorizedKeyFiles maps profiles under C:\Users to their authorized_keys
+// files. Keys of administrators live in one shared file, reported under
+// the user "administrators".
+func authorizedKeyFiles() (map[string][]string, error) {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	usersDir := filepath.Join(filepath.VolumeName(programData)+`\`, "Users")
+	profiles, err := os.ReadDir(usersDir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]string)
+	for _, p := range profiles {
+		if p.IsDir() {
+			files[p.Name()] = []string{filepath.Join(usersDir, p.Name(), ".ssh", "authorized_keys")}
+		}
+	}
+	files["administrators"] = []string{filepath.Join(programData, "ssh", "administrators_authorized_keys")}
+	return files, nil
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pmsetSourceRe  = regexp.MustCompile(`Now drawing from '([^']+)'`)
+	pmsetBatteryRe = regexp.MustCompile(`(\d+)%;\s*([^;]+);`)
+	ioregIntRe     = regexp.MustCompile(`"(\w+)" = (\d+)`)
+)
+
+func readBatteries(ctx context.Context) ([]BatteryInfo, error) {
+	if battery := readMacBattery(ctx); battery != nil {
+		return []BatteryInfo{*battery}, nil
+	}
+	return nil, nil
+}
+
+func readMacBattery(ctx context.Context) *BatteryInfo {
+	out, err := runCommand(ctx, 0, "pmset", "-g", "batt")
+	if err != nil {
+		return nil
+	}
+	battery := parsePmsetBattery(out)
+	if battery == nil {
+		return nil
+	}
+	if out, err := runCommand(ctx, 0, "ioreg", "-rn", "AppleSmartBattery"); err == nil {
+		values := make(map[string]int)
+		for _, m := range ioregIntRe.FindAllSubmatch(out, -1) {
+			values[string(m[1])], _ = strconv.Atoi(string(m[2]))
+		}
+		battery.CycleCount = values["CycleCount"]
+		maxCapacity := values["AppleRawMaxCapacity"]
+		if maxCapacity == 0 {
+			maxCapacity = values["NominalChargeCapacity"]
+		}
+		if design := values["DesignCapacity"]; design > 0 && maxCapacity > 0 {
+			battery.HealthPercent = float64(maxCapacity) / float64(design) * 100
+		}
+	}
+	return battery
+}
+
+// parsePmsetBattery parses `pmset -g batt`:
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234)	85%; discharging; 3:12 remaining present: true
+func parsePmsetBattery(out []byte) *BatteryInfo {
+	m := pmsetBatteryRe.FindSubmatch(out)
+	if m == nil {
+		// Desktops (Mac mini, Mac Studio) have no internal battery.
+		return nil
+	}
+
+	battery := &BatteryInfo{State: strings.TrimSpace(string(m[2]))}
+	battery.Percent, _ = strconv.Atoi(string(m[1]))
+	if s := pmsetSourceRe.FindSubmatch(out); s != nil {
+		battery.PowerSource = string(s[1])
+	}
+	return battery
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+func readBatteries(ctx context.Context) ([]BatteryInfo, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	source := "Battery Power"
+	var batteries []BatteryInfo
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		switch readSysfsString(dir, "type") {
+		case "Mains":
+			if readSysfsString(dir, "online") == "1" {
+				source = "AC Power"
+			}
+		case "Battery":
+			// Peripheral batteries (mice, keyboards) report scope Device.
+			if readSysfsString(dir, "scope") == "Device" || readSysfsString(dir, "present") == "0" {
+				continue
+			}
+			battery := BatteryInfo{
+				Name:  entry.Name(),
+				State: strings.ToLower(readSysfsString(dir, "status")),
+			}
+			battery.Percent, _ = strconv.Atoi(readSysfsString(dir, "capacity"))
+			battery.CycleCount, _ = strconv.Atoi(readSysfsString(dir, "cycle_count"))
+			// Batteries report either energy (µWh) or charge (µAh).
+			for _, prefix := range []string{"energy", "charge"} {
+				full, _ := strconv.ParseFloat(readSysfsString(dir, prefix+"_full"), 64)
+				design, _ := strconv.ParseFloat(readSysfsString(dir, prefix+"_full_design"), 64)
+				if full > 0 && design > 0 {
+					battery.HealthPercent = full / design * 100
+					break
+				}
+			}
+			batteries = append(batteries, battery)
+		}
+	}
+	for i := range batteries {
+		batteries[i].PowerSource = source
+	}
+	return batteries, nil
+}
+
+func readSysfsString(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package collector
+
+import "context"
+
+func readBatteries(ctx context.Context) ([]BatteryInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yusufpapurcu/wmi"
+)
+
+type win32Battery struct {
+	Name                     string
+	EstimatedChargeRemaining uint16
+	BatteryStatus            uint16
+}
+
+// win32BatteryStates maps Win32_Battery.BatteryStatus.
+var win32BatteryStates = map[uint16]string{
+	1:  "discharging",
+	2:  "ac attached",
+	3:  "charged",
+	4:  "low",
+	5:  "critical",
+	6:  "charging",
+	7:  "charging",
+	8:  "charging",
+	9:  "charging",
+	10: "unknown",
+	11: "partially charged",
+}
+
+func readBatteries(ctx context.Context) ([]BatteryInfo, error) {
+	var result []win32Battery
+	if err := wmi.Query("SELECT Name, EstimatedChargeRemaining, BatteryStatus FROM Win32_Battery", &result); err != nil {
+		return nil, fmt.Errorf("failed to query batteries: %w", err)
+	}
+
+	var batteries []BatteryInfo
+	for _, b := range result {
+		battery := BatteryInfo{
+			Name:        b.Name,
+			Percent:     int(b.EstimatedChargeRemaining),
+			State:       win32BatteryStates[b.BatteryStatus],
+			PowerSource: "AC Power",
+		}
+		if b.BatteryStatus == 1 || b.BatteryStatus == 4 || b.BatteryStatus == 5 {
+			battery.PowerSource = "Battery Power"
+		}
+		batteries = append(batteries, battery)
+	}
+	return batteries, nil
+}
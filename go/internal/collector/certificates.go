@@ -0,0 +1,229 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"sentinel-agent/internal/config"
+)
+
+const maxCertificateFileSize = 1 << 20
+
+var certificateExtensions = map[string]bool{
+	".pem":  true,
+	".crt":  true,
+	".cer":  true,
+	".cert": true,
+	".der":  true,
+	".p12":  true,
+	".pfx":  true,
+}
+
+type CertificatesInfo struct {
+	Certificates []CertificateInfo `json:"certificates"`
+	Expiring     int               `json:"expiring"`
+	Expired      int               `json:"expired"`
+}
+
+type CertificateInfo struct {
+	Path        string    `json:"path"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	SANs        []string  `json:"sans,omitempty"`
+	Serial      string    `json:"serial"`
+	Fingerprint string    `json:"fingerprint"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	DaysLeft    int       `json:"daysLeft"`
+	IsCA        bool      `json:"isCa,omitempty"`
+}
+
+type CertificateCollector struct {
+	paths     []string
+	passwords []string
+	warnDays  int
+	schedule  schedule
+	events    *EventBuffer
+
+	// states tracks "ok", "expiring" or "expired" per fingerprint and path
+	// so each certificate raises an event only when its state changes.
+	states map[string]string
+}
+
+func NewCertificateCollector(cfg config.CertificatesConfig, events *EventBuffer) *CertificateCollector {
+	return &CertificateCollector{
+		paths:     cfg.Paths,
+		passwords: append([]string{""}, cfg.Passwords...),
+		warnDays:  cfg.WarnDays,
+		schedule:  newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:    events,
+		states:    make(map[string]string),
+	}
+}
+
+// Collect scans the configured files and directories for PEM, DER and
+// PKCS#12 certificates. Unreadable files and files without certificates
+// are skipped.
+func (c *CertificateCollector) Collect(ctx context.Context) (*CertificatesInfo, error) {
+	now := time.Now()
+	if !c.schedule.due(now) {
+		return nil, nil
+	}
+
+	info := &CertificatesInfo{Certificates: make([]CertificateInfo, 0)}
+	for _, root := range c.paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if !certificateExtensions[strings.ToLower(filepath.Ext(path))] && path != root {
+				return nil
+			}
+			for _, cert := range c.readCertificates(path) {
+				info.Certificates = append(info.Certificates, certificateInfo(path, cert, now))
+			}
+			return nil
+		})
+	}
+	sort.Slice(info.Certificates, func(i, j int) bool {
+		return info.Certificates[i].NotAfter.Before(info.Certificates[j].NotAfter)
+	})
+
+	seen := make(map[string]bool)
+	for _, cert := range info.Certificates {
+		key := cert.Fingerprint + " " + cert.Path
+		seen[key] = true
+
+		state := "ok"
+		switch {
+		case cert.DaysLeft < 0:
+			state = "expired"
+			info.Expired++
+		case cert.DaysLeft < c.warnDays:
+			state = "expiring"
+			info.Expiring++
+		}
+		if previous := c.states[key]; state != previous && (state != "ok" || previous != "") {
+			c.certificateEvent(cert, state)
+		}
+		c.states[key] = state
+	}
+	for key := range c.states {
+		if !seen[key] {
+			delete(c.states, key)
+		}
+	}
+	return info, nil
+}
+
+func (c *CertificateCollector) certificateEvent(cert CertificateInfo, state string) {
+	attrs := map[string]string{
+		"path":     cert.Path,
+		"subject":  cert.Subject,
+		"notAfter": cert.NotAfter.Format(time.RFC3339),
+	}
+	switch state {
+	case "expired":
+		c.events.Add("certificate_expired", SeverityCritical,
+			fmt.Sprintf("Certificate %s in %s expired on %s", cert.Subject, cert.Path, cert.NotAfter.Format("2006-01-02")), attrs)
+	case "expiring":
+		c.events.Add("certificate_expiring", SeverityWarning,
+			fmt.Sprintf("Certificate %s in %s expires in %d days", cert.Subject, cert.Path, cert.DaysLeft), attrs)
+	case "ok":
+		c.events.Add("certificate_renewed", SeverityInfo,
+			fmt.Sprintf("Certificate %s in %s is valid until %s", cert.Subject, cert.Path, cert.NotAfter.Format("2006-01-02")), attrs)
+	}
+}
+
+func (c *CertificateCollector) readCertificates(path string) []*x509.Certificate {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() > maxCertificateFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".p12", ".pfx":
+		for _, password := range c.passwords {
+			blocks, err := pkcs12.ToPEM(data, password)
+			if err == nil {
+				return certificatesFromBlocks(blocks)
+			}
+		}
+		return nil
+	}
+
+	var blocks []*pem.Block
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) > 0 {
+		return certificatesFromBlocks(blocks)
+	}
+	if cert, err := x509.ParseCertificate(data); err == nil {
+		return []*x509.Certificate{cert}
+	}
+	return nil
+}
+
+func certificatesFromBlocks(blocks []*pem.Block) []*x509.Certificate {
+	certs := make([]*x509.Certificate, 0)
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
+
+func certificateInfo(path string, cert *x509.Certificate, now time.Time) CertificateInfo {
+	sum := sha256.Sum256(cert.Raw)
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	daysLeft := int(cert.NotAfter.Sub(now).Hours() / 24)
+	if cert.NotAfter.Before(now) {
+		daysLeft = -1 - int(now.Sub(cert.NotAfter).Hours()/24)
+	}
+	return CertificateInfo{
+		Path:        path,
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		SANs:        sans,
+		Serial:      cert.SerialNumber.Text(16),
+		Fingerprint: hex.EncodeToString(sum[:]),
+		NotBefore:   cert.NotBefore.UTC(),
+		NotAfter:    cert.NotAfter.UTC(),
+		DaysLeft:    daysLeft,
+		IsCA:        cert.IsCA,
+	}
+}
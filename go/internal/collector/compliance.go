@@ -0,0 +1,224 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	CompliancePass  = "pass"
+	ComplianceFail  = "fail"
+	ComplianceError = "error"
+)
+
+type ComplianceInfo struct {
+	Passed  int              `json:"passed"`
+	Failed  int              `json:"failed"`
+	Errors  int              `json:"errors"`
+	Results []ComplianceRule `json:"results"`
+}
+
+type ComplianceRule struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ComplianceRuleSpec is the YAML representation of a rule in a rule file:
+//
+//	rules:
+//	  - id: ssh-no-empty-passwords
+//	    title: SSH disallows empty passwords
+//	    type: file_regex
+//	    path: /etc/ssh/sshd_config
+//	    pattern: '(?mi)^\s*PermitEmptyPasswords\s+no\b'
+//	    severity: high
+type ComplianceRuleSpec struct {
+	ID       string `yaml:"id"`
+	Title    string `yaml:"title"`
+	Type     string `yaml:"type"`
+	Severity string `yaml:"severity"`
+	Path     string `yaml:"path"`
+	Pattern  string `yaml:"pattern"`
+	Negate   bool   `yaml:"negate"`
+	MaxMode  string `yaml:"max_mode"`
+}
+
+type complianceCheck struct {
+	id       string
+	title    string
+	severity string
+	run      func() (bool, string, error)
+}
+
+type ComplianceCollector struct {
+	checks   []complianceCheck
+	schedule schedule
+}
+
+func NewComplianceCollector(cfg config.ComplianceConfig) (*ComplianceCollector, error) {
+	disabled := make(map[string]bool)
+	for _, id := range cfg.DisableBuiltin {
+		disabled[id] = true
+	}
+
+	c := &ComplianceCollector{
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+	}
+	for _, check := range builtinComplianceChecks() {
+		if !disabled[check.id] {
+			c.checks = append(c.checks, check)
+		}
+	}
+
+	for _, pattern := range cfg.RuleFiles {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compliance rule file pattern %q: %w", pattern, err)
+		}
+		for _, path := range paths {
+			checks, err := loadComplianceRules(path)
+			if err != nil {
+				return nil, err
+			}
+			c.checks = append(c.checks, checks...)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *ComplianceCollector) Collect(ctx context.Context) (*ComplianceInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	info := &ComplianceInfo{Results: make([]ComplianceRule, 0, len(c.checks))}
+	for _, check := range c.checks {
+		result := ComplianceRule{ID: check.id, Title: check.title, Severity: check.severity}
+
+		passed, detail, err := check.run()
+		switch {
+		case err != nil:
+			result.Status = ComplianceError
+			result.Detail = err.Error()
+			info.Errors++
+		case passed:
+			result.Status = CompliancePass
+			info.Passed++
+		default:
+			result.Status = ComplianceFail
+			result.Detail = detail
+			info.Failed++
+		}
+		info.Results = append(info.Results, result)
+	}
+
+	return info, nil
+}
+
+func loadComplianceRules(path string) ([]complianceCheck, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compliance rules %s: %w", path, err)
+	}
+
+	var file struct {
+		Rules []ComplianceRuleSpec `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse compliance rules %s: %w", path, err)
+	}
+
+	checks := make([]complianceCheck, 0, len(file.Rules))
+	for _, spec := range file.Rules {
+		check, err := compileComplianceRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func compileComplianceRule(spec ComplianceRuleSpec) (complianceCheck, error) {
+	if spec.ID == "" {
+		return complianceCheck{}, fmt.Errorf("compliance rule without id")
+	}
+	if spec.Path == "" {
+		return complianceCheck{}, fmt.Errorf("compliance rule %s: path is required", spec.ID)
+	}
+
+	check := complianceCheck{id: spec.ID, title: spec.Title, severity: spec.Severity}
+	if check.title == "" {
+		check.title = spec.ID
+	}
+	if check.severity == "" {
+		check.severity = "medium"
+	}
+
+	switch spec.Type {
+	case "file_regex":
+		re, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return complianceCheck{}, fmt.Errorf("compliance rule %s: invalid pattern: %w", spec.ID, err)
+		}
+		check.run = func() (bool, string, error) {
+			data, err := os.ReadFile(spec.Path)
+			if err != nil {
+				return false, "", err
+			}
+			matched := re.Match(data)
+			if matched == spec.Negate {
+				if spec.Negate {
+					return false, fmt.Sprintf("%s matches %s", spec.Path, spec.Pattern), nil
+				}
+				return false, fmt.Sprintf("%s does not match %s", spec.Path, spec.Pattern), nil
+			}
+			return true, "", nil
+		}
+	case "file_mode":
+		maxMode, err := strconv.ParseUint(spec.MaxMode, 8, 32)
+		if err != nil {
+			return complianceCheck{}, fmt.Errorf("compliance rule %s: invalid max_mode %q", spec.ID, spec.MaxMode)
+		}
+		check.run = func() (bool, string, error) {
+			st, err := os.Stat(spec.Path)
+			if err != nil {
+				return false, "", err
+			}
+			mode := uint64(st.Mode().Perm())
+			if mode&^maxMode != 0 {
+				return false, fmt.Sprintf("%s has mode %04o, expected at most %04o", spec.Path, mode, maxMode), nil
+			}
+			return true, "", nil
+		}
+	case "file_exists":
+		check.run = func() (bool, string, error) {
+			_, err := os.Stat(spec.Path)
+			exists := err == nil
+			if exists == spec.Negate {
+				if spec.Negate {
+					return false, fmt.Sprintf("%s exists", spec.Path), nil
+				}
+				return false, fmt.Sprintf("%s does not exist", spec.Path), nil
+			}
+			return true, "", nil
+		}
+	default:
+		return complianceCheck{}, fmt.Errorf("compliance rule %s: unknown type %q", spec.ID, spec.Type)
+	}
+
+	return check, nil
+}
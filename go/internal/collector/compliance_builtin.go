@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxReportedPaths limits how many offending files a single rule lists.
+const maxReportedPaths = 10
+
+func builtinComplianceChecks() []complianceCheck {
+	return []complianceCheck{
+		{
+			id:       "ssh-root-login-disabled",
+			title:    "SSH root login is disabled",
+			severity: "high",
+			run:      checkSSHRootLogin,
+		},
+		{
+			id:       "password-max-days",
+			title:    "Password expiration is 365 days or less",
+			severity: "medium",
+			run:      checkPasswordMaxDays,
+		},
+		{
+			id:       "password-min-length",
+			title:    "Minimum password length is at least 14",
+			severity: "medium",
+			run:      checkPasswordMinLength,
+		},
+		{
+			id:       "etc-no-world-writable",
+			title:    "No world-writable files in /etc",
+			severity: "high",
+			run:      checkEtcWorldWritable,
+		},
+	}
+}
+
+func checkSSHRootLogin() (bool, string, error) {
+	values, err := readKeyValues("/etc/ssh/sshd_config", " ")
+	if err != nil {
+		return false, "", err
+	}
+	// sshd defaults to prohibit-password when the option is absent.
+	value := strings.ToLower(values["permitrootlogin"])
+	if value != "no" {
+		if value == "" {
+			value = "unset"
+		}
+		return false, fmt.Sprintf("PermitRootLogin is %s", value), nil
+	}
+	return true, "", nil
+}
+
+func checkPasswordMaxDays() (bool, string, error) {
+	values, err := readKeyValues("/etc/login.defs", " ")
+	if err != nil {
+		return false, "", err
+	}
+	days, err := strconv.Atoi(values["pass_max_days"])
+	if err != nil {
+		return false, "PASS_MAX_DAYS is not set", nil
+	}
+	if days > 365 {
+		return false, fmt.Sprintf("PASS_MAX_DAYS is %d", days), nil
+	}
+	return true, "", nil
+}
+
+func checkPasswordMinLength() (bool, string, error) {
+	// pam_pwquality takes precedence over the legacy login.defs setting.
+	if values, err := readKeyValues("/etc/security/pwquality.conf", "="); err == nil {
+		if minLen, err := strconv.Atoi(values["minlen"]); err == nil {
+			if minLen < 14 {
+				return false, fmt.Sprintf("pwquality minlen is %d", minLen), nil
+			}
+			return true, "", nil
+		}
+	}
+
+	values, err := readKeyValues("/etc/login.defs", " ")
+	if err != nil {
+		return false, "", err
+	}
+	minLen, err := strconv.Atoi(values["pass_min_len"])
+	if err != nil {
+		return false, "no minimum password length configured", nil
+	}
+	if minLen < 14 {
+		return false, fmt.Sprintf("PASS_MIN_LEN is %d", minLen), nil
+	}
+	return true, "", nil
+}
+
+func checkEtcWorldWritable() (bool, string, error) {
+	found := make([]string, 0)
+	err := filepath.WalkDir("/etc", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		// Sticky directories are shared by design and not flagged.
+		if info.Mode().Perm()&0o002 != 0 && info.Mode()&fs.ModeSticky == 0 {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(found) > 0 {
+		detail := strings.Join(found[:min(len(found), maxReportedPaths)], ", ")
+		if len(found) > maxReportedPaths {
+			detail += fmt.Sprintf(" and %d more", len(found)-maxReportedPaths)
+		}
+		return false, detail, nil
+	}
+	return true, "", nil
+}
+
+// readKeyValues parses simple "key value" or "key=value" config files,
+// lowercasing keys. As in sshd_config, the first occurrence of a key wins.
+func readKeyValues(path, sep string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var key, value string
+		if sep == "=" {
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			key, value = k, v
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			key, value = fields[0], fields[1]
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, seen := values[key]; !seen {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values, scanner.Err()
+}
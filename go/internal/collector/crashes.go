@@ -0,0 +1,151 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type crashReport struct {
+	Binary    string
+	Signal    string
+	PID       string
+	Unit      string
+	Path      string
+	Timestamp time.Time
+}
+
+// CrashCollector reports workloads that crashed since the last poll, from
+// systemd-coredump's journal entries and from new files in crash
+// directories such as /var/crash.
+type CrashCollector struct {
+	dirs     []string
+	schedule schedule
+	events   *EventBuffer
+
+	journal  *coredumpJournal
+	lastScan time.Time
+	seen     map[string]bool
+}
+
+func NewCrashCollector(cfg config.CrashesConfig, events *EventBuffer) *CrashCollector {
+	c := &CrashCollector{
+		dirs:     cfg.Dirs,
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		journal:  newCoredumpJournal(),
+		lastScan: time.Now(),
+		seen:     make(map[string]bool),
+	}
+	// Crash files that predate the agent were reported before or are stale.
+	for _, report := range c.scanDirs(time.Time{}) {
+		c.seen[report.Path] = true
+	}
+	return c
+}
+
+func (c *CrashCollector) Poll(ctx context.Context) error {
+	now := time.Now()
+	if !c.schedule.due(now) {
+		return nil
+	}
+
+	reports, err := c.journal.poll(ctx)
+	for _, report := range c.scanDirs(c.lastScan.Add(-time.Minute)) {
+		if !c.seen[report.Path] {
+			c.seen[report.Path] = true
+			reports = append(reports, report)
+		}
+	}
+	c.lastScan = now
+
+	for _, report := range reports {
+		c.emit(report)
+	}
+	return err
+}
+
+func (c *CrashCollector) emit(report crashReport) {
+	message := fmt.Sprintf("%s crashed", report.Binary)
+	if report.Signal != "" {
+		message += " with " + report.Signal
+	}
+	if report.Unit != "" {
+		message += " (" + report.Unit + ")"
+	}
+
+	attrs := map[string]string{
+		"binary":    report.Binary,
+		"timestamp": report.Timestamp.UTC().Format(time.RFC3339),
+	}
+	for key, value := range map[string]string{
+		"signal": report.Signal,
+		"pid":    report.PID,
+		"unit":   report.Unit,
+		"path":   report.Path,
+	} {
+		if value != "" {
+			attrs[key] = value
+		}
+	}
+	c.events.Add("process_crashed", SeverityWarning, message, attrs)
+}
+
+// scanDirs lists crash files modified after since.
+func (c *CrashCollector) scanDirs(since time.Time) []crashReport {
+	reports := make([]crashReport, 0)
+	for _, dir := range c.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().After(since) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			report := crashReport{Binary: entry.Name(), Path: path, Timestamp: info.ModTime()}
+			if strings.HasSuffix(entry.Name(), ".crash") {
+				parseApportReport(path, &report)
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// parseApportReport reads the executable and signal from the header of an
+// Ubuntu apport .crash file.
+func parseApportReport(path string, report *crashReport) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lines := 0; scanner.Scan() && lines < 200; lines++ {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ExecutablePath":
+			report.Binary = value
+		case "Signal":
+			report.Signal = signalName(value)
+		case "Pid", "ProcPid":
+			report.PID = value
+		}
+	}
+}
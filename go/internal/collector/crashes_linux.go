@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// coredumpMessageID identifies systemd-coredump's journal entries.
+const coredumpMessageID = "fc2e22bc6ee647b6b90729ab34a250b1"
+
+// coredumpJournal follows systemd-coredump entries with a journal cursor,
+// starting at the time the agent started. Hosts without journalctl only
+// have their crash directories watched.
+type coredumpJournal struct {
+	available bool
+	cursor    string
+	since     time.Time
+}
+
+func newCoredumpJournal() *coredumpJournal {
+	_, err := exec.LookPath("journalctl")
+	return &coredumpJournal{available: err == nil, since: time.Now()}
+}
+
+func (j *coredumpJournal) poll(ctx context.Context) ([]crashReport, error) {
+	if !j.available {
+		return nil, nil
+	}
+	args := []string{"MESSAGE_ID=" + coredumpMessageID, "-o", "json", "--no-pager"}
+	if j.cursor != "" {
+		args = append(args, "--after-cursor", j.cursor)
+	} else {
+		args = append(args, "--since", "@"+strconv.FormatInt(j.since.Unix(), 10))
+	}
+	out, err := runCommand(ctx, 0, "journalctl", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]crashReport, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		field := func(name string) string {
+			s, _ := entry[name].(string)
+			return s
+		}
+		j.cursor = field("__CURSOR")
+
+		report := crashReport{
+			Binary:    field("COREDUMP_EXE"),
+			Signal:    signalName(field("COREDUMP_SIGNAL")),
+			PID:       field("COREDUMP_PID"),
+			Unit:      field("COREDUMP_UNIT"),
+			Path:      field("COREDUMP_FILENAME"),
+			Timestamp: time.Now(),
+		}
+		if report.Binary == "" {
+			report.Binary = field("COREDUMP_COMM")
+		}
+		if usec, err := strconv.ParseInt(field("COREDUMP_TIMESTAMP"), 10, 64); err == nil {
+			report.Timestamp = time.UnixMicro(usec)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func signalName(value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return value
+	}
+	if name := unix.SignalName(unix.Signal(n)); name != "" {
+		return name
+	}
+	return value
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package collector
+
+import "context"
+
+// coredumpJournal is a no-op without systemd-coredump; only crash
+// directories are watched.
+type coredumpJournal struct{}
+
+func newCoredumpJournal() *coredumpJournal {
+	return &coredumpJournal{}
+}
+
+func (j *coredumpJournal) poll(ctx context.Context) ([]crashReport, error) {
+	return nil, nil
+}
+
+func signalName(value string) string {
+	return value
+}
@@ -0,0 +1,178 @@
+package collector
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type DiskUsageInfo struct {
+	ScannedAt time.Time       `json:"scannedAt"`
+	Roots     []DiskUsageRoot `json:"roots"`
+}
+
+// DiskUsageRoot is a scanned root with its largest directories, whose
+// sizes include their subdirectories, as in du. Errors counts entries that
+// could not be read, e.g. for lack of permission.
+type DiskUsageRoot struct {
+	Path        string           `json:"path"`
+	Bytes       uint64           `json:"bytes"`
+	Directories []DirectoryUsage `json:"directories"`
+	Errors      int              `json:"errors,omitempty"`
+	DurationMs  int64            `json:"durationMs"`
+}
+
+type DirectoryUsage struct {
+	Path  string `json:"path"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// DiskUsageCollector scans in the background, as walking a large
+// filesystem can take minutes; each scan is reported once it completes.
+type DiskUsageCollector struct {
+	roots          []string
+	topN           int
+	maxDepth       int
+	triggerPercent float64
+	schedule       schedule
+
+	mu       sync.Mutex
+	scanning bool
+	result   *DiskUsageInfo
+	// triggered is set while disk usage stays at or above triggerPercent.
+	triggered bool
+}
+
+func NewDiskUsageCollector(cfg config.DiskUsageConfig) *DiskUsageCollector {
+	roots := cfg.Roots
+	if len(roots) == 0 {
+		roots = []string{"/"}
+	}
+	return &DiskUsageCollector{
+		roots:          roots,
+		topN:           cfg.TopN,
+		maxDepth:       cfg.MaxDepth,
+		triggerPercent: cfg.TriggerPercent,
+		schedule:       newSchedule(time.Duration(cfg.Interval) * time.Second),
+	}
+}
+
+// Collect starts a scan when one is due, or right away when diskPercent
+// (the usage of the main disk) reaches the trigger, and returns the result
+// of a scan that finished since the last call.
+func (c *DiskUsageCollector) Collect(ctx context.Context, diskPercent float64) (*DiskUsageInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	trigger := c.triggerPercent > 0 && diskPercent >= c.triggerPercent
+	due := c.schedule.due(time.Now())
+	if trigger && !c.triggered {
+		due = true
+	}
+	c.triggered = trigger
+	if due && !c.scanning {
+		c.scanning = true
+		go c.background(ctx)
+	}
+
+	result := c.result
+	c.result = nil
+	return result, nil
+}
+
+func (c *DiskUsageCollector) background(ctx context.Context) {
+	info := c.Scan(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scanning = false
+	if ctx.Err() == nil {
+		c.result = info
+	}
+}
+
+// Scan walks every root and waits for the result.
+func (c *DiskUsageCollector) Scan(ctx context.Context) *DiskUsageInfo {
+	info := &DiskUsageInfo{ScannedAt: time.Now().UTC()}
+	for _, root := range c.roots {
+		if ctx.Err() != nil {
+			break
+		}
+		info.Roots = append(info.Roots, c.scanRoot(ctx, root))
+	}
+	return info
+}
+
+func (c *DiskUsageCollector) scanRoot(ctx context.Context, root string) DiskUsageRoot {
+	start := time.Now()
+	result := DiskUsageRoot{Path: root}
+	root = filepath.Clean(root)
+	sizes := make(map[string]uint64)
+	var device uint64
+	var haveDevice bool
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			result.Errors++
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			result.Errors++
+			return nil
+		}
+		size, dev, ok := fileUsage(info)
+		if path == root {
+			device, haveDevice = dev, ok
+		} else if d.IsDir() && haveDevice && ok && dev != device {
+			// Stay on the root's filesystem, like du -x.
+			return fs.SkipDir
+		}
+
+		result.Bytes += size
+		// Charge the size to every ancestor within maxDepth.
+		dir := path
+		if !d.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		for depth := relativeDepth(root, dir); ; depth-- {
+			if depth <= c.maxDepth && depth > 0 {
+				sizes[dir] += size
+			}
+			if depth <= 0 {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		return nil
+	})
+
+	for path, bytes := range sizes {
+		result.Directories = append(result.Directories, DirectoryUsage{Path: path, Bytes: bytes})
+	}
+	sort.Slice(result.Directories, func(i, j int) bool {
+		return result.Directories[i].Bytes > result.Directories[j].Bytes
+	})
+	if len(result.Directories) > c.topN {
+		result.Directories = result.Directories[:c.topN]
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// relativeDepth is how many levels dir is below root.
+func relativeDepth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
@@ -0,0 +1,15 @@
+package collector
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileUsage returns the space allocated to a file and its device.
+func fileUsage(info fs.FileInfo) (size, device uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return uint64(info.Size()), 0, false
+	}
+	return uint64(stat.Blocks) * 512, uint64(stat.Dev), true
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package collector
+
+import "io/fs"
+
+// fileUsage returns a file's apparent size; the device is unknown, so
+// scans may cross into other filesystems.
+func fileUsage(info fs.FileInfo) (size, device uint64, ok bool) {
+	return uint64(info.Size()), 0, false
+}
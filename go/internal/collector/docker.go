@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	dockerTimeout = 10 * time.Second
+	// /system/df sizes every volume, which is slow on large hosts.
+	dockerDiskUsageTimeout = time.Minute
+)
+
+type DockerInfo struct {
+	Runtime    string          `json:"runtime"`
+	Running    int             `json:"running"`
+	Stopped    int             `json:"stopped"`
+	Containers []ContainerInfo `json:"containers"`
+	Projects   []ProjectInfo   `json:"projects,omitempty"`
+
+	// Images, Volumes and DiskUsage are only set on heartbeats where the
+	// disk usage inventory was due.
+	Images    []ImageInfo      `json:"images,omitempty"`
+	Volumes   []VolumeInfo     `json:"volumes,omitempty"`
+	DiskUsage *DockerDiskUsage `json:"diskUsage,omitempty"`
+}
+
+type ImageInfo struct {
+	ID         string     `json:"id"`
+	Tags       []string   `json:"tags,omitempty"`
+	Size       int64      `json:"size"`
+	SharedSize int64      `json:"sharedSize,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	Containers int64      `json:"containers"`
+	Dangling   bool       `json:"dangling,omitempty"`
+}
+
+// VolumeInfo.Size is -1 for volume drivers that do not report usage.
+type VolumeInfo struct {
+	Name       string `json:"name"`
+	Driver     string `json:"driver"`
+	Size       int64  `json:"size"`
+	Containers int64  `json:"containers"`
+}
+
+// DockerDiskUsage sums the inventory. Image sizes count shared layers once;
+// DanglingSize is what `docker image prune` would free.
+type DockerDiskUsage struct {
+	Images         int64 `json:"images"`
+	DanglingImages int   `json:"danglingImages"`
+	DanglingSize   int64 `json:"danglingSize"`
+	Volumes        int64 `json:"volumes"`
+	UnusedVolumes  int   `json:"unusedVolumes"`
+	BuildCache     int64 `json:"buildCache"`
+}
+
+type ContainerInfo struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Image         string    `json:"image"`
+	State         string    `json:"state"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"createdAt"`
+	CPUPercent    float64   `json:"cpuPercent,omitempty"`
+	MemoryUsage   uint64    `json:"memoryUsage,omitempty"`
+	MemoryLimit   uint64    `json:"memoryLimit,omitempty"`
+	MemoryPercent float64   `json:"memoryPercent,omitempty"`
+	NetRxBytes    uint64    `json:"netRxBytes,omitempty"`
+	NetTxBytes    uint64    `json:"netTxBytes,omitempty"`
+	Restarts      int       `json:"restarts,omitempty"`
+	// Labels carries the compose project and service, or the Kubernetes
+	// namespace, pod, container and workload.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	fullID string
+}
+
+type DockerCollector struct {
+	runtime     containerRuntime
+	name        string
+	stats       bool
+	labelChecks bool
+	diskUsage   *schedule
+	kube        *kubeResolver
+	restarts    *restartTracker
+
+	mu         sync.Mutex
+	containers []runtimeContainer
+	warned     map[string]bool
+}
+
+func NewDockerCollector(cfg config.DockerConfig, events *EventBuffer) (*DockerCollector, error) {
+	runtime, err := newContainerRuntime(cfg.Runtime, cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+	c := &DockerCollector{
+		runtime: runtime,
+		name:    cfg.Runtime,
+		stats:   cfg.Stats,
+		// crictl does not report pod addresses to run the checks against.
+		labelChecks: cfg.LabelChecks && cfg.Runtime != "cri",
+		warned:      make(map[string]bool),
+	}
+	if cfg.DiskUsageInterval > 0 {
+		s := newSchedule(time.Duration(cfg.DiskUsageInterval) * time.Second)
+		c.diskUsage = &s
+	}
+	if cfg.RestartThreshold > 0 {
+		c.restarts = newRestartTracker(cfg.RestartThreshold, time.Duration(cfg.RestartWindow)*time.Second, events)
+	}
+	if cfg.Kubernetes.Enabled {
+		if c.kube, err = newKubeResolver(cfg.Kubernetes); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *DockerCollector) Collect(ctx context.Context) (*DockerInfo, error) {
+	listCtx, cancel := context.WithTimeout(ctx, dockerTimeout)
+	defer cancel()
+
+	containers, err := c.runtime.containers(listCtx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.containers = containers
+	c.mu.Unlock()
+
+	info := &DockerInfo{Runtime: c.name, Containers: make([]ContainerInfo, len(containers))}
+	var running []*ContainerInfo
+	for i := range containers {
+		ctr := &containers[i]
+		info.Containers[i] = ContainerInfo{
+			ID:        ctr.ID[:min(len(ctr.ID), 12)],
+			Name:      ctr.Name,
+			Image:     ctr.Image,
+			State:     ctr.State,
+			Status:    ctr.Status,
+			CreatedAt: ctr.Created,
+			fullID:    ctr.ID,
+		}
+		if ctr.State != "running" {
+			info.Stopped++
+			continue
+		}
+		info.Running++
+		running = append(running, &info.Containers[i])
+	}
+	labelKubernetesContainers(listCtx, c.kube, containers, info.Containers)
+	if c.restarts != nil {
+		c.runtime.restartCounts(listCtx, containers)
+		c.restarts.observe(time.Now(), containers, info.Containers)
+	}
+	if c.stats && len(running) > 0 {
+		c.runtime.stats(listCtx, running)
+	}
+	info.Projects = groupProjects(containers, info.Containers)
+
+	if c.diskUsage != nil && c.diskUsage.due(time.Now()) {
+		ctx, cancel := context.WithTimeout(ctx, dockerDiskUsageTimeout)
+		defer cancel()
+		if err := c.runtime.diskUsage(ctx, info); err != nil {
+			log.Printf("Error collecting container disk usage: %v", err)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sentinel-agent/internal/config"
+)
+
+// checkLabelPrefix marks container labels that request a check, e.g.
+// sentinel.check.http=/health:8080 or sentinel.check.tcp.admin=9000.
+// sentinel.check.interval sets the interval for all of a container's checks.
+const checkLabelPrefix = "sentinel.check."
+
+// LabelChecks returns the checks requested by labels on running containers
+// as of the last Collect. Check names are "<container>/<type or suffix>".
+func (c *DockerCollector) LabelChecks() []config.CheckConfig {
+	if !c.labelChecks {
+		return nil
+	}
+	c.mu.Lock()
+	containers := c.containers
+	c.mu.Unlock()
+
+	result := make([]config.CheckConfig, 0)
+	for i := range containers {
+		ctr := &containers[i]
+		if ctr.State != "running" {
+			continue
+		}
+
+		interval := 0
+		if v, ok := ctr.Labels[checkLabelPrefix+"interval"]; ok {
+			interval, _ = strconv.Atoi(v)
+		}
+		host := ctr.IP
+		if host == "" {
+			host = "127.0.0.1"
+		}
+
+		keys := make([]string, 0)
+		for key := range ctr.Labels {
+			if strings.HasPrefix(key, checkLabelPrefix) && key != checkLabelPrefix+"interval" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			check, err := labelCheck(strings.TrimPrefix(key, checkLabelPrefix), ctr.Labels[key], host)
+			if err != nil {
+				// Labels are re-read every heartbeat; report each bad one once.
+				if warning := ctr.ID + key; !c.warned[warning] {
+					c.warned[warning] = true
+					log.Printf("Ignoring label %s on container %s: %v", key, ctr.Name, err)
+				}
+				continue
+			}
+			check.Name = ctr.Name + "/" + check.Name
+			check.Interval = interval
+			check.Source = "docker"
+			result = append(result, check)
+		}
+	}
+	return result
+}
+
+// labelCheck parses "<type>[.<name>]" and a value of "[path:]port" for
+// http or "port" for tcp and redis.
+func labelCheck(key, value, host string) (config.CheckConfig, error) {
+	checkType, name, _ := strings.Cut(key, ".")
+	if name == "" {
+		name = checkType
+	}
+
+	value = strings.TrimSpace(value)
+	path := ""
+	if checkType == "http" {
+		if i := strings.LastIndex(value, ":"); i >= 0 {
+			path, value = value[:i], value[i+1:]
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return config.CheckConfig{}, fmt.Errorf("invalid port %q", value)
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	switch checkType {
+	case "http":
+		return config.CheckConfig{Name: name, Type: "http", Target: "http://" + address + path}, nil
+	case "tcp", "redis":
+		return config.CheckConfig{Name: name, Type: checkType, Target: address}, nil
+	default:
+		return config.CheckConfig{}, fmt.Errorf("unsupported check type %q", checkType)
+	}
+}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"sort"
+	"strings"
+)
+
+// Labels set by docker compose (and podman-compose) and by swarm stacks.
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+	stackNamespaceLabel = "com.docker.stack.namespace"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+)
+
+// ProjectInfo sums the containers of a compose project or swarm stack.
+type ProjectInfo struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"`
+	Services []ServiceInfo `json:"services"`
+}
+
+type ServiceInfo struct {
+	Name        string  `json:"name"`
+	Running     int     `json:"running"`
+	Stopped     int     `json:"stopped"`
+	CPUPercent  float64 `json:"cpuPercent,omitempty"`
+	MemoryUsage uint64  `json:"memoryUsage,omitempty"`
+	NetRxBytes  uint64  `json:"netRxBytes,omitempty"`
+	NetTxBytes  uint64  `json:"netTxBytes,omitempty"`
+}
+
+// containerProject returns the project, its kind and the service of a
+// container, or ok false for standalone containers.
+func containerProject(labels map[string]string) (project, kind, service string, ok bool) {
+	if project := labels[composeProjectLabel]; project != "" {
+		return project, "compose", labels[composeServiceLabel], true
+	}
+	if stack := labels[stackNamespaceLabel]; stack != "" {
+		// Swarm service names are "<stack>_<service>".
+		return stack, "stack", strings.TrimPrefix(labels[swarmServiceLabel], stack+"_"), true
+	}
+	return "", "", "", false
+}
+
+// groupProjects labels each container with its project and service and
+// returns the per-service totals, sorted by project and service name.
+func groupProjects(containers []runtimeContainer, out []ContainerInfo) []ProjectInfo {
+	type key struct{ project, kind string }
+	services := make(map[key]map[string]*ServiceInfo)
+	for i := range containers {
+		project, kind, service, ok := containerProject(containers[i].Labels)
+		if !ok {
+			continue
+		}
+		ctr := &out[i]
+		if ctr.Labels == nil {
+			ctr.Labels = make(map[string]string)
+		}
+		ctr.Labels["project"] = project
+		ctr.Labels["service"] = service
+
+		k := key{project, kind}
+		if services[k] == nil {
+			services[k] = make(map[string]*ServiceInfo)
+		}
+		svc := services[k][service]
+		if svc == nil {
+			svc = &ServiceInfo{Name: service}
+			services[k][service] = svc
+		}
+		if ctr.State != "running" {
+			svc.Stopped++
+			continue
+		}
+		svc.Running++
+		svc.CPUPercent += ctr.CPUPercent
+		svc.MemoryUsage += ctr.MemoryUsage
+		svc.NetRxBytes += ctr.NetRxBytes
+		svc.NetTxBytes += ctr.NetTxBytes
+	}
+
+	projects := make([]ProjectInfo, 0, len(services))
+	for k, byName := range services {
+		project := ProjectInfo{Name: k.project, Kind: k.kind}
+		for _, svc := range byName {
+			project.Services = append(project.Services, *svc)
+		}
+		sort.Slice(project.Services, func(i, j int) bool { return project.Services[i].Name < project.Services[j].Name })
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].Kind < projects[j].Kind
+	})
+	return projects
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type restartSample struct {
+	at    time.Time
+	count int
+}
+
+// restartTracker keeps each container's restart count samples for the
+// window and reports containers that exceed the threshold. Containers are
+// keyed by name, which survives Kubernetes replacing the container.
+type restartTracker struct {
+	threshold int
+	window    time.Duration
+	events    *EventBuffer
+
+	samples map[string][]restartSample
+	looping map[string]bool
+}
+
+func newRestartTracker(threshold int, window time.Duration, events *EventBuffer) *restartTracker {
+	return &restartTracker{
+		threshold: threshold,
+		window:    window,
+		events:    events,
+		samples:   make(map[string][]restartSample),
+		looping:   make(map[string]bool),
+	}
+}
+
+func (t *restartTracker) observe(now time.Time, containers []runtimeContainer, out []ContainerInfo) {
+	seen := make(map[string]bool, len(containers))
+	for i := range containers {
+		ctr := &containers[i]
+		seen[ctr.Name] = true
+		out[i].Restarts = ctr.Restarts
+
+		samples := append(t.samples[ctr.Name], restartSample{at: now, count: ctr.Restarts})
+		for len(samples) > 1 && now.Sub(samples[1].at) >= t.window {
+			samples = samples[1:]
+		}
+		t.samples[ctr.Name] = samples
+
+		// A lower count means the container was recreated; count from there.
+		restarts := ctr.Restarts - samples[0].count
+		if restarts < 0 {
+			t.samples[ctr.Name] = samples[len(samples)-1:]
+			restarts = 0
+		}
+		switch {
+		case restarts > t.threshold && !t.looping[ctr.Name]:
+			t.looping[ctr.Name] = true
+			t.events.Add("container_restart_loop", SeverityWarning,
+				fmt.Sprintf("Container %s restarted %d times in %s", ctr.Name, restarts, t.window),
+				map[string]string{"container": ctr.Name, "image": ctr.Image, "restarts": strconv.Itoa(restarts)})
+		case restarts == 0 && t.looping[ctr.Name]:
+			delete(t.looping, ctr.Name)
+			t.events.Add("container_restart_loop_resolved", SeverityInfo,
+				fmt.Sprintf("Container %s has not restarted in %s", ctr.Name, t.window),
+				map[string]string{"container": ctr.Name, "image": ctr.Image})
+		}
+	}
+	for name := range t.samples {
+		if !seen[name] {
+			delete(t.samples, name)
+			delete(t.looping, name)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+// DomainInfo is the host's directory domain membership. SecureChannel is
+// "ok", "broken" or "unknown" when it could not be tested; on Windows it is
+// the machine account trust, with SSSD the host keytab (adcli testjoin).
+type DomainInfo struct {
+	Joined             bool       `json:"joined"`
+	Domain             string     `json:"domain,omitempty"`
+	Client             string     `json:"client,omitempty"`
+	SecureChannel      string     `json:"secureChannel,omitempty"`
+	SecureChannelError string     `json:"secureChannelError,omitempty"`
+	Online             *bool      `json:"online,omitempty"`
+	LastPolicyRefresh  *time.Time `json:"lastPolicyRefresh,omitempty"`
+	PolicyAgeSeconds   int64      `json:"policyAgeSeconds,omitempty"`
+}
+
+type DomainCollector struct {
+	policyMaxAge time.Duration
+	schedule     schedule
+	events       *EventBuffer
+	broken       bool
+	stale        bool
+}
+
+func NewDomainCollector(cfg config.DomainConfig, events *EventBuffer) *DomainCollector {
+	return &DomainCollector{
+		policyMaxAge: time.Duration(cfg.PolicyMaxAge) * time.Hour,
+		schedule:     newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:       events,
+	}
+}
+
+func (c *DomainCollector) Collect(ctx context.Context) (*DomainInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	info, err := collectDomain(ctx)
+	if err != nil || !info.Joined {
+		return info, err
+	}
+	attrs := map[string]string{"domain": info.Domain}
+
+	if info.SecureChannel != "unknown" {
+		broken := info.SecureChannel == "broken"
+		if broken != c.broken {
+			c.broken = broken
+			if broken {
+				c.events.Add("domain_trust_broken", SeverityCritical,
+					fmt.Sprintf("Secure channel to domain %s is broken: %s", info.Domain, info.SecureChannelError), attrs)
+			} else {
+				c.events.Add("domain_trust_restored", SeverityInfo,
+					fmt.Sprintf("Secure channel to domain %s is healthy again", info.Domain), attrs)
+			}
+		}
+	}
+
+	if info.LastPolicyRefresh != nil {
+		age := time.Since(*info.LastPolicyRefresh)
+		info.PolicyAgeSeconds = int64(age.Seconds())
+		stale := c.policyMaxAge > 0 && age > c.policyMaxAge
+		if stale != c.stale {
+			c.stale = stale
+			if stale {
+				c.events.Add("domain_policy_stale", SeverityWarning,
+					fmt.Sprintf("Domain policy has not been refreshed for %s", age.Round(time.Minute)), attrs)
+			} else {
+				c.events.Add("domain_policy_refreshed", SeverityInfo, "Domain policy was refreshed", attrs)
+			}
+		}
+	}
+	return info, nil
+}
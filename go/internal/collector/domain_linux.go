@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const sssdGPOCache = "/var/lib/sss/gpo_cache"
+
+// collectDomain reports the first domain realmd has joined. Hosts joined
+// without realmd are reported as not joined.
+func collectDomain(ctx context.Context) (*DomainInfo, error) {
+	info := &DomainInfo{}
+	if _, err := exec.LookPath("realm"); err != nil {
+		return info, nil
+	}
+	out, err := runCommand(ctx, 0, "realm", "list")
+	if err != nil {
+		return nil, err
+	}
+	info.Domain, info.Client = parseRealmList(out)
+	if info.Domain == "" {
+		return info, nil
+	}
+	info.Joined = true
+
+	info.SecureChannel = "unknown"
+	if _, err := exec.LookPath("adcli"); err == nil {
+		if _, err := runCommand(ctx, 30*time.Second, "adcli", "testjoin", "--domain", info.Domain); err != nil {
+			info.SecureChannel = "broken"
+			info.SecureChannelError = err.Error()
+		} else {
+			info.SecureChannel = "ok"
+		}
+	}
+
+	if info.Client == "sssd" {
+		if out, err := runCommand(ctx, 0, "sssctl", "domain-status", info.Domain, "--online"); err == nil {
+			online := strings.Contains(string(out), "Online status: Online")
+			info.Online = &online
+		}
+		// SSSD caches GPOs when it applies them on login, so the newest
+		// file in the cache is the last refresh.
+		if refreshed := newestModTime(filepath.Join(sssdGPOCache, strings.ToLower(info.Domain))); !refreshed.IsZero() {
+			info.LastPolicyRefresh = &refreshed
+		}
+	}
+	return info, nil
+}
+
+// parseRealmList returns the first configured kerberos-member realm.
+func parseRealmList(out []byte) (domain, client string) {
+	var name, software string
+	var member bool
+	flush := func() bool {
+		if member && domain == "" {
+			domain, client = name, software
+		}
+		name, software, member = "", "", false
+		return domain != ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && line[0] != ' ' && line[0] != '\t' {
+			if flush() {
+				return
+			}
+			name = strings.TrimSpace(line)
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "domain-name":
+			name = value
+		case "client-software":
+			software = value
+		case "configured":
+			member = value == "kerberos-member"
+		}
+	}
+	flush()
+	return
+}
+
+func newestModTime(dir string) time.Time {
+	var newest time.Time
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil && fi.ModTime().After(newest) {
+			newest = fi.ModTime()
+		}
+		return nil
+	})
+	return newest
+}
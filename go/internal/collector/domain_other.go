@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectDomain(ctx context.Context) (*DomainInfo, error) {
+	return nil, fmt.Errorf("domain status is only available on Linux and Windows")
+}
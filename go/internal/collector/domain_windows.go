@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+	"golang.org/x/sys/windows/registry"
+)
+
+type win32ComputerSystemDomain struct {
+	PartOfDomain bool
+	Domain       string
+}
+
+// gpStateKey records the last machine policy processing; the all-zero
+// GUID is the core Group Policy engine.
+const gpStateKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\Group Policy\State\Machine\Extension-List\{00000000-0000-0000-0000-000000000000}`
+
+func collectDomain(ctx context.Context) (*DomainInfo, error) {
+	var systems []win32ComputerSystemDomain
+	if err := wmi.Query("SELECT PartOfDomain, Domain FROM Win32_ComputerSystem", &systems); err != nil {
+		return nil, fmt.Errorf("failed to query domain membership: %w", err)
+	}
+	info := &DomainInfo{}
+	if len(systems) == 0 || !systems[0].PartOfDomain {
+		return info, nil
+	}
+	info.Joined = true
+	info.Domain = systems[0].Domain
+	info.Client = "windows"
+
+	out, err := runCommand(ctx, 30*time.Second, "powershell", "-NoProfile", "-NonInteractive", "-Command", "Test-ComputerSecureChannel")
+	switch {
+	case err != nil:
+		info.SecureChannel = "unknown"
+		info.SecureChannelError = err.Error()
+	case strings.TrimSpace(string(out)) == "True":
+		info.SecureChannel = "ok"
+	default:
+		info.SecureChannel = "broken"
+		info.SecureChannelError = "Test-ComputerSecureChannel returned False"
+	}
+
+	if refreshed, ok := lastPolicyRefresh(); ok {
+		info.LastPolicyRefresh = &refreshed
+	}
+	return info, nil
+}
+
+func lastPolicyRefresh() (time.Time, bool) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, gpStateKey, registry.QUERY_VALUE)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer key.Close()
+	lo, _, err := key.GetIntegerValue("EndTimeLo")
+	if err != nil {
+		return time.Time{}, false
+	}
+	hi, _, err := key.GetIntegerValue("EndTimeHi")
+	if err != nil {
+		return time.Time{}, false
+	}
+	ft := syscall.Filetime{LowDateTime: uint32(lo), HighDateTime: uint32(hi)}
+	return time.Unix(0, ft.Nanoseconds()).UTC(), true
+}
@@ -0,0 +1,122 @@
+package collector
+
+import "time"
+
+// EnergyInfo is the power draw averaged over the time since the previous
+// heartbeat. Domains lists every meter found; TotalWatts uses the one that
+// best covers the whole system (an ACPI power meter, then the RAPL psys
+// domain, then the sum of the RAPL package domains).
+type EnergyInfo struct {
+	Domains         []PowerDomain `json:"domains"`
+	TotalWatts      float64       `json:"totalWatts"`
+	EnergyWh        float64       `json:"energyWh"`
+	IntervalSeconds float64       `json:"intervalSeconds"`
+}
+
+type PowerDomain struct {
+	Name     string  `json:"name"`
+	Source   string  `json:"source"`
+	Watts    float64 `json:"watts"`
+	EnergyWh float64 `json:"energyWh"`
+}
+
+// energyCounter is a cumulative meter (RAPL) in microjoules that wraps at
+// maxRange.
+type energyCounter struct {
+	name     string
+	source   string
+	uj       uint64
+	maxRange uint64
+	// system and top mark a whole-platform meter and a top-level package
+	// zone respectively, for picking the total.
+	system bool
+	top    bool
+}
+
+// powerMeter is an instantaneous reading (hwmon, ACPI) in watts.
+type powerMeter struct {
+	name   string
+	source string
+	watts  float64
+	system bool
+}
+
+type energyTracker struct {
+	last   map[string]uint64
+	lastAt time.Time
+}
+
+func newEnergyTracker() *energyTracker {
+	return &energyTracker{last: make(map[string]uint64)}
+}
+
+// collect returns nil until two readings are available, and when the host
+// has no power meters.
+func (t *energyTracker) collect(now time.Time) *EnergyInfo {
+	counters, meters := readPowerMeters()
+	if len(counters) == 0 && len(meters) == 0 {
+		return nil
+	}
+
+	elapsed := now.Sub(t.lastAt).Seconds()
+	first := t.lastAt.IsZero()
+	t.lastAt = now
+	previous := t.last
+	t.last = make(map[string]uint64, len(counters))
+	for _, c := range counters {
+		t.last[c.name] = c.uj
+	}
+	if first || elapsed <= 0 {
+		return nil
+	}
+
+	info := &EnergyInfo{Domains: make([]PowerDomain, 0), IntervalSeconds: elapsed}
+	var systemWatts, psysWatts, packageWatts float64
+	var hasSystem, hasPsys bool
+	for _, c := range counters {
+		before, ok := previous[c.name]
+		if !ok {
+			continue
+		}
+		delta := c.uj - before
+		if c.uj < before {
+			delta = c.maxRange - before + c.uj
+		}
+		joules := float64(delta) / 1e6
+		domain := PowerDomain{
+			Name:     c.name,
+			Source:   c.source,
+			Watts:    joules / elapsed,
+			EnergyWh: joules / 3600,
+		}
+		info.Domains = append(info.Domains, domain)
+		switch {
+		case c.system:
+			psysWatts, hasPsys = domain.Watts, true
+		case c.top:
+			packageWatts += domain.Watts
+		}
+	}
+	for _, m := range meters {
+		info.Domains = append(info.Domains, PowerDomain{
+			Name:     m.name,
+			Source:   m.source,
+			Watts:    m.watts,
+			EnergyWh: m.watts * elapsed / 3600,
+		})
+		if m.system && !hasSystem {
+			systemWatts, hasSystem = m.watts, true
+		}
+	}
+
+	switch {
+	case hasSystem:
+		info.TotalWatts = systemWatts
+	case hasPsys:
+		info.TotalWatts = psysWatts
+	default:
+		info.TotalWatts = packageWatts
+	}
+	info.EnergyWh = info.TotalWatts * elapsed / 3600
+	return info
+}
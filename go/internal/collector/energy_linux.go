@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	powercapDir = "/sys/class/powercap"
+	hwmonDir    = "/sys/class/hwmon"
+)
+
+// readPowerMeters reads Intel/AMD RAPL zones from powercap and power
+// sensors from hwmon (ACPI power meters, some server BMCs and GPUs).
+// RAPL counters are root-only on current kernels.
+func readPowerMeters() ([]energyCounter, []powerMeter) {
+	var counters []energyCounter
+	zones, _ := filepath.Glob(filepath.Join(powercapDir, "*-rapl:*"))
+	for _, dir := range zones {
+		uj, err := strconv.ParseUint(readSysfsString(dir, "energy_uj"), 10, 64)
+		if err != nil {
+			continue
+		}
+		maxRange, _ := strconv.ParseUint(readSysfsString(dir, "max_energy_range_uj"), 10, 64)
+		zone := filepath.Base(dir)
+		name := readSysfsString(dir, "name")
+		// Subzones (intel-rapl:0:0) are part of their package's total.
+		top := strings.Count(zone, ":") == 1
+		counters = append(counters, energyCounter{
+			name:     zone + " " + name,
+			source:   "rapl",
+			uj:       uj,
+			maxRange: maxRange,
+			system:   name == "psys",
+			top:      top && strings.HasPrefix(name, "package"),
+		})
+	}
+
+	var meters []powerMeter
+	sensors, _ := filepath.Glob(filepath.Join(hwmonDir, "hwmon*", "power*_input"))
+	averages, _ := filepath.Glob(filepath.Join(hwmonDir, "hwmon*", "power*_average"))
+	for _, path := range append(sensors, averages...) {
+		dir, file := filepath.Split(path)
+		microwatts, err := strconv.ParseFloat(readSysfsString(dir, file), 64)
+		if err != nil {
+			continue
+		}
+		device := readSysfsString(dir, "name")
+		meters = append(meters, powerMeter{
+			name:   device + " " + file,
+			source: "hwmon",
+			watts:  microwatts / 1e6,
+			system: device == "power_meter",
+		})
+	}
+	return counters, meters
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+func readPowerMeters() ([]energyCounter, []powerMeter) {
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// maxBufferedEvents bounds memory if heartbeats cannot be delivered for a while.
+const maxBufferedEvents = 500
+
+type Event struct {
+	Type       string            `json:"type"`
+	Severity   string            `json:"severity"`
+	Message    string            `json:"message"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type EventBuffer struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewEventBuffer() *EventBuffer {
+	return &EventBuffer{events: make([]Event, 0)}
+}
+
+func (b *EventBuffer) Add(eventType, severity, message string, attributes map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.events) >= maxBufferedEvents {
+		b.events = b.events[1:]
+	}
+	b.events = append(b.events, Event{
+		Type:       eventType,
+		Severity:   severity,
+		Message:    message,
+		Timestamp:  time.Now().UTC(),
+		Attributes: attributes,
+	})
+}
+
+func (b *EventBuffer) Drain() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.events) == 0 {
+		return nil
+	}
+	events := b.events
+	b.events = make([]Event, 0)
+	return events
+}
@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const defaultCommandTimeout = 10 * time.Second
+
+// runCommand runs name and returns its output. It is killed after timeout
+// (defaultCommandTimeout if zero) or when ctx is cancelled.
+func runCommand(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const factsCommandTimeout = 30 * time.Second
+
+// FactsCollector merges custom host facts from YAML/JSON files in a
+// directory and from commands printing a YAML/JSON mapping. Files are read
+// on every heartbeat so other tooling can edit them; commands are re-run on
+// a slower schedule and their output cached.
+type FactsCollector struct {
+	dir      string
+	commands []string
+	schedule schedule
+	cmdFacts map[string]interface{}
+}
+
+func NewFactsCollector(dir string, commands []string, interval time.Duration) *FactsCollector {
+	return &FactsCollector{
+		dir:      dir,
+		commands: commands,
+		schedule: newSchedule(interval),
+		cmdFacts: make(map[string]interface{}),
+	}
+}
+
+// Collect returns the merged facts. Files are applied in name order and
+// command output last, so later sources override earlier ones per key.
+func (c *FactsCollector) Collect(ctx context.Context) (map[string]interface{}, error) {
+	facts := make(map[string]interface{})
+	errs := make([]string, 0)
+
+	if c.dir != "" {
+		if err := c.readDir(facts); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(c.commands) > 0 && c.schedule.due(time.Now()) {
+		cmdFacts := make(map[string]interface{})
+		for _, command := range c.commands {
+			fields := strings.Fields(command)
+			if len(fields) == 0 {
+				continue
+			}
+			out, err := runCommand(ctx, factsCommandTimeout, fields[0], fields[1:]...)
+			if err == nil {
+				err = mergeFacts(cmdFacts, out)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("facts_cmd %q: %v", command, err))
+			}
+		}
+		c.cmdFacts = cmdFacts
+	}
+	for key, value := range c.cmdFacts {
+		facts[key] = value
+	}
+
+	if len(errs) > 0 {
+		return facts, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return facts, nil
+}
+
+func (c *FactsCollector) readDir(facts map[string]interface{}) error {
+	entries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	errs := make([]string, 0)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(c.dir, name))
+		if err == nil {
+			err = mergeFacts(facts, data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// mergeFacts decodes a YAML (and therefore also JSON) mapping into facts.
+func mergeFacts(facts map[string]interface{}, data []byte) error {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("expected a YAML or JSON mapping: %w", err)
+	}
+	for key, value := range values {
+		facts[key] = normalizeFact(value)
+	}
+	return nil
+}
+
+// normalizeFact converts maps with non-string keys, which YAML allows but
+// JSON cannot encode, into string-keyed maps.
+func normalizeFact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeFact(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeFact(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeFact(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+const (
+	maxWatchedFileSize = 1 << 20
+	maxDiffEdits       = 1000
+)
+
+type WatchedFilesInfo struct {
+	Files []WatchedFile `json:"files"`
+}
+
+// WatchedFile is the state of one watched file. Diff lists the lines
+// changed since the previous snapshot, with secrets redacted; it is empty
+// when only redacted values changed and omitted when the change was too
+// large (DiffTruncated) or the file is not text.
+type WatchedFile struct {
+	Path          string     `json:"path"`
+	SHA256        string     `json:"sha256"`
+	Size          int64      `json:"size"`
+	ModTime       time.Time  `json:"modTime"`
+	Changed       bool       `json:"changed,omitempty"`
+	Diff          []DiffLine `json:"diff,omitempty"`
+	DiffTruncated bool       `json:"diffTruncated,omitempty"`
+}
+
+// fileSnapshot is what the state store keeps per file: the hash of the
+// real content and the redacted lines to diff against.
+type fileSnapshot struct {
+	SHA256 string   `json:"sha256"`
+	Lines  []string `json:"lines,omitempty"`
+}
+
+// FileWatchCollector hashes watched files and reports what changed since
+// the previous snapshot, which is kept in the state store.
+type FileWatchCollector struct {
+	paths    []string
+	redact   []*regexp.Regexp
+	maxLines int
+	schedule schedule
+	events   *EventBuffer
+	state    *state.Bucket
+}
+
+func NewFileWatchCollector(cfg config.FileWatchConfig, events *EventBuffer, bucket *state.Bucket) (*FileWatchCollector, error) {
+	c := &FileWatchCollector{
+		paths:    cfg.Paths,
+		maxLines: cfg.MaxDiffLines,
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		state:    bucket,
+	}
+	for _, pattern := range cfg.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		c.redact = append(c.redact, re)
+	}
+	return c, nil
+}
+
+func (c *FileWatchCollector) Collect(ctx context.Context) (*WatchedFilesInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	var paths []string
+	for _, pattern := range c.paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	// Files are only reported as created once a first snapshot exists.
+	baseline := len(c.state.Keys()) == 0
+	info := &WatchedFilesInfo{Files: []WatchedFile{}}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		file, ok := c.check(path, baseline)
+		if ok {
+			info.Files = append(info.Files, file)
+		}
+	}
+	for _, path := range c.state.Keys() {
+		if !seen[path] {
+			c.state.Delete(path)
+			c.events.Add("config_file_removed", SeverityWarning,
+				fmt.Sprintf("Watched file %s was removed", path), map[string]string{"path": path})
+		}
+	}
+	return info, nil
+}
+
+func (c *FileWatchCollector) check(path string, baseline bool) (WatchedFile, bool) {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() > maxWatchedFileSize {
+		return WatchedFile{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return WatchedFile{}, false
+	}
+	sum := sha256.Sum256(data)
+	file := WatchedFile{
+		Path:    path,
+		SHA256:  hex.EncodeToString(sum[:]),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UTC(),
+	}
+	current := fileSnapshot{SHA256: file.SHA256}
+	text := !bytes.Contains(data, []byte{0})
+	if text {
+		current.Lines = c.redactLines(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+	}
+
+	var previous fileSnapshot
+	known := c.state.Get(path, &previous)
+	c.state.Put(path, current)
+	if !known {
+		if !baseline {
+			c.events.Add("config_file_created", SeverityInfo,
+				fmt.Sprintf("Watched file %s appeared", path), map[string]string{"path": path})
+		}
+		return file, true
+	}
+	if previous.SHA256 == current.SHA256 {
+		return file, true
+	}
+
+	file.Changed = true
+	attrs := map[string]string{"path": path, "sha256": file.SHA256}
+	if text {
+		diff, ok := diffLines(previous.Lines, current.Lines, maxDiffEdits)
+		if ok && len(diff) <= c.maxLines {
+			file.Diff = diff
+			added, removed := 0, 0
+			for _, d := range diff {
+				if d.Op == "+" {
+					added++
+				} else {
+					removed++
+				}
+			}
+			attrs["added"] = strconv.Itoa(added)
+			attrs["removed"] = strconv.Itoa(removed)
+		} else {
+			file.DiffTruncated = true
+		}
+	}
+	c.events.Add("config_file_changed", SeverityWarning, fmt.Sprintf("Watched file %s changed", path), attrs)
+	return file, true
+}
+
+// redactLines replaces everything after a redact pattern match, so
+// "password = hunter2" becomes "password = [REDACTED]".
+func (c *FileWatchCollector) redactLines(lines []string) []string {
+	for i, line := range lines {
+		for _, re := range c.redact {
+			if loc := re.FindStringIndex(line); loc != nil {
+				lines[i] = line[:loc[1]] + "[REDACTED]"
+				break
+			}
+		}
+	}
+	return lines
+}
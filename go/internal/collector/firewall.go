@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type FirewallInfo struct {
+	Backend         string            `json:"backend"`
+	Enabled         bool              `json:"enabled"`
+	RuleCount       int               `json:"ruleCount"`
+	DefaultPolicies map[string]string `json:"defaultPolicies,omitempty"`
+	Zone            string            `json:"zone,omitempty"`
+	Unexpected      bool              `json:"unexpected"`
+}
+
+type FirewallCollector struct {
+	expectEnabled bool
+	schedule      schedule
+	events        *EventBuffer
+	wasDisabled   bool
+}
+
+func NewFirewallCollector(cfg config.FirewallConfig, events *EventBuffer) *FirewallCollector {
+	return &FirewallCollector{
+		expectEnabled: cfg.ExpectEnabled,
+		schedule:      newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:        events,
+	}
+}
+
+func (c *FirewallCollector) Collect(ctx context.Context) (*FirewallInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	info, err := detectFirewall(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	disabled := c.expectEnabled && !info.Enabled
+	info.Unexpected = disabled
+	if disabled && !c.wasDisabled {
+		c.events.Add("firewall_disabled", SeverityCritical,
+			fmt.Sprintf("Firewall (%s) is not enabled", info.Backend),
+			map[string]string{"backend": info.Backend})
+	} else if !disabled && c.wasDisabled {
+		c.events.Add("firewall_enabled", SeverityInfo,
+			fmt.Sprintf("Firewall (%s) is enabled again", info.Backend),
+			map[string]string{"backend": info.Backend})
+	}
+	c.wasDisabled = disabled
+
+	return info, nil
+}
@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// detectFirewall returns the first active firewall front-end, checking the
+// management tools before the raw nftables/iptables rulesets they generate.
+func detectFirewall(ctx context.Context) (*FirewallInfo, error) {
+	var fallback *FirewallInfo
+	for _, probe := range []func(context.Context) *FirewallInfo{probeUFW, probeFirewalld, probeNftables, probeIptables} {
+		info := probe(ctx)
+		if info == nil {
+			continue
+		}
+		if info.Enabled {
+			return info, nil
+		}
+		if fallback == nil {
+			fallback = info
+		}
+	}
+
+	if fallback == nil {
+		fallback = &FirewallInfo{Backend: "none"}
+	}
+	return fallback, nil
+}
+
+func probeUFW(ctx context.Context) *FirewallInfo {
+	if _, err := exec.LookPath("ufw"); err != nil {
+		return nil
+	}
+	out, err := runCommand(ctx, 0, "ufw", "status", "verbose")
+	if err != nil {
+		return nil
+	}
+	return parseUFWStatus(out)
+}
+
+// parseUFWStatus parses `ufw status verbose`:
+//
+//	Status: active
+//	Default: deny (incoming), allow (outgoing), disabled (routed)
+//	...
+//	--                         ------      ----
+//	22/tcp                     ALLOW IN    Anywhere
+func parseUFWStatus(out []byte) *FirewallInfo {
+	info := &FirewallInfo{Backend: "ufw", DefaultPolicies: make(map[string]string)}
+
+	inRules := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			info.Enabled = strings.TrimSpace(strings.TrimPrefix(line, "Status:")) == "active"
+		case strings.HasPrefix(line, "Default:"):
+			for _, part := range strings.Split(strings.TrimPrefix(line, "Default:"), ",") {
+				fields := strings.Fields(part)
+				if len(fields) == 2 {
+					info.DefaultPolicies[strings.Trim(fields[1], "()")] = fields[0]
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			inRules = true
+		case inRules && line != "":
+			info.RuleCount++
+		}
+	}
+	return info
+}
+
+func probeFirewalld(ctx context.Context) *FirewallInfo {
+	if _, err := exec.LookPath("firewall-cmd"); err != nil {
+		return nil
+	}
+
+	info := &FirewallInfo{Backend: "firewalld"}
+	// firewall-cmd exits non-zero when the daemon is not running.
+	out, err := runCommand(ctx, 0, "firewall-cmd", "--state")
+	if err != nil || strings.TrimSpace(string(out)) != "running" {
+		return info
+	}
+	info.Enabled = true
+
+	if out, err := runCommand(ctx, 0, "firewall-cmd", "--get-default-zone"); err == nil {
+		info.Zone = strings.TrimSpace(string(out))
+	}
+	for _, flag := range []string{"--list-services", "--list-ports"} {
+		if out, err := runCommand(ctx, 0, "firewall-cmd", flag); err == nil {
+			info.RuleCount += len(strings.Fields(string(out)))
+		}
+	}
+	if out, err := runCommand(ctx, 0, "firewall-cmd", "--list-rich-rules"); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.TrimSpace(line) != "" {
+				info.RuleCount++
+			}
+		}
+	}
+	return info
+}
+
+func probeNftables(ctx context.Context) *FirewallInfo {
+	if _, err := exec.LookPath("nft"); err != nil {
+		return nil
+	}
+	out, err := runCommand(ctx, 0, "nft", "list", "ruleset")
+	if err != nil {
+		return nil
+	}
+	return parseNftRuleset(out)
+}
+
+// parseNftRuleset counts rules inside chains and records base chain policies
+// from lines like "type filter hook input priority filter; policy drop;".
+func parseNftRuleset(out []byte) *FirewallInfo {
+	info := &FirewallInfo{Backend: "nftables", DefaultPolicies: make(map[string]string)}
+
+	inChain := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "chain "):
+			inChain = true
+		case line == "}":
+			inChain = false
+		case inChain && strings.HasPrefix(line, "type "):
+			fields := strings.Fields(strings.ReplaceAll(line, ";", " "))
+			var hook, policy string
+			for i := 0; i < len(fields)-1; i++ {
+				switch fields[i] {
+				case "hook":
+					hook = fields[i+1]
+				case "policy":
+					policy = fields[i+1]
+				}
+			}
+			if hook != "" && policy != "" {
+				info.DefaultPolicies[hook] = policy
+			}
+		case inChain && line != "":
+			info.RuleCount++
+		}
+	}
+
+	info.Enabled = info.RuleCount > 0 || hasRestrictivePolicy(info.DefaultPolicies)
+	return info
+}
+
+func probeIptables(ctx context.Context) *FirewallInfo {
+	if _, err := exec.LookPath("iptables"); err != nil {
+		return nil
+	}
+	out, err := runCommand(ctx, 0, "iptables", "-S")
+	if err != nil {
+		return nil
+	}
+
+	info := &FirewallInfo{Backend: "iptables", DefaultPolicies: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "-P":
+			if len(fields) == 3 {
+				info.DefaultPolicies[strings.ToLower(fields[1])] = strings.ToLower(fields[2])
+			}
+		case "-A":
+			info.RuleCount++
+		}
+	}
+
+	info.Enabled = info.RuleCount > 0 || hasRestrictivePolicy(info.DefaultPolicies)
+	return info
+}
+
+func hasRestrictivePolicy(policies map[string]string) bool {
+	for _, policy := range policies {
+		if policy == "drop" || policy == "reject" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func detectFirewall(ctx context.Context) (*FirewallInfo, error) {
+	return nil, fmt.Errorf("firewall detection is not supported on this platform")
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+// HardwareInfo is the host's hardware inventory. Serial numbers need root
+// on Linux and are left empty otherwise.
+type HardwareInfo struct {
+	System HardwareSystem   `json:"system"`
+	Memory []MemoryModule   `json:"memory,omitempty"`
+	Disks  []PhysicalDisk   `json:"disks,omitempty"`
+	NICs   []NetworkAdapter `json:"nics,omitempty"`
+}
+
+type HardwareSystem struct {
+	Vendor      string `json:"vendor,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	BoardVendor string `json:"boardVendor,omitempty"`
+	BoardModel  string `json:"boardModel,omitempty"`
+	BIOSVendor  string `json:"biosVendor,omitempty"`
+	BIOSVersion string `json:"biosVersion,omitempty"`
+	BIOSDate    string `json:"biosDate,omitempty"`
+	CPUModel    string `json:"cpuModel,omitempty"`
+}
+
+type MemoryModule struct {
+	Locator      string `json:"locator,omitempty"`
+	Size         uint64 `json:"size"`
+	Type         string `json:"type,omitempty"`
+	SpeedMTs     int    `json:"speedMTs,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	PartNumber   string `json:"partNumber,omitempty"`
+}
+
+// PhysicalDisk is a whole disk. Type is "nvme", "ssd" or "hdd" when known.
+type PhysicalDisk struct {
+	Name   string `json:"name"`
+	Model  string `json:"model,omitempty"`
+	Serial string `json:"serial,omitempty"`
+	Size   uint64 `json:"size"`
+	Type   string `json:"type,omitempty"`
+}
+
+type NetworkAdapter struct {
+	Name      string `json:"name"`
+	MAC       string `json:"mac,omitempty"`
+	Vendor    string `json:"vendor,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Driver    string `json:"driver,omitempty"`
+	SpeedMbps int    `json:"speedMbps,omitempty"`
+}
+
+// HardwareCollector reports the inventory once per interval; it is nil in
+// other heartbeats.
+type HardwareCollector struct {
+	schedule schedule
+}
+
+func NewHardwareCollector(cfg config.HardwareConfig) *HardwareCollector {
+	return &HardwareCollector{schedule: newSchedule(time.Duration(cfg.Interval) * time.Second)}
+}
+
+func (c *HardwareCollector) Collect(ctx context.Context) (*HardwareInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	return collectHardware(ctx)
+}
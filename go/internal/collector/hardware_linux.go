@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+const (
+	dmiDir   = "/sys/class/dmi/id"
+	udevData = "/run/udev/data"
+)
+
+func collectHardware(ctx context.Context) (*HardwareInfo, error) {
+	info := &HardwareInfo{
+		System: HardwareSystem{
+			Vendor:      readSysfsString(dmiDir, "sys_vendor"),
+			Model:       readSysfsString(dmiDir, "product_name"),
+			Serial:      readSysfsString(dmiDir, "product_serial"),
+			BoardVendor: readSysfsString(dmiDir, "board_vendor"),
+			BoardModel:  readSysfsString(dmiDir, "board_name"),
+			BIOSVendor:  readSysfsString(dmiDir, "bios_vendor"),
+			BIOSVersion: readSysfsString(dmiDir, "bios_version"),
+			BIOSDate:    readSysfsString(dmiDir, "bios_date"),
+		},
+	}
+	if cpus, err := cpu.Info(); err == nil && len(cpus) > 0 {
+		info.System.CPUModel = cpus[0].ModelName
+	}
+	if _, err := exec.LookPath("dmidecode"); err == nil {
+		if out, err := runCommand(ctx, 0, "dmidecode", "-t", "17"); err == nil {
+			info.Memory = parseDmidecodeMemory(out)
+		}
+	}
+	info.Disks = readPhysicalDisks()
+	info.NICs = readNetworkAdapters()
+	return info, nil
+}
+
+// parseDmidecodeMemory reads "Memory Device" (type 17) records, skipping
+// empty slots.
+func parseDmidecodeMemory(out []byte) []MemoryModule {
+	var modules []MemoryModule
+	var current *MemoryModule
+	flush := func() {
+		if current != nil && current.Size > 0 {
+			modules = append(modules, *current)
+		}
+		current = nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "Memory Device" {
+			flush()
+			current = &MemoryModule{}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			if strings.TrimSpace(line) == "" {
+				flush()
+			}
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "Unknown" || value == "Not Specified" || strings.HasPrefix(value, "NO DIMM") {
+			continue
+		}
+		switch key {
+		case "Size":
+			current.Size = parseDMISize(value)
+		case "Locator":
+			current.Locator = value
+		case "Type":
+			current.Type = value
+		case "Speed":
+			current.SpeedMTs, _ = strconv.Atoi(strings.Fields(value)[0])
+		case "Manufacturer":
+			current.Manufacturer = value
+		case "Serial Number":
+			current.Serial = value
+		case "Part Number":
+			current.PartNumber = value
+		}
+	}
+	flush()
+	return modules
+}
+
+// parseDMISize parses sizes such as "16 GB" or "16384 MB".
+func parseDMISize(value string) uint64 {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0
+	}
+	n, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch fields[1] {
+	case "kB", "KB":
+		return n << 10
+	case "MB":
+		return n << 20
+	case "GB":
+		return n << 30
+	case "TB":
+		return n << 40
+	}
+	return 0
+}
+
+func readPhysicalDisks() []PhysicalDisk {
+	entries, _ := os.ReadDir("/sys/block")
+	var disks []PhysicalDisk
+	for _, e := range entries {
+		name := e.Name()
+		dir := filepath.Join("/sys/block", name)
+		// Only disks backed by a device; this skips loop, dm, md and zram.
+		if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+			continue
+		}
+		if strings.HasPrefix(name, "sr") {
+			continue
+		}
+		sectors, _ := strconv.ParseUint(readSysfsString(dir, "size"), 10, 64)
+		disk := PhysicalDisk{
+			Name:   name,
+			Model:  readSysfsString(filepath.Join(dir, "device"), "model"),
+			Serial: readSysfsString(filepath.Join(dir, "device"), "serial"),
+			Size:   sectors * 512,
+		}
+		udev := readUdevProperties("b" + readSysfsString(dir, "dev"))
+		if disk.Serial == "" {
+			disk.Serial = udev["ID_SERIAL_SHORT"]
+		}
+		if disk.Model == "" {
+			disk.Model = strings.ReplaceAll(udev["ID_MODEL"], "_", " ")
+		}
+		switch {
+		case strings.HasPrefix(name, "nvme"):
+			disk.Type = "nvme"
+		case readSysfsString(dir, "queue/rotational") == "1":
+			disk.Type = "hdd"
+		case readSysfsString(dir, "queue/rotational") == "0":
+			disk.Type = "ssd"
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// readNetworkAdapters lists interfaces backed by a device, which excludes
+// bridges, bonds, tunnels and other virtual interfaces.
+func readNetworkAdapters() []NetworkAdapter {
+	entries, _ := os.ReadDir("/sys/class/net")
+	var nics []NetworkAdapter
+	for _, e := range entries {
+		dir := filepath.Join("/sys/class/net", e.Name())
+		if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+			continue
+		}
+		nic := NetworkAdapter{
+			Name: e.Name(),
+			MAC:  readSysfsString(dir, "address"),
+		}
+		if driver, err := os.Readlink(filepath.Join(dir, "device", "driver")); err == nil {
+			nic.Driver = filepath.Base(driver)
+		}
+		if speed, err := strconv.Atoi(readSysfsString(dir, "speed")); err == nil && speed > 0 {
+			nic.SpeedMbps = speed
+		}
+		udev := readUdevProperties("n" + readSysfsString(dir, "ifindex"))
+		nic.Vendor = udev["ID_VENDOR_FROM_DATABASE"]
+		nic.Model = udev["ID_MODEL_FROM_DATABASE"]
+		nics = append(nics, nic)
+	}
+	return nics
+}
+
+// readUdevProperties reads the E: lines of a udev database entry, which
+// carry the hwdb vendor and model names.
+func readUdevProperties(id string) map[string]string {
+	props := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(udevData, id))
+	if err != nil {
+		return props
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if kv, ok := strings.CutPrefix(line, "E:"); ok {
+			if key, value, ok := strings.Cut(kv, "="); ok {
+				props[key] = value
+			}
+		}
+	}
+	return props
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectHardware(ctx context.Context) (*HardwareInfo, error) {
+	return nil, fmt.Errorf("hardware inventory is not available on this platform")
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+)
+
+type win32ComputerSystemProduct struct {
+	Vendor            string
+	Name              string
+	IdentifyingNumber string
+}
+
+type win32BaseBoard struct {
+	Manufacturer string
+	Product      string
+}
+
+type win32BIOS struct {
+	Manufacturer      string
+	SMBIOSBIOSVersion string
+	ReleaseDate       string
+}
+
+type win32Processor struct {
+	Name string
+}
+
+type win32PhysicalMemory struct {
+	DeviceLocator        string
+	Capacity             uint64
+	SMBIOSMemoryType     uint32
+	ConfiguredClockSpeed uint32
+	Manufacturer         string
+	SerialNumber         string
+	PartNumber           string
+}
+
+type win32DiskDrive struct {
+	Index        uint32
+	Model        string
+	SerialNumber string
+	Size         uint64
+}
+
+type win32NetworkAdapterHardware struct {
+	NetConnectionID string
+	MACAddress      string
+	Manufacturer    string
+	ProductName     string
+	ServiceName     string
+	Speed           uint64
+}
+
+// smbiosMemoryTypes names the SMBIOS memory type codes seen in practice.
+var smbiosMemoryTypes = map[uint32]string{
+	20: "DDR", 21: "DDR2", 24: "DDR3", 26: "DDR4", 34: "DDR5",
+	27: "LPDDR", 28: "LPDDR2", 29: "LPDDR3", 30: "LPDDR4", 35: "LPDDR5",
+}
+
+func collectHardware(ctx context.Context) (*HardwareInfo, error) {
+	var products []win32ComputerSystemProduct
+	if err := wmi.Query("SELECT Vendor, Name, IdentifyingNumber FROM Win32_ComputerSystemProduct", &products); err != nil {
+		return nil, fmt.Errorf("failed to query system product: %w", err)
+	}
+	info := &HardwareInfo{}
+	if len(products) > 0 {
+		info.System.Vendor = products[0].Vendor
+		info.System.Model = products[0].Name
+		info.System.Serial = products[0].IdentifyingNumber
+	}
+	var boards []win32BaseBoard
+	if err := wmi.Query("SELECT Manufacturer, Product FROM Win32_BaseBoard", &boards); err == nil && len(boards) > 0 {
+		info.System.BoardVendor = boards[0].Manufacturer
+		info.System.BoardModel = boards[0].Product
+	}
+	var bios []win32BIOS
+	if err := wmi.Query("SELECT Manufacturer, SMBIOSBIOSVersion, ReleaseDate FROM Win32_BIOS", &bios); err == nil && len(bios) > 0 {
+		info.System.BIOSVendor = bios[0].Manufacturer
+		info.System.BIOSVersion = bios[0].SMBIOSBIOSVersion
+		// ReleaseDate is a CIM datetime; keep the date part.
+		if len(bios[0].ReleaseDate) >= 8 {
+			d := bios[0].ReleaseDate
+			info.System.BIOSDate = d[4:6] + "/" + d[6:8] + "/" + d[:4]
+		}
+	}
+	var processors []win32Processor
+	if err := wmi.Query("SELECT Name FROM Win32_Processor", &processors); err == nil && len(processors) > 0 {
+		info.System.CPUModel = strings.TrimSpace(processors[0].Name)
+	}
+
+	var memory []win32PhysicalMemory
+	if err := wmi.Query("SELECT DeviceLocator, Capacity, SMBIOSMemoryType, ConfiguredClockSpeed, Manufacturer, SerialNumber, PartNumber FROM Win32_PhysicalMemory", &memory); err == nil {
+		for _, m := range memory {
+			info.Memory = append(info.Memory, MemoryModule{
+				Locator:      m.DeviceLocator,
+				Size:         m.Capacity,
+				Type:         smbiosMemoryTypes[m.SMBIOSMemoryType],
+				SpeedMTs:     int(m.ConfiguredClockSpeed),
+				Manufacturer: strings.TrimSpace(m.Manufacturer),
+				Serial:       strings.TrimSpace(m.SerialNumber),
+				PartNumber:   strings.TrimSpace(m.PartNumber),
+			})
+		}
+	}
+
+	var disks []win32DiskDrive
+	if err := wmi.Query("SELECT Index, Model, SerialNumber, Size FROM Win32_DiskDrive", &disks); err == nil {
+		for _, d := range disks {
+			info.Disks = append(info.Disks, PhysicalDisk{
+				Name:   fmt.Sprintf("PhysicalDrive%d", d.Index),
+				Model:  d.Model,
+				Serial: strings.TrimSpace(d.SerialNumber),
+				Size:   d.Size,
+			})
+		}
+	}
+
+	var nics []win32NetworkAdapterHardware
+	if err := wmi.Query("SELECT NetConnectionID, MACAddress, Manufacturer, ProductName, ServiceName, Speed FROM Win32_NetworkAdapter WHERE PhysicalAdapter = TRUE", &nics); err == nil {
+		for _, n := range nics {
+			info.NICs = append(info.NICs, NetworkAdapter{
+				Name:      n.NetConnectionID,
+				MAC:       strings.ToLower(n.MACAddress),
+				Vendor:    n.Manufacturer,
+				Model:     n.ProductName,
+				Driver:    n.ServiceName,
+				SpeedMbps: int(n.Speed / 1000000),
+			})
+		}
+	}
+	return info, nil
+}
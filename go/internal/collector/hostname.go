@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	HostnameSystem = "system"
+	HostnameShort  = "short"
+	HostnameFQDN   = "fqdn"
+)
+
+const fqdnCacheTTL = 10 * time.Minute
+
+// HostnameResolver decides which hostname is reported and emits an event
+// when the system hostname changes, e.g. after a VM clone is renamed.
+type HostnameResolver struct {
+	override string
+	format   string
+	events   *EventBuffer
+
+	last      string
+	fqdn      string
+	fqdnFor   string
+	fqdnUntil time.Time
+}
+
+func NewHostnameResolver(cfg config.HostnameConfig, events *EventBuffer) *HostnameResolver {
+	return &HostnameResolver{override: cfg.Override, format: cfg.Format, events: events}
+}
+
+// Resolve returns the hostname to report given the system hostname.
+func (r *HostnameResolver) Resolve(system string) string {
+	if r.last != "" && system != r.last {
+		r.events.Add("hostname_changed", SeverityWarning,
+			fmt.Sprintf("Hostname changed from %s to %s", r.last, system),
+			map[string]string{"previous": r.last, "current": system})
+	}
+	r.last = system
+
+	if r.override != "" {
+		return r.override
+	}
+	switch r.format {
+	case HostnameShort:
+		short, _, _ := strings.Cut(system, ".")
+		return short
+	case HostnameFQDN:
+		return r.lookupFQDN(system)
+	default:
+		return system
+	}
+}
+
+// lookupFQDN mirrors `hostname -f`: resolve the hostname and take the first
+// fully qualified reverse name of its addresses. Results are cached so DNS
+// is not queried on every heartbeat.
+func (r *HostnameResolver) lookupFQDN(hostname string) string {
+	now := time.Now()
+	if r.fqdnFor == hostname && now.Before(r.fqdnUntil) {
+		return r.fqdn
+	}
+
+	fqdn := hostname
+	if !strings.Contains(hostname, ".") {
+		if addrs, err := net.LookupHost(hostname); err == nil {
+		lookup:
+			for _, addr := range addrs {
+				names, err := net.LookupAddr(addr)
+				if err != nil {
+					continue
+				}
+				for _, name := range names {
+					name = strings.TrimSuffix(name, ".")
+					if strings.Contains(name, ".") && !strings.HasPrefix(name, "localhost") {
+						fqdn = name
+						break lookup
+					}
+				}
+			}
+		}
+	}
+
+	r.fqdn, r.fqdnFor, r.fqdnUntil = fqdn, hostname, now.Add(fqdnCacheTTL)
+	return fqdn
+}
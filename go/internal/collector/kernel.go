@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// kernelEventInterval suppresses repeats of the same event for the same
+// process or device, since failing disks can log thousands of lines.
+const kernelEventInterval = 5 * time.Minute
+
+type KernelInfo struct {
+	OOMKills       uint64 `json:"oomKills"`
+	FSErrors       uint64 `json:"fsErrors"`
+	IOErrors       uint64 `json:"ioErrors"`
+	HardwareErrors uint64 `json:"hardwareErrors"`
+}
+
+type kernelPattern struct {
+	eventType string
+	severity  string
+	re        *regexp.Regexp
+	// attrs names the regexp's capture groups.
+	attrs   []string
+	message func(m []string) string
+	counter func(info *KernelInfo) *uint64
+}
+
+var kernelPatterns = []kernelPattern{
+	{
+		eventType: "oom_kill",
+		severity:  SeverityCritical,
+		re:        regexp.MustCompile(`(Memory cgroup out of memory|Out of memory).*: Killed process (\d+) \(([^)]*)\)`),
+		attrs:     []string{"scope", "pid", "process"},
+		message: func(m []string) string {
+			return fmt.Sprintf("Kernel OOM killer killed %s (pid %s)", m[3], m[2])
+		},
+		counter: func(info *KernelInfo) *uint64 { return &info.OOMKills },
+	},
+	{
+		eventType: "filesystem_error",
+		severity:  SeverityCritical,
+		re:        regexp.MustCompile(`(EXT[234]-fs|BTRFS) (?:error|critical) \(device ([^)]+)\)`),
+		attrs:     []string{"filesystem", "device"},
+		message: func(m []string) string {
+			return fmt.Sprintf("%s error on %s", m[1], m[2])
+		},
+		counter: func(info *KernelInfo) *uint64 { return &info.FSErrors },
+	},
+	{
+		eventType: "filesystem_error",
+		severity:  SeverityCritical,
+		re:        regexp.MustCompile(`(XFS) \(([^)]+)\): .*(?:[Cc]orrupt|I/O error|Internal error)`),
+		attrs:     []string{"filesystem", "device"},
+		message: func(m []string) string {
+			return fmt.Sprintf("%s error on %s", m[1], m[2])
+		},
+		counter: func(info *KernelInfo) *uint64 { return &info.FSErrors },
+	},
+	{
+		eventType: "disk_io_error",
+		severity:  SeverityWarning,
+		re:        regexp.MustCompile(`I/O error,? dev ([^, ]+)`),
+		attrs:     []string{"device"},
+		message: func(m []string) string {
+			return fmt.Sprintf("I/O error on %s", m[1])
+		},
+		counter: func(info *KernelInfo) *uint64 { return &info.IOErrors },
+	},
+	{
+		eventType: "hardware_error",
+		severity:  SeverityCritical,
+		re:        regexp.MustCompile(`\[Hardware Error\]:?\s*(.*)|(EDAC \S+: .*(?:CE|UE) .*)`),
+		attrs:     []string{"detail", "edac"},
+		message: func(m []string) string {
+			if m[2] != "" {
+				return "Memory error: " + m[2]
+			}
+			return "Machine check: " + m[1]
+		},
+		counter: func(info *KernelInfo) *uint64 { return &info.HardwareErrors },
+	},
+}
+
+// KernelWatcher follows the kernel log and turns OOM kills, filesystem and
+// disk errors and machine checks into events as they happen.
+type KernelWatcher struct {
+	events *EventBuffer
+
+	mu       sync.Mutex
+	info     KernelInfo
+	lastSent map[string]time.Time
+}
+
+func NewKernelWatcher(events *EventBuffer) *KernelWatcher {
+	return &KernelWatcher{events: events, lastSent: make(map[string]time.Time)}
+}
+
+// Start begins following the kernel log in the background. Only messages
+// logged after Start are reported.
+func (w *KernelWatcher) Start() error {
+	return followKernelLog(w.handle)
+}
+
+func (w *KernelWatcher) Collect(ctx context.Context) (*KernelInfo, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info := w.info
+	return &info, nil
+}
+
+func (w *KernelWatcher) handle(line string) {
+	for _, p := range kernelPatterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		attrs := map[string]string{"message": line}
+		key := p.eventType
+		for i, name := range p.attrs {
+			if m[i+1] != "" {
+				attrs[name] = m[i+1]
+				// Free-form details would defeat rate limiting; only the
+				// kind of detail is part of the key.
+				if name == "pid" || name == "detail" || name == "edac" {
+					key += " " + name
+				} else {
+					key += " " + m[i+1]
+				}
+			}
+		}
+
+		w.mu.Lock()
+		*p.counter(&w.info)++
+		now := time.Now()
+		suppressed := now.Sub(w.lastSent[key]) < kernelEventInterval
+		// OOM kills are always reported; each one is a distinct victim.
+		if p.eventType == "oom_kill" {
+			suppressed = false
+		}
+		if !suppressed {
+			w.lastSent[key] = now
+		}
+		w.mu.Unlock()
+
+		if !suppressed {
+			w.events.Add(p.eventType, p.severity, p.message(m), attrs)
+		}
+		return
+	}
+}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// followKernelLog reads /dev/kmsg, which needs root or CAP_SYSLOG, and
+// falls back to journalctl -k. The device is opened before the agent drops
+// privileges, so the watcher keeps working inside the sandbox.
+func followKernelLog(handle func(string)) error {
+	f, err := os.Open("/dev/kmsg")
+	if err == nil {
+		// Skip the existing ring buffer; only new messages are reported.
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return err
+		}
+		go readKmsg(f, handle)
+		return nil
+	}
+
+	cmd := exec.Command("journalctl", "-k", "-f", "-n", "0", "-o", "cat", "--no-pager")
+	out, pipeErr := cmd.StdoutPipe()
+	if pipeErr != nil {
+		return pipeErr
+	}
+	if startErr := cmd.Start(); startErr != nil {
+		return fmt.Errorf("cannot read /dev/kmsg (%v) or run journalctl (%v)", err, startErr)
+	}
+	go func() {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			handle(scanner.Text())
+		}
+		cmd.Wait()
+		log.Printf("Kernel log watcher stopped")
+	}()
+	return nil
+}
+
+// readKmsg handles one record per read: "prio,seq,usec,flags;message",
+// followed by optional continuation lines.
+func readKmsg(f *os.File, handle func(string)) {
+	defer f.Close()
+	buf := make([]byte, 8192)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			// EPIPE means records were overwritten before we read them.
+			if errors.Is(err, syscall.EPIPE) {
+				continue
+			}
+			log.Printf("Kernel log watcher stopped: %v", err)
+			return
+		}
+		record := string(buf[:n])
+		if i := strings.IndexByte(record, ';'); i >= 0 {
+			record = record[i+1:]
+		}
+		if i := strings.IndexByte(record, '\n'); i >= 0 {
+			record = record[:i]
+		}
+		handle(record)
+	}
+}
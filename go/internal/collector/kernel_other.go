@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func followKernelLog(handle func(string)) error {
+	return fmt.Errorf("kernel log monitoring is not supported on this platform")
+}
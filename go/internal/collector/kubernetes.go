@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/kube"
+)
+
+// Labels the kubelet sets on every container it starts.
+const (
+	kubeNamespaceLabel = "io.kubernetes.pod.namespace"
+	kubePodLabel       = "io.kubernetes.pod.name"
+	kubeContainerLabel = "io.kubernetes.container.name"
+)
+
+// kubeMinRefresh limits pod list refreshes triggered by unknown containers.
+const kubeMinRefresh = 10 * time.Second
+
+// kubeWorkload is the controller a pod belongs to, with ReplicaSets
+// resolved to their Deployment.
+type kubeWorkload struct {
+	kind string
+	name string
+}
+
+// kubeResolver maps container IDs to workloads from the pods on this node.
+type kubeResolver struct {
+	client   *kube.Client
+	node     string
+	schedule schedule
+
+	mu        sync.Mutex
+	workloads map[string]kubeWorkload
+	refreshed time.Time
+}
+
+func newKubeResolver(cfg config.KubernetesConfig) (*kubeResolver, error) {
+	client, err := kube.NewInCluster()
+	if err != nil {
+		return nil, err
+	}
+	node := cfg.NodeName
+	if node == "" {
+		node = os.Getenv("NODE_NAME")
+	}
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+	return &kubeResolver{
+		client:    client,
+		node:      node,
+		schedule:  newSchedule(time.Duration(cfg.Interval) * time.Second),
+		workloads: make(map[string]kubeWorkload),
+	}, nil
+}
+
+// labelKubernetesContainers sets the labels of containers started by the
+// kubelet: namespace, pod and container from the container's own labels,
+// plus workload and workloadKind when a resolver is configured.
+func labelKubernetesContainers(ctx context.Context, r *kubeResolver, containers []runtimeContainer, out []ContainerInfo) {
+	var workloads map[string]kubeWorkload
+	if r != nil {
+		workloads = r.lookup(ctx, containers)
+	}
+	for i := range containers {
+		ctr := &containers[i]
+		namespace := ctr.Labels[kubeNamespaceLabel]
+		if namespace == "" {
+			continue
+		}
+		labels := map[string]string{
+			"namespace": namespace,
+			"pod":       ctr.Labels[kubePodLabel],
+			"container": ctr.Labels[kubeContainerLabel],
+		}
+		if w, ok := workloads[ctr.ID]; ok {
+			labels["workload"] = w.name
+			labels["workloadKind"] = w.kind
+		}
+		out[i].Labels = labels
+	}
+}
+
+func (r *kubeResolver) lookup(ctx context.Context, containers []runtimeContainer) map[string]kubeWorkload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	stale := false
+	for i := range containers {
+		if _, ok := r.workloads[containers[i].ID]; !ok && containers[i].Labels[kubePodLabel] != "" {
+			stale = true
+			break
+		}
+	}
+	if (stale && now.Sub(r.refreshed) >= kubeMinRefresh) || r.schedule.due(now) {
+		r.refreshed = now
+		if err := r.refresh(ctx); err != nil {
+			log.Printf("Error listing Kubernetes pods: %v", err)
+		}
+	}
+	return r.workloads
+}
+
+func (r *kubeResolver) refresh(ctx context.Context) error {
+	pods, err := r.client.NodePods(ctx, r.node)
+	if err != nil {
+		return err
+	}
+	workloads := make(map[string]kubeWorkload)
+	for i := range pods {
+		pod := &pods[i]
+		w := podWorkload(pod)
+		statuses := append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...)
+		for _, status := range statuses {
+			if _, id, ok := strings.Cut(status.ContainerID, "://"); ok {
+				workloads[id] = w
+			}
+		}
+	}
+	r.workloads = workloads
+	return nil
+}
+
+// podWorkload resolves the pod's controller without further API calls: a
+// Deployment's ReplicaSet is named "<deployment>-<pod-template-hash>".
+// Pods without a controller are their own workload.
+func podWorkload(pod *kube.Pod) kubeWorkload {
+	owner, ok := pod.Controller()
+	if !ok {
+		return kubeWorkload{kind: "Pod", name: pod.Metadata.Name}
+	}
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Metadata.Labels["pod-template-hash"]; hash != "" {
+			if name, ok := strings.CutSuffix(owner.Name, "-"+hash); ok {
+				return kubeWorkload{kind: "Deployment", name: name}
+			}
+		}
+	}
+	return kubeWorkload{kind: owner.Kind, name: owner.Name}
+}
@@ -0,0 +1,75 @@
+package collector
+
+// DiffLine is a removed ("-", Line in the old file) or added ("+", Line in
+// the new file) line.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// diffLines computes a shortest line diff with Myers' algorithm. It gives
+// up, returning false, when more than maxEdits lines changed, which also
+// bounds the memory used to O(maxEdits²).
+func diffLines(a, b []string, maxEdits int) ([]DiffLine, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		// Keep the k range [-d-1, d+1] of the previous round to backtrack.
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b), true
+			}
+		}
+	}
+	return nil, true
+}
+
+func backtrack(trace [][]int, a, b []string) []DiffLine {
+	var edits []DiffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, DiffLine{Op: "+", Line: prevY + 1, Text: b[prevY]})
+		} else {
+			edits = append(edits, DiffLine{Op: "-", Line: prevX + 1, Text: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
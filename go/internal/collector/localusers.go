@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+type LocalUsersInfo struct {
+	Users  []LocalUser  `json:"users"`
+	Groups []LocalGroup `json:"groups"`
+}
+
+// LocalUser is a local account. ID is the UID, or the SID on Windows.
+// Admin is set for sudoers (directly or through a group) and members of
+// the Administrators group.
+type LocalUser struct {
+	Name            string     `json:"name"`
+	ID              string     `json:"id"`
+	Shell           string     `json:"shell,omitempty"`
+	System          bool       `json:"system,omitempty"`
+	Admin           bool       `json:"admin,omitempty"`
+	Disabled        bool       `json:"disabled,omitempty"`
+	PasswordExpires *time.Time `json:"passwordExpires,omitempty"`
+	PasswordExpired bool       `json:"passwordExpired,omitempty"`
+}
+
+type LocalGroup struct {
+	Name    string   `json:"name"`
+	ID      string   `json:"id"`
+	Members []string `json:"members,omitempty"`
+}
+
+// LocalUsersCollector inventories local accounts and groups. The previous
+// inventory is kept in the state store so changes made while the agent was
+// stopped are reported too.
+type LocalUsersCollector struct {
+	schedule schedule
+	events   *EventBuffer
+	state    *state.Bucket
+}
+
+func NewLocalUsersCollector(cfg config.LocalUsersConfig, events *EventBuffer, bucket *state.Bucket) *LocalUsersCollector {
+	return &LocalUsersCollector{
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		state:    bucket,
+	}
+}
+
+func (c *LocalUsersCollector) Collect(ctx context.Context) (*LocalUsersInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	info, err := collectLocalUsers()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(info.Users, func(a, b LocalUser) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(info.Groups, func(a, b LocalGroup) int { return strings.Compare(a.Name, b.Name) })
+
+	var previous LocalUsersInfo
+	if c.state.Get("inventory", &previous) {
+		c.diff(previous, *info)
+	}
+	if err := c.state.Put("inventory", info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c *LocalUsersCollector) diff(previous, current LocalUsersInfo) {
+	before := make(map[string]LocalUser, len(previous.Users))
+	for _, u := range previous.Users {
+		before[u.Name] = u
+	}
+	after := make(map[string]bool, len(current.Users))
+	for _, u := range current.Users {
+		after[u.Name] = true
+		attrs := map[string]string{"user": u.Name, "id": u.ID}
+		old, existed := before[u.Name]
+		switch {
+		case !existed:
+			c.events.Add("user_added", SeverityWarning, fmt.Sprintf("Local user %s was added", u.Name), attrs)
+			if u.Admin {
+				c.events.Add("admin_granted", SeverityWarning, fmt.Sprintf("%s has administrative rights", u.Name), attrs)
+			}
+		case u.Admin && !old.Admin:
+			c.events.Add("admin_granted", SeverityWarning, fmt.Sprintf("%s was granted administrative rights", u.Name), attrs)
+		case !u.Admin && old.Admin:
+			c.events.Add("admin_revoked", SeverityInfo, fmt.Sprintf("%s no longer has administrative rights", u.Name), attrs)
+		}
+	}
+	for _, u := range previous.Users {
+		if !after[u.Name] {
+			c.events.Add("user_removed", SeverityInfo, fmt.Sprintf("Local user %s was removed", u.Name),
+				map[string]string{"user": u.Name, "id": u.ID})
+		}
+	}
+
+	groups := make(map[string]LocalGroup, len(previous.Groups))
+	for _, g := range previous.Groups {
+		groups[g.Name] = g
+	}
+	for _, g := range current.Groups {
+		old, existed := groups[g.Name]
+		if !existed {
+			continue
+		}
+		added, removed := diffMembers(old.Members, g.Members)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		attrs := map[string]string{"group": g.Name}
+		if len(added) > 0 {
+			attrs["added"] = strings.Join(added, ",")
+		}
+		if len(removed) > 0 {
+			attrs["removed"] = strings.Join(removed, ",")
+		}
+		c.events.Add("group_membership_changed", SeverityInfo, fmt.Sprintf("Membership of group %s changed", g.Name), attrs)
+	}
+}
+
+func diffMembers(before, after []string) (added, removed []string) {
+	for _, m := range after {
+		if !slices.Contains(before, m) {
+			added = append(added, m)
+		}
+	}
+	for _, m := range before {
+		if !slices.Contains(after, m) {
+			removed = append(removed, m)
+		}
+	}
+	return added, removed
+}
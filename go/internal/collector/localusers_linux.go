@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func collectLocalUsers() (*LocalUsersInfo, error) {
+	passwd, err := readPasswd("/etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := readGroups("/etc/group")
+	if err != nil {
+		return nil, err
+	}
+	// Readable by root only; expiry is left out otherwise.
+	shadow, _ := readShadow("/etc/shadow")
+
+	uidMin := 1000
+	if values, err := readKeyValues("/etc/login.defs", " "); err == nil {
+		if v, err := strconv.Atoi(values["uid_min"]); err == nil {
+			uidMin = v
+		}
+	}
+
+	sudoUsers, sudoGroups := readSudoers("/etc/sudoers")
+	memberOf := make(map[string][]string)
+	primary := make(map[int]string)
+	for _, g := range groups {
+		gid, _ := strconv.Atoi(g.ID)
+		primary[gid] = g.Name
+		for _, m := range g.Members {
+			memberOf[m] = append(memberOf[m], g.Name)
+		}
+	}
+
+	info := &LocalUsersInfo{Groups: groups}
+	now := time.Now()
+	for _, e := range passwd {
+		user := LocalUser{
+			Name:   e.Name,
+			ID:     strconv.Itoa(e.UID),
+			Shell:  e.Shell,
+			System: e.UID != 0 && e.UID < uidMin || e.UID >= 65534,
+			Admin:  sudoUsers[e.Name] || sudoGroups[primary[e.GID]],
+		}
+		for _, g := range memberOf[e.Name] {
+			user.Admin = user.Admin || sudoGroups[g]
+		}
+		if s, ok := shadow[e.Name]; ok {
+			user.Disabled = strings.HasPrefix(s.password, "!")
+			if s.expires != nil {
+				user.PasswordExpires = s.expires
+				user.PasswordExpired = now.After(*s.expires)
+			}
+			user.PasswordExpired = user.PasswordExpired || s.mustChange
+		}
+		info.Users = append(info.Users, user)
+	}
+	return info, nil
+}
+
+func readGroups(path string) ([]LocalGroup, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var groups []LocalGroup
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 4 || fields[0] == "" || strings.HasPrefix(fields[0], "+") || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		g := LocalGroup{Name: fields[0], ID: fields[2]}
+		if fields[3] != "" {
+			g.Members = strings.Split(fields[3], ",")
+		}
+		groups = append(groups, g)
+	}
+	return groups, scanner.Err()
+}
+
+type shadowEntry struct {
+	password   string
+	expires    *time.Time
+	mustChange bool
+}
+
+// readShadow parses the password aging fields, which count days since the
+// epoch: the last change plus the maximum age is when the password expires.
+func readShadow(path string) (map[string]shadowEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make(map[string]shadowEntry)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 5 {
+			continue
+		}
+		e := shadowEntry{password: fields[1]}
+		lastChange, errLast := strconv.Atoi(fields[2])
+		maxAge, errMax := strconv.Atoi(fields[4])
+		switch {
+		case errLast == nil && lastChange == 0:
+			e.mustChange = true
+		case errLast == nil && errMax == nil && maxAge > 0 && maxAge < 99999:
+			expires := time.Unix(int64(lastChange+maxAge)*86400, 0).UTC()
+			e.expires = &expires
+		}
+		entries[fields[0]] = e
+	}
+	return entries, scanner.Err()
+}
+
+// readSudoers returns the users and groups (%group) granted rules in
+// sudoers and the files it includes. Aliases are not expanded.
+func readSudoers(path string) (users, groups map[string]bool) {
+	users, groups = make(map[string]bool), make(map[string]bool)
+	seen := make(map[string]bool)
+	var read func(path string)
+	read = func(path string) {
+		if seen[path] || len(seen) > 100 {
+			return
+		}
+		seen[path] = true
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch fields[0] {
+			case "#include", "@include":
+				if len(fields) > 1 {
+					read(sudoersPath(path, fields[1]))
+				}
+				continue
+			case "#includedir", "@includedir":
+				if len(fields) > 1 {
+					dir := sudoersPath(path, fields[1])
+					entries, _ := os.ReadDir(dir)
+					for _, e := range entries {
+						// sudo skips names with a dot or ending in ~.
+						if !e.IsDir() && !strings.Contains(e.Name(), ".") && !strings.HasSuffix(e.Name(), "~") {
+							read(filepath.Join(dir, e.Name()))
+						}
+					}
+				}
+				continue
+			}
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(fields[0], "Defaults") || strings.HasSuffix(fields[0], "_Alias") {
+				continue
+			}
+			for _, who := range strings.Split(fields[0], ",") {
+				if name, ok := strings.CutPrefix(who, "%"); ok {
+					groups[name] = true
+				} else if who != "" {
+					users[who] = true
+				}
+			}
+		}
+	}
+	read(path)
+	return users, groups
+}
+
+func sudoersPath(from, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
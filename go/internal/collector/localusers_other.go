@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package collector
+
+import "fmt"
+
+func collectLocalUsers() (*LocalUsersInfo, error) {
+	return nil, fmt.Errorf("local user inventory is only available on Linux and Windows")
+}
@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+)
+
+type win32UserAccount struct {
+	Name     string
+	SID      string
+	Disabled bool
+	Lockout  bool
+}
+
+type win32Group struct {
+	Name string
+	SID  string
+}
+
+type win32GroupUser struct {
+	GroupComponent string
+	PartComponent  string
+}
+
+// administratorsSID is the well-known SID of BUILTIN\Administrators, whose
+// name is localized.
+const administratorsSID = "S-1-5-32-544"
+
+var wmiReference = regexp.MustCompile(`Domain="([^"]*)",Name="([^"]*)"`)
+
+func collectLocalUsers() (*LocalUsersInfo, error) {
+	var accounts []win32UserAccount
+	if err := wmi.Query("SELECT Name, SID, Disabled, Lockout FROM Win32_UserAccount WHERE LocalAccount = True", &accounts); err != nil {
+		return nil, fmt.Errorf("failed to query local users: %w", err)
+	}
+	var groups []win32Group
+	if err := wmi.Query("SELECT Name, SID FROM Win32_Group WHERE LocalAccount = True", &groups); err != nil {
+		return nil, fmt.Errorf("failed to query local groups: %w", err)
+	}
+	var links []win32GroupUser
+	if err := wmi.Query("SELECT GroupComponent, PartComponent FROM Win32_GroupUser", &links); err != nil {
+		return nil, fmt.Errorf("failed to query group membership: %w", err)
+	}
+
+	computer, _ := os.Hostname()
+	members := make(map[string][]string)
+	for _, link := range links {
+		group := wmiReference.FindStringSubmatch(link.GroupComponent)
+		part := wmiReference.FindStringSubmatch(link.PartComponent)
+		if group == nil || part == nil || !strings.EqualFold(group[1], computer) {
+			continue
+		}
+		member := part[2]
+		if !strings.EqualFold(part[1], computer) {
+			member = part[1] + `\` + part[2]
+		}
+		members[group[2]] = append(members[group[2]], member)
+	}
+
+	info := &LocalUsersInfo{}
+	admins := make(map[string]bool)
+	for _, g := range groups {
+		info.Groups = append(info.Groups, LocalGroup{Name: g.Name, ID: g.SID, Members: members[g.Name]})
+		if g.SID == administratorsSID {
+			for _, m := range members[g.Name] {
+				admins[m] = true
+			}
+		}
+	}
+	for _, a := range accounts {
+		info.Users = append(info.Users, LocalUser{
+			Name:     a.Name,
+			ID:       a.SID,
+			Admin:    admins[a.Name],
+			Disabled: a.Disabled || a.Lockout,
+		})
+	}
+	return info, nil
+}
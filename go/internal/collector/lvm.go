@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type LVMInfo struct {
+	VolumeGroups []VolumeGroup `json:"volumeGroups"`
+	ThinPools    []ThinPool    `json:"thinPools"`
+}
+
+type VolumeGroup struct {
+	Name        string  `json:"name"`
+	Size        uint64  `json:"size"`
+	Free        uint64  `json:"free"`
+	FreePercent float64 `json:"freePercent"`
+}
+
+type ThinPool struct {
+	Name            string  `json:"name"`
+	VolumeGroup     string  `json:"volumeGroup"`
+	Size            uint64  `json:"size"`
+	DataPercent     float64 `json:"dataPercent"`
+	MetadataPercent float64 `json:"metadataPercent"`
+}
+
+type LVMCollector struct {
+	warnPercent float64
+	schedule    schedule
+	events      *EventBuffer
+	warned      map[string]bool
+}
+
+func NewLVMCollector(cfg config.LVMConfig, events *EventBuffer) *LVMCollector {
+	return &LVMCollector{
+		warnPercent: float64(cfg.WarnPercent),
+		schedule:    newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:      events,
+		warned:      make(map[string]bool),
+	}
+}
+
+func (c *LVMCollector) Collect(ctx context.Context) (*LVMInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	info, err := collectLVM(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, pool := range info.ThinPools {
+		key := pool.VolumeGroup + "/" + pool.Name
+		seen[key] = true
+		full := max(pool.DataPercent, pool.MetadataPercent) >= c.warnPercent
+		if full == c.warned[key] {
+			continue
+		}
+		c.warned[key] = full
+		attrs := map[string]string{
+			"volumeGroup":     pool.VolumeGroup,
+			"thinPool":        pool.Name,
+			"dataPercent":     fmt.Sprintf("%.1f", pool.DataPercent),
+			"metadataPercent": fmt.Sprintf("%.1f", pool.MetadataPercent),
+		}
+		if full {
+			c.events.Add("thin_pool_full", SeverityCritical,
+				fmt.Sprintf("Thin pool %s is %.1f%% data and %.1f%% metadata full", key, pool.DataPercent, pool.MetadataPercent), attrs)
+		} else {
+			c.events.Add("thin_pool_recovered", SeverityInfo,
+				fmt.Sprintf("Thin pool %s is below %.0f%% usage", key, c.warnPercent), attrs)
+		}
+	}
+	for key := range c.warned {
+		if !seen[key] {
+			delete(c.warned, key)
+		}
+	}
+	return info, nil
+}
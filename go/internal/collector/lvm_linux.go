@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// lvmReport is the JSON report format of vgs and lvs (LVM 2.02.158+).
+// All values are strings.
+type lvmReport struct {
+	Report []struct {
+		VG []map[string]string `json:"vg"`
+		LV []map[string]string `json:"lv"`
+	} `json:"report"`
+}
+
+func collectLVM(ctx context.Context) (*LVMInfo, error) {
+	info := &LVMInfo{
+		VolumeGroups: make([]VolumeGroup, 0),
+		ThinPools:    make([]ThinPool, 0),
+	}
+
+	vgs, err := runLVMReport(ctx, "vgs", "vg_name,vg_size,vg_free")
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range vgs.Report {
+		for _, vg := range report.VG {
+			group := VolumeGroup{
+				Name: vg["vg_name"],
+				Size: parseLVMUint(vg["vg_size"]),
+				Free: parseLVMUint(vg["vg_free"]),
+			}
+			if group.Size > 0 {
+				group.FreePercent = float64(group.Free) / float64(group.Size) * 100
+			}
+			info.VolumeGroups = append(info.VolumeGroups, group)
+		}
+	}
+
+	lvs, err := runLVMReport(ctx, "lvs", "lv_name,vg_name,lv_size,segtype,data_percent,metadata_percent")
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range lvs.Report {
+		for _, lv := range report.LV {
+			if lv["segtype"] != "thin-pool" {
+				continue
+			}
+			data, _ := strconv.ParseFloat(lv["data_percent"], 64)
+			metadata, _ := strconv.ParseFloat(lv["metadata_percent"], 64)
+			info.ThinPools = append(info.ThinPools, ThinPool{
+				Name:            lv["lv_name"],
+				VolumeGroup:     lv["vg_name"],
+				Size:            parseLVMUint(lv["lv_size"]),
+				DataPercent:     data,
+				MetadataPercent: metadata,
+			})
+		}
+	}
+	return info, nil
+}
+
+func runLVMReport(ctx context.Context, command, fields string) (*lvmReport, error) {
+	out, err := runCommand(ctx, 0, command, "--reportformat", "json", "--units", "b", "--nosuffix", "-o", fields)
+	if err != nil {
+		return nil, err
+	}
+	var report lvmReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", command, err)
+	}
+	return &report, nil
+}
+
+func parseLVMUint(s string) uint64 {
+	v, _ := strconv.ParseUint(s, 10, 64)
+	return v
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectLVM(ctx context.Context) (*LVMInfo, error) {
+	return nil, fmt.Errorf("LVM monitoring is not supported on this platform")
+}
@@ -0,0 +1,34 @@
+package collector
+
+import "context"
+
+type MacOSInfo struct {
+	ThermalPressure string          `json:"thermalPressure,omitempty"`
+	CPUSpeedLimit   int             `json:"cpuSpeedLimit,omitempty"`
+	Battery         *BatteryInfo    `json:"battery,omitempty"`
+	FileVault       *FileVaultState `json:"fileVault,omitempty"`
+}
+
+type BatteryInfo struct {
+	Name          string  `json:"name,omitempty"`
+	Percent       int     `json:"percent"`
+	State         string  `json:"state"`
+	PowerSource   string  `json:"powerSource"`
+	CycleCount    int     `json:"cycleCount,omitempty"`
+	HealthPercent float64 `json:"healthPercent,omitempty"`
+}
+
+type FileVaultState struct {
+	Enabled bool   `json:"enabled"`
+	Status  string `json:"status"`
+}
+
+type MacOSCollector struct{}
+
+func NewMacOSCollector() *MacOSCollector {
+	return &MacOSCollector{}
+}
+
+func (c *MacOSCollector) Collect(ctx context.Context) (*MacOSInfo, error) {
+	return collectMacOS(ctx)
+}
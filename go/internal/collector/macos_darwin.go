@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// thermalPressureLevels maps com.apple.system.thermalpressurelevel values.
+var thermalPressureLevels = map[string]string{
+	"0": "nominal",
+	"1": "moderate",
+	"2": "heavy",
+	"3": "trapping",
+	"4": "sleeping",
+}
+
+func collectMacOS(ctx context.Context) (*MacOSInfo, error) {
+	info := &MacOSInfo{}
+
+	if out, err := runCommand(ctx, 0, "notifyutil", "-g", "com.apple.system.thermalpressurelevel"); err == nil {
+		fields := strings.Fields(string(out))
+		if len(fields) == 2 {
+			info.ThermalPressure = thermalPressureLevels[fields[1]]
+		}
+	}
+	if out, err := runCommand(ctx, 0, "pmset", "-g", "therm"); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			if key, value, ok := strings.Cut(scanner.Text(), "="); ok && strings.TrimSpace(key) == "CPU_Speed_Limit" {
+				info.CPUSpeedLimit, _ = strconv.Atoi(strings.TrimSpace(value))
+			}
+		}
+	}
+
+	info.Battery = readMacBattery(ctx)
+
+	out, err := runCommand(ctx, 0, "fdesetup", "status")
+	if err != nil {
+		return info, fmt.Errorf("failed to read FileVault status: %w", err)
+	}
+	status := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	info.FileVault = &FileVaultState{
+		Enabled: strings.HasPrefix(status, "FileVault is On"),
+		Status:  status,
+	}
+
+	return info, nil
+}
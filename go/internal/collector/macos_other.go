@@ -0,0 +1,12 @@
+//go:build !darwin
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectMacOS(ctx context.Context) (*MacOSInfo, error) {
+	return nil, fmt.Errorf("macOS metrics are only available on macOS")
+}
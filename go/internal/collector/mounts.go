@@ -0,0 +1,244 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+
+	"sentinel-agent/internal/config"
+)
+
+var networkFilesystems = map[string]bool{
+	"nfs":        true,
+	"nfs4":       true,
+	"cifs":       true,
+	"smb3":       true,
+	"smbfs":      true,
+	"afpfs":      true,
+	"glusterfs":  true,
+	"ceph":       true,
+	"fuse.sshfs": true,
+}
+
+var errMountTimeout = errors.New("timed out")
+
+type NetworkMountsInfo struct {
+	Mounts []NetworkMount `json:"mounts"`
+	Hung   int            `json:"hung"`
+}
+
+// NetworkMount reports one network filesystem. Status is "ok", "hung" when
+// statfs or the test write did not return within the timeout, or "error".
+type NetworkMount struct {
+	MountPoint   string  `json:"mountPoint"`
+	Device       string  `json:"device"`
+	FSType       string  `json:"fsType"`
+	Status       string  `json:"status"`
+	LatencyMs    float64 `json:"latencyMs"`
+	Total        uint64  `json:"total,omitempty"`
+	Used         uint64  `json:"used,omitempty"`
+	Available    uint64  `json:"available,omitempty"`
+	UsagePercent float64 `json:"usagePercent,omitempty"`
+	ReadOnly     bool    `json:"readOnly,omitempty"`
+	Writable     *bool   `json:"writable,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+type NetworkMountCollector struct {
+	timeout   time.Duration
+	writeTest bool
+	events    *EventBuffer
+	states    map[string]string
+}
+
+func NewNetworkMountCollector(cfg config.NetworkMountsConfig, events *EventBuffer) *NetworkMountCollector {
+	return &NetworkMountCollector{
+		timeout:   time.Duration(cfg.Timeout) * time.Second,
+		writeTest: cfg.WriteTest,
+		events:    events,
+		states:    make(map[string]string),
+	}
+}
+
+func (c *NetworkMountCollector) Collect(ctx context.Context) (*NetworkMountsInfo, error) {
+	partitions, err := runBlocking(ctx, "partitions", c.timeout, func() ([]disk.PartitionStat, error) {
+		return disk.Partitions(true)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mounts: %w", err)
+	}
+
+	info := &NetworkMountsInfo{Mounts: make([]NetworkMount, 0)}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, p := range partitions {
+		if !networkFilesystems[p.Fstype] {
+			continue
+		}
+		wg.Add(1)
+		go func(p disk.PartitionStat) {
+			defer wg.Done()
+			mount := c.check(ctx, p)
+			mu.Lock()
+			info.Mounts = append(info.Mounts, mount)
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, mount := range info.Mounts {
+		seen[mount.MountPoint] = true
+		if mount.Status == "hung" {
+			info.Hung++
+		}
+		c.mountEvent(mount)
+	}
+	for mountPoint := range c.states {
+		if !seen[mountPoint] {
+			delete(c.states, mountPoint)
+		}
+	}
+	return info, nil
+}
+
+func (c *NetworkMountCollector) check(ctx context.Context, p disk.PartitionStat) NetworkMount {
+	mount := NetworkMount{
+		MountPoint: p.Mountpoint,
+		Device:     p.Device,
+		FSType:     p.Fstype,
+		Status:     "ok",
+	}
+	for _, opt := range p.Opts {
+		if opt == "ro" {
+			mount.ReadOnly = true
+		}
+	}
+
+	start := time.Now()
+	usage, err := mountUsage(ctx, p.Mountpoint, c.timeout)
+	mount.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		mount.Status, mount.Error = mountStatus(err)
+		return mount
+	}
+	mount.Total = usage.Total
+	mount.Used = usage.Used
+	mount.Available = usage.Free
+	mount.UsagePercent = usage.UsedPercent
+
+	if c.writeTest && !mount.ReadOnly {
+		_, err := runBlocking(ctx, "write "+p.Mountpoint, c.timeout, func() (struct{}, error) {
+			return struct{}{}, writeTestFile(p.Mountpoint)
+		})
+		writable := err == nil
+		mount.Writable = &writable
+		if err != nil {
+			mount.Status, mount.Error = mountStatus(err)
+			mount.Error = "test write failed: " + mount.Error
+		}
+	}
+	return mount
+}
+
+func mountStatus(err error) (string, string) {
+	if errors.Is(err, errMountTimeout) {
+		return "hung", err.Error()
+	}
+	return "error", err.Error()
+}
+
+func (c *NetworkMountCollector) mountEvent(mount NetworkMount) {
+	previous, known := c.states[mount.MountPoint]
+	c.states[mount.MountPoint] = mount.Status
+	if mount.Status == previous || (!known && mount.Status == "ok") {
+		return
+	}
+	attrs := map[string]string{
+		"mountPoint": mount.MountPoint,
+		"device":     mount.Device,
+		"fsType":     mount.FSType,
+	}
+	switch {
+	case mount.Status == "hung":
+		c.events.Add("mount_hung", SeverityCritical,
+			fmt.Sprintf("Network mount %s (%s) is not responding: %s", mount.MountPoint, mount.Device, mount.Error), attrs)
+	case mount.Status == "error":
+		c.events.Add("mount_error", SeverityWarning,
+			fmt.Sprintf("Network mount %s (%s) failed: %s", mount.MountPoint, mount.Device, mount.Error), attrs)
+	case known:
+		c.events.Add("mount_recovered", SeverityInfo,
+			fmt.Sprintf("Network mount %s (%s) is responding again", mount.MountPoint, mount.Device), attrs)
+	}
+}
+
+func writeTestFile(dir string) error {
+	f, err := os.CreateTemp(dir, ".sentinel-write-test-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("sentinel\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// mountUsage is disk.Usage with a timeout.
+func mountUsage(ctx context.Context, path string, timeout time.Duration) (*disk.UsageStat, error) {
+	return runBlocking(ctx, "statfs "+path, timeout, func() (*disk.UsageStat, error) {
+		return disk.Usage(path)
+	})
+}
+
+// blockedProbes holds the keys of calls that have not returned yet. A statfs on a dead NFS server sleeps uninterruptibly in the
+// kernel and cannot be cancelled, so the goroutine is abandoned; while it
+// is still stuck, later calls with the same key fail immediately instead of
+// piling up more blocked goroutines.
+var (
+	blockedProbesMu sync.Mutex
+	blockedProbes   = make(map[string]bool)
+)
+
+func runBlocking[T any](ctx context.Context, key string, timeout time.Duration, fn func() (T, error)) (T, error) {
+	var zero T
+	blockedProbesMu.Lock()
+	if blockedProbes[key] {
+		blockedProbesMu.Unlock()
+		return zero, fmt.Errorf("%w (previous call still blocked)", errMountTimeout)
+	}
+	blockedProbes[key] = true
+	blockedProbesMu.Unlock()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		blockedProbesMu.Lock()
+		delete(blockedProbes, key)
+		blockedProbesMu.Unlock()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-time.After(timeout):
+		return zero, fmt.Errorf("%w after %s", errMountTimeout, timeout)
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
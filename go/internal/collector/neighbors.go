@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type NeighborInfo struct {
+	Entries   []Neighbor `json:"entries"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+type Neighbor struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac,omitempty"`
+	Interface string `json:"interface"`
+	State     string `json:"state,omitempty"`
+}
+
+type NeighborCollector struct {
+	maxEntries int
+	schedule   schedule
+}
+
+func NewNeighborCollector(cfg config.NeighborConfig) *NeighborCollector {
+	return &NeighborCollector{
+		maxEntries: cfg.MaxEntries,
+		schedule:   newSchedule(time.Duration(cfg.Interval) * time.Second),
+	}
+}
+
+func (c *NeighborCollector) Collect(ctx context.Context) (*NeighborInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	entries, err := collectNeighbors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NeighborInfo{Entries: entries}
+	if c.maxEntries > 0 && len(entries) > c.maxEntries {
+		info.Entries = entries[:c.maxEntries]
+		info.Truncated = true
+	}
+	return info, nil
+}
@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// collectNeighbors prefers `ip neigh`, which covers both ARP and IPv6 ND,
+// and falls back to /proc/net/arp on minimal systems without iproute2.
+func collectNeighbors(ctx context.Context) ([]Neighbor, error) {
+	if _, err := exec.LookPath("ip"); err == nil {
+		out, err := runCommand(ctx, 0, "ip", "neigh", "show")
+		if err == nil {
+			return parseIPNeigh(out), nil
+		}
+	}
+
+	data, err := os.ReadFile("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	return parseProcARP(data), nil
+}
+
+// parseIPNeigh parses lines such as
+//
+//	192.168.1.1 dev eth0 lladdr aa:bb:cc:dd:ee:ff REACHABLE
+//	fe80::1 dev eth0 lladdr aa:bb:cc:dd:ee:ff router STALE
+func parseIPNeigh(out []byte) []Neighbor {
+	entries := make([]Neighbor, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		n := Neighbor{IP: fields[0], State: fields[len(fields)-1]}
+		for i := 1; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "dev":
+				n.Interface = fields[i+1]
+			case "lladdr":
+				n.MAC = fields[i+1]
+			}
+		}
+		entries = append(entries, n)
+	}
+	return entries
+}
+
+func parseProcARP(data []byte) []Neighbor {
+	entries := make([]Neighbor, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		state := "REACHABLE"
+		if fields[2] == "0x0" {
+			state = "INCOMPLETE"
+		}
+		entries = append(entries, Neighbor{
+			IP:        fields[0],
+			MAC:       fields[3],
+			Interface: fields[5],
+			State:     state,
+		})
+	}
+	return entries
+}
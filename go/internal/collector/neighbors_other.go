@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectNeighbors(ctx context.Context) ([]Neighbor, error) {
+	return nil, fmt.Errorf("neighbor table collection is not supported on this platform")
+}
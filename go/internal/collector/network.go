@@ -1,6 +1,9 @@
 package collector
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net"
 	"strings"
 )
@@ -8,7 +11,8 @@ import (
 type NetworkInfo struct {
 	PrimaryIP   string              `json:"primary_ip"`
 	PrimaryMAC  string              `json:"primary_mac"`
-	Interfaces  []InterfaceInfo     `json:"interfaces"`
+	Interfaces  []InterfaceInfo     `json:"interfaces,omitempty"`
+	Routes      *RouteInfo          `json:"routes,omitempty"`
 }
 
 type InterfaceInfo struct {
@@ -19,13 +23,16 @@ type InterfaceInfo struct {
 	IsLoopback bool    `json:"is_loopback"`
 }
 
-type NetworkCollector struct{}
+type NetworkCollector struct {
+	events      *EventBuffer
+	lastGateway string
+}
 
-func NewNetworkCollector() *NetworkCollector {
-	return &NetworkCollector{}
+func NewNetworkCollector(events *EventBuffer) *NetworkCollector {
+	return &NetworkCollector{events: events}
 }
 
-func (c *NetworkCollector) Collect() (*NetworkInfo, error) {
+func (c *NetworkCollector) Collect(ctx context.Context) (*NetworkInfo, error) {
 	info := &NetworkInfo{
 		Interfaces: make([]InterfaceInfo, 0),
 	}
@@ -78,9 +85,35 @@ func (c *NetworkCollector) Collect() (*NetworkInfo, error) {
 		}
 	}
 
+	routes, err := collectRoutes()
+	if err != nil {
+		log.Printf("Error collecting routing table: %v", err)
+	} else if routes != nil {
+		info.Routes = routes
+		c.checkGateway(routes)
+	}
+
 	return info, nil
 }
 
+func (c *NetworkCollector) checkGateway(routes *RouteInfo) {
+	current := routes.DefaultGateway
+	if c.lastGateway != "" && current != c.lastGateway {
+		severity := SeverityWarning
+		message := fmt.Sprintf("Default gateway changed from %s to %s", c.lastGateway, current)
+		if current == "" {
+			severity = SeverityCritical
+			message = fmt.Sprintf("Default gateway %s removed", c.lastGateway)
+		}
+		c.events.Add("default_gateway_changed", severity, message, map[string]string{
+			"previous":  c.lastGateway,
+			"current":   current,
+			"interface": routes.DefaultInterface,
+		})
+	}
+	c.lastGateway = current
+}
+
 func extractIP(addr string) string {
 	if idx := strings.Index(addr, "/"); idx != -1 {
 		return addr[:idx]
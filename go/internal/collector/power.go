@@ -0,0 +1,260 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type PowerInfo struct {
+	Batteries []BatteryInfo `json:"batteries,omitempty"`
+	UPS       []UPSStatus   `json:"ups,omitempty"`
+	Energy    *EnergyInfo   `json:"energy,omitempty"`
+}
+
+// UPSStatus is the last polled state of a UPS. Status is "online",
+// "on_battery", "low_battery" or "unknown" when the UPS daemon can't be
+// reached.
+type UPSStatus struct {
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	Status         string  `json:"status"`
+	RawStatus      string  `json:"rawStatus,omitempty"`
+	ChargePercent  float64 `json:"chargePercent"`
+	RuntimeSeconds float64 `json:"runtimeSeconds,omitempty"`
+	LoadPercent    float64 `json:"loadPercent,omitempty"`
+	InputVoltage   float64 `json:"inputVoltage,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+type PowerCollector struct {
+	battery bool
+	ups     []config.UPSConfig
+	poll    time.Duration
+	events  *EventBuffer
+	energy  *energyTracker
+
+	urgent chan struct{}
+	// known is the last status other than "unknown" per UPS, so a daemon
+	// that stops answering doesn't produce on_battery/on_line events.
+	known []string
+
+	mu     sync.Mutex
+	status []UPSStatus
+}
+
+func NewPowerCollector(cfg config.PowerConfig, events *EventBuffer) *PowerCollector {
+	c := &PowerCollector{
+		battery: cfg.Battery,
+		ups:     cfg.UPS,
+		poll:    time.Duration(cfg.UPSPollInterval) * time.Second,
+		events:  events,
+		urgent:  make(chan struct{}, 1),
+		known:   make([]string, len(cfg.UPS)),
+	}
+	if cfg.Energy {
+		c.energy = newEnergyTracker()
+	}
+	return c
+}
+
+// Start polls the configured UPSes in the background, more often than the
+// heartbeat interval, so a switch to battery power is noticed quickly.
+// Polling stops when ctx is canceled.
+func (c *PowerCollector) Start(ctx context.Context) {
+	if len(c.ups) == 0 {
+		return
+	}
+	c.pollUPS(ctx)
+	go func() {
+		ticker := time.NewTicker(c.poll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.pollUPS(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Rebaseline discards the previous energy reading, e.g. after a resume from
+// suspend, so the next call establishes a new baseline.
+func (c *PowerCollector) Rebaseline() {
+	if c.energy != nil {
+		c.energy.lastAt = time.Time{}
+	}
+}
+
+// Urgent receives when a UPS switches to battery power or reports a low
+// battery, so the caller can send a heartbeat without waiting for the next
+// interval.
+func (c *PowerCollector) Urgent() <-chan struct{} {
+	return c.urgent
+}
+
+func (c *PowerCollector) Collect(ctx context.Context) (*PowerInfo, error) {
+	info := &PowerInfo{}
+	if c.battery {
+		batteries, err := readBatteries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		info.Batteries = batteries
+	}
+	if c.energy != nil {
+		info.Energy = c.energy.collect(time.Now())
+	}
+	c.mu.Lock()
+	info.UPS = append([]UPSStatus(nil), c.status...)
+	c.mu.Unlock()
+	return info, nil
+}
+
+func (c *PowerCollector) pollUPS(ctx context.Context) {
+	status := make([]UPSStatus, len(c.ups))
+	var wg sync.WaitGroup
+	for i, ups := range c.ups {
+		wg.Add(1)
+		go func(i int, ups config.UPSConfig) {
+			defer wg.Done()
+			status[i] = queryUPS(ctx, ups)
+		}(i, ups)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	c.status = status
+	c.mu.Unlock()
+
+	urgent := false
+	for i, s := range status {
+		if s.Status == "unknown" || s.Status == c.known[i] {
+			continue
+		}
+		first := c.known[i] == ""
+		c.known[i] = s.Status
+		if first && s.Status == "online" {
+			continue
+		}
+		attrs := map[string]string{
+			"ups":    s.Name,
+			"status": s.RawStatus,
+			"charge": fmt.Sprintf("%.0f", s.ChargePercent),
+		}
+		switch s.Status {
+		case "on_battery":
+			c.events.Add("ups_on_battery", SeverityCritical,
+				fmt.Sprintf("UPS %s is on battery power (%.0f%% charge, %.0f minutes remaining)", s.Name, s.ChargePercent, s.RuntimeSeconds/60), attrs)
+			urgent = true
+		case "low_battery":
+			c.events.Add("ups_low_battery", SeverityCritical,
+				fmt.Sprintf("UPS %s battery is low (%.0f%% charge, %.0f minutes remaining)", s.Name, s.ChargePercent, s.RuntimeSeconds/60), attrs)
+			urgent = true
+		case "online":
+			c.events.Add("ups_on_line", SeverityInfo,
+				fmt.Sprintf("UPS %s is back on line power", s.Name), attrs)
+		}
+	}
+	if urgent {
+		select {
+		case c.urgent <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func queryUPS(ctx context.Context, ups config.UPSConfig) UPSStatus {
+	status := UPSStatus{Name: ups.Name, Type: ups.Type, Status: "unknown"}
+	if status.Name == "" {
+		status.Name = ups.Target
+	}
+	if status.Name == "" {
+		status.Name = ups.Type
+	}
+
+	var values map[string]string
+	var err error
+	switch ups.Type {
+	case "nut":
+		values, err = readUPSValues(ctx, 5*time.Second, "upsc", ups.Target)
+		if err == nil {
+			status.RawStatus = values["ups.status"]
+			status.ChargePercent = upsFloat(values["battery.charge"])
+			status.RuntimeSeconds = upsFloat(values["battery.runtime"])
+			status.LoadPercent = upsFloat(values["ups.load"])
+			status.InputVoltage = upsFloat(values["input.voltage"])
+			flags := strings.Fields(status.RawStatus)
+			switch {
+			case slices.Contains(flags, "LB"):
+				status.Status = "low_battery"
+			case slices.Contains(flags, "OB"):
+				status.Status = "on_battery"
+			case slices.Contains(flags, "OL"):
+				status.Status = "online"
+			}
+		}
+	case "apcupsd":
+		args := []string{"status"}
+		if ups.Target != "" {
+			args = append(args, ups.Target)
+		}
+		values, err = readUPSValues(ctx, 5*time.Second, "apcaccess", args...)
+		if err == nil {
+			status.RawStatus = values["STATUS"]
+			status.ChargePercent = upsFloat(values["BCHARGE"])
+			status.RuntimeSeconds = upsFloat(values["TIMELEFT"]) * 60
+			status.LoadPercent = upsFloat(values["LOADPCT"])
+			status.InputVoltage = upsFloat(values["LINEV"])
+			flags := strings.Fields(status.RawStatus)
+			switch {
+			case slices.Contains(flags, "LOWBATT"):
+				status.Status = "low_battery"
+			case slices.Contains(flags, "ONBATT"):
+				status.Status = "on_battery"
+			case slices.Contains(flags, "ONLINE"):
+				status.Status = "online"
+			}
+		}
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// readUPSValues runs upsc or apcaccess and splits their "key: value" lines.
+func readUPSValues(ctx context.Context, timeout time.Duration, name string, args ...string) (map[string]string, error) {
+	out, err := runCommand(ctx, timeout, name, args...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), ":"); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values, nil
+}
+
+// upsFloat parses the leading number of values such as "100.0 Percent".
+func upsFloat(s string) float64 {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(fields[0], 64)
+	return v
+}
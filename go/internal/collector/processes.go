@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessEntry is one running process. CPUPercent is relative to a single
+// core, as in top; command lines are left out as they may hold secrets.
+type ProcessEntry struct {
+	PID         int32   `json:"pid"`
+	PPID        int32   `json:"ppid"`
+	Name        string  `json:"name"`
+	User        string  `json:"user,omitempty"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryBytes uint64  `json:"memoryBytes"`
+}
+
+// ProcessListCollector lists every process, busiest first. It is meant for
+// short periods of high detail rather than every heartbeat.
+type ProcessListCollector struct {
+	previousTime time.Time
+	previousCPU  map[int32]float64
+	usernames    usernameCache
+}
+
+func NewProcessListCollector() *ProcessListCollector {
+	return &ProcessListCollector{usernames: make(usernameCache)}
+}
+
+// Collect measures CPU usage since the previous call. Processes without a
+// recent previous sample report their lifetime average instead.
+func (c *ProcessListCollector) Collect(ctx context.Context) ([]ProcessEntry, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(c.previousTime).Seconds()
+	prevCPU := c.previousCPU
+	if elapsed > 10*60 {
+		prevCPU = nil
+	}
+	cpu := make(map[int32]float64, len(procs))
+
+	entries := make([]ProcessEntry, 0, len(procs))
+	for _, p := range procs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		entry := ProcessEntry{PID: p.Pid, Name: name}
+		entry.PPID, _ = p.PpidWithContext(ctx)
+		if uids, err := p.UidsWithContext(ctx); err == nil && len(uids) > 0 {
+			entry.User = c.usernames.lookup(uids[0])
+		} else {
+			entry.User, _ = p.UsernameWithContext(ctx)
+		}
+		if mem, err := p.MemoryInfoWithContext(ctx); err == nil {
+			entry.MemoryBytes = mem.RSS
+		}
+		if times, err := p.TimesWithContext(ctx); err == nil {
+			total := times.User + times.System
+			cpu[p.Pid] = total
+			if prev, ok := prevCPU[p.Pid]; ok && elapsed > 0 {
+				entry.CPUPercent = max(total-prev, 0) / elapsed * 100
+			} else {
+				entry.CPUPercent, _ = p.CPUPercentWithContext(ctx)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	c.previousTime, c.previousCPU = now, cpu
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CPUPercent != entries[j].CPUPercent {
+			return entries[i].CPUPercent > entries[j].CPUPercent
+		}
+		return entries[i].MemoryBytes > entries[j].MemoryBytes
+	})
+	return entries, nil
+}
@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	netlinkInetDiag   = 4
+	sockDiagByFamily  = 20
+	inetDiagInfo      = 2
+	inetDiagMsgLen    = 72
+	inetDiagReqV2Len  = 56
+	tcpInfoBytesAcked = 120
+	tcpInfoBytesRecvd = 128
+)
+
+func readTCPSockets() ([]tcpSocket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, netlinkInetDiag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sock_diag socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to bind sock_diag socket: %w", err)
+	}
+
+	sockets := make([]tcpSocket, 0)
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		if err := dumpTCPSockets(fd, family, &sockets); err != nil {
+			return nil, err
+		}
+	}
+	return sockets, nil
+}
+
+func dumpTCPSockets(fd int, family uint8, sockets *[]tcpSocket) error {
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqV2Len)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)
+	binary.NativeEndian.PutUint32(body[4:8], 0xffffffff)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return fmt.Errorf("failed to send sock_diag request: %w", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("failed to read sock_diag response: %w", err)
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("failed to parse sock_diag response: %w", err)
+		}
+
+		for _, msg := range msgs {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					errno := -int32(binary.NativeEndian.Uint32(msg.Data[:4]))
+					if errno != 0 {
+						return fmt.Errorf("sock_diag error: %w", syscall.Errno(errno))
+					}
+				}
+				return nil
+			}
+
+			if s, ok := parseInetDiagMsg(msg.Data); ok {
+				*sockets = append(*sockets, s)
+			}
+		}
+	}
+}
+
+// parseInetDiagMsg decodes an inet_diag_msg followed by its rtattr list.
+// Ports and addresses in inet_diag_sockid are in network byte order.
+func parseInetDiagMsg(data []byte) (tcpSocket, bool) {
+	if len(data) < inetDiagMsgLen {
+		return tcpSocket{}, false
+	}
+	s := tcpSocket{
+		inode:      binary.NativeEndian.Uint32(data[68:72]),
+		state:      data[1],
+		localPort:  binary.BigEndian.Uint16(data[4:6]),
+		remotePort: binary.BigEndian.Uint16(data[6:8]),
+	}
+	if s.inode == 0 {
+		return s, false
+	}
+	if data[0] == syscall.AF_INET {
+		s.localIP = net.IP(append([]byte(nil), data[8:12]...))
+		s.remoteIP = net.IP(append([]byte(nil), data[24:28]...))
+	} else {
+		s.localIP = net.IP(append([]byte(nil), data[8:24]...))
+		s.remoteIP = net.IP(append([]byte(nil), data[24:40]...))
+	}
+
+	attrs := data[inetDiagMsgLen:]
+	for len(attrs) >= syscall.SizeofRtAttr {
+		attrLen := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		attrType := binary.NativeEndian.Uint16(attrs[2:4])
+		if attrLen < syscall.SizeofRtAttr || attrLen > len(attrs) {
+			break
+		}
+
+		payload := attrs[syscall.SizeofRtAttr:attrLen]
+		if attrType == inetDiagInfo && len(payload) >= tcpInfoBytesRecvd+8 {
+			s.sent = binary.NativeEndian.Uint64(payload[tcpInfoBytesAcked:])
+			s.received = binary.NativeEndian.Uint64(payload[tcpInfoBytesRecvd:])
+		}
+
+		aligned := (attrLen + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+	return s, true
+}
+
+// socketOwners maps socket inodes to the first process holding them open.
+func socketOwners() (map[uint32]socketOwner, error) {
+	procs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[uint32]socketOwner)
+	for _, dir := range procs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		var owner *socketOwner
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(link[8:len(link)-1], 10, 32)
+			if err != nil {
+				continue
+			}
+			if _, seen := owners[uint32(inode)]; seen {
+				continue
+			}
+
+			if owner == nil {
+				owner = &socketOwner{PID: pid, Name: readProcComm(pid), ContainerID: containerIDForPID(pid)}
+			}
+			owners[uint32(inode)] = *owner
+		}
+	}
+	return owners, nil
+}
+
+func readProcComm(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// containerIDForPID recognises the cgroup layouts used by Docker, containerd
+// and Podman, e.g. "/docker/<id>" or "docker-<id>.scope".
+func containerIDForPID(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		for _, part := range strings.Split(line, "/") {
+			part = strings.TrimSuffix(part, ".scope")
+			if i := strings.LastIndex(part, "-"); i != -1 {
+				part = part[i+1:]
+			}
+			if len(part) == 64 && isHex(part) {
+				return part[:12]
+			}
+		}
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
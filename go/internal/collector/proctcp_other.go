@@ -0,0 +1,13 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func readTCPSockets() ([]tcpSocket, error) {
+	return nil, fmt.Errorf("per-process network accounting is only supported on Linux")
+}
+
+func socketOwners() (map[uint32]socketOwner, error) {
+	return nil, fmt.Errorf("per-process network accounting is only supported on Linux")
+}
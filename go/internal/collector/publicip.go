@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type PublicIPInfo struct {
+	IP         string `json:"ip"`
+	PreviousIP string `json:"previousIp,omitempty"`
+	Changed    bool   `json:"changed"`
+	Country    string `json:"country,omitempty"`
+	Region     string `json:"region,omitempty"`
+	City       string `json:"city,omitempty"`
+	Org        string `json:"org,omitempty"`
+}
+
+type PublicIPCollector struct {
+	endpoint   string
+	schedule   schedule
+	events     *EventBuffer
+	httpClient *http.Client
+	lastIP     string
+}
+
+func NewPublicIPCollector(cfg config.PublicIPConfig, events *EventBuffer) *PublicIPCollector {
+	return &PublicIPCollector{
+		endpoint: cfg.Endpoint,
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (c *PublicIPCollector) Collect(ctx context.Context) (*PublicIPInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query public IP endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public IP response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("public IP endpoint returned status %d", resp.StatusCode)
+	}
+
+	info, err := parsePublicIP(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.lastIP != "" && c.lastIP != info.IP {
+		info.Changed = true
+		info.PreviousIP = c.lastIP
+		c.events.Add("public_ip_changed", SeverityInfo,
+			fmt.Sprintf("Public IP changed from %s to %s", c.lastIP, info.IP),
+			map[string]string{"previous": c.lastIP, "current": info.IP})
+	}
+	c.lastIP = info.IP
+
+	return info, nil
+}
+
+// parsePublicIP accepts either a plain-text address (ifconfig.me, icanhazip)
+// or an ipinfo.io-style JSON document with optional geo fields.
+func parsePublicIP(body []byte) (*PublicIPInfo, error) {
+	text := strings.TrimSpace(string(body))
+
+	info := &PublicIPInfo{}
+	if strings.HasPrefix(text, "{") {
+		if err := json.Unmarshal([]byte(text), info); err != nil {
+			return nil, fmt.Errorf("failed to parse public IP response: %w", err)
+		}
+		info.Changed = false
+		info.PreviousIP = ""
+	} else {
+		info.IP = text
+	}
+
+	if net.ParseIP(info.IP) == nil {
+		return nil, fmt.Errorf("public IP endpoint returned invalid address %.64q", info.IP)
+	}
+	return info, nil
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type QuotaInfo struct {
+	Filesystems []QuotaFilesystem `json:"filesystems"`
+	NearLimit   []QuotaEntry      `json:"nearLimit"`
+}
+
+type QuotaFilesystem struct {
+	Device  string `json:"device"`
+	Type    string `json:"type"`
+	Entries int    `json:"entries"`
+}
+
+// QuotaEntry is a user or group at or above the warning threshold. Block
+// values are in bytes; PercentUsed is of the lowest configured limit.
+type QuotaEntry struct {
+	Device      string  `json:"device"`
+	Type        string  `json:"type"`
+	Name        string  `json:"name"`
+	BytesUsed   uint64  `json:"bytesUsed"`
+	BytesSoft   uint64  `json:"bytesSoft,omitempty"`
+	BytesHard   uint64  `json:"bytesHard,omitempty"`
+	FilesUsed   uint64  `json:"filesUsed"`
+	FilesSoft   uint64  `json:"filesSoft,omitempty"`
+	FilesHard   uint64  `json:"filesHard,omitempty"`
+	PercentUsed float64 `json:"percentUsed"`
+	InGrace     bool    `json:"inGrace,omitempty"`
+}
+
+type QuotaCollector struct {
+	warnPercent float64
+	schedule    schedule
+}
+
+func NewQuotaCollector(cfg config.QuotasConfig) *QuotaCollector {
+	return &QuotaCollector{
+		warnPercent: float64(cfg.WarnPercent),
+		schedule:    newSchedule(time.Duration(cfg.Interval) * time.Second),
+	}
+}
+
+func (c *QuotaCollector) Collect(ctx context.Context) (*QuotaInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	return collectQuotas(ctx, c.warnPercent)
+}
+
+// quotaPercent is the highest usage relative to any configured limit.
+func quotaPercent(used, soft, hard uint64) float64 {
+	percent := 0.0
+	for _, limit := range []uint64{soft, hard} {
+		if limit > 0 {
+			percent = max(percent, float64(used)/float64(limit)*100)
+		}
+	}
+	return percent
+}
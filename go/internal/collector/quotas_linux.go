@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// collectQuotas parses repquota for user and group quotas on all
+// filesystems with quotas enabled. -p prints grace times as seconds so
+// every row has the same columns.
+func collectQuotas(ctx context.Context, warnPercent float64) (*QuotaInfo, error) {
+	info := &QuotaInfo{
+		Filesystems: make([]QuotaFilesystem, 0),
+		NearLimit:   make([]QuotaEntry, 0),
+	}
+	var firstErr error
+	for _, kind := range []struct{ flag, name string }{{"-u", "user"}, {"-g", "group"}} {
+		out, err := runCommand(ctx, 0, "repquota", "-a", kind.flag, "-p")
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		parseRepquota(string(out), kind.name, warnPercent, info)
+	}
+	if len(info.Filesystems) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return info, nil
+}
+
+func parseRepquota(out, kind string, warnPercent float64, info *QuotaInfo) {
+	var fs *QuotaFilesystem
+	for _, line := range strings.Split(out, "\n") {
+		if rest, ok := strings.CutPrefix(line, "*** Report for "); ok {
+			device := rest[strings.LastIndex(rest, " ")+1:]
+			info.Filesystems = append(info.Filesystems, QuotaFilesystem{Device: device, Type: kind})
+			fs = &info.Filesystems[len(info.Filesystems)-1]
+			continue
+		}
+		fields := strings.Fields(line)
+		// name, flags, blocks used/soft/hard/grace, files used/soft/hard/grace
+		if fs == nil || len(fields) != 10 || len(fields[1]) != 2 || strings.Trim(fields[1], "+-") != "" {
+			continue
+		}
+		values := make([]uint64, 0, 8)
+		for _, f := range fields[2:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				break
+			}
+			values = append(values, v)
+		}
+		if len(values) != 8 {
+			continue
+		}
+		fs.Entries++
+
+		entry := QuotaEntry{
+			Device:    fs.Device,
+			Type:      kind,
+			Name:      fields[0],
+			BytesUsed: values[0] * 1024,
+			BytesSoft: values[1] * 1024,
+			BytesHard: values[2] * 1024,
+			FilesUsed: values[4],
+			FilesSoft: values[5],
+			FilesHard: values[6],
+			InGrace:   values[3] > 0 || values[7] > 0,
+		}
+		entry.PercentUsed = max(
+			quotaPercent(values[0], values[1], values[2]),
+			quotaPercent(values[4], values[5], values[6]))
+		if entry.PercentUsed >= warnPercent || fields[1] != "--" {
+			info.NearLimit = append(info.NearLimit, entry)
+		}
+	}
+}
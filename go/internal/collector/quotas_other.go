@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectQuotas(ctx context.Context, warnPercent float64) (*QuotaInfo, error) {
+	return nil, fmt.Errorf("quota monitoring is not supported on this platform")
+}
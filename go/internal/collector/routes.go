@@ -0,0 +1,18 @@
+package collector
+
+type RouteInfo struct {
+	DefaultGateway   string  `json:"defaultGateway,omitempty"`
+	DefaultInterface string  `json:"defaultInterface,omitempty"`
+	RouteCount       int     `json:"routeCount"`
+	StaticRoutes     []Route `json:"staticRoutes,omitempty"`
+}
+
+type Route struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Interface   string `json:"interface"`
+	Metric      int    `json:"metric"`
+}
+
+// maxStaticRoutes keeps large BGP-fed tables from bloating the heartbeat.
+const maxStaticRoutes = 20
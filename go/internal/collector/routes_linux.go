@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	rtfUp      = 0x1
+	rtfGateway = 0x2
+)
+
+func collectRoutes() (*RouteInfo, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := &RouteInfo{StaticRoutes: make([]Route, 0)}
+	bestMetric := -1
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfUp == 0 {
+			continue
+		}
+		info.RouteCount++
+
+		dest, err1 := parseProcIPv4(fields[1])
+		gateway, err2 := parseProcIPv4(fields[2])
+		mask, err3 := parseProcIPv4(fields[7])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		metric, _ := strconv.Atoi(fields[6])
+
+		ones, _ := net.IPMask(mask.To4()).Size()
+		if ones == 0 && dest.Equal(net.IPv4zero) {
+			if bestMetric == -1 || metric < bestMetric {
+				bestMetric = metric
+				info.DefaultGateway = gateway.String()
+				info.DefaultInterface = fields[0]
+			}
+			continue
+		}
+
+		if flags&rtfGateway != 0 && len(info.StaticRoutes) < maxStaticRoutes {
+			info.StaticRoutes = append(info.StaticRoutes, Route{
+				Destination: fmt.Sprintf("%s/%d", dest, ones),
+				Gateway:     gateway.String(),
+				Interface:   fields[0],
+				Metric:      metric,
+			})
+		}
+	}
+
+	return info, scanner.Err()
+}
+
+// parseProcIPv4 decodes the little-endian hex addresses used in /proc/net/route.
+func parseProcIPv4(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+	return ip, nil
+}
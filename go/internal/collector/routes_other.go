@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+func collectRoutes() (*RouteInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/docker"
+)
+
+var errNotSupported = errors.New("not supported by this container runtime")
+
+// containerRuntime is the part of a container engine the Docker collector
+// uses. Docker and Podman share the Engine API; containerd and CRI-O are
+// reached through crictl.
+type containerRuntime interface {
+	containers(ctx context.Context) ([]runtimeContainer, error)
+	// restartCounts sets Restarts on the running or restarting containers.
+	restartCounts(ctx context.Context, containers []runtimeContainer)
+	// stats fills the usage fields of the running containers in out.
+	stats(ctx context.Context, out []*ContainerInfo)
+	// diskUsage adds images, volumes and their sizes to info, or returns
+	// errNotSupported.
+	diskUsage(ctx context.Context, info *DockerInfo) error
+}
+
+type runtimeContainer struct {
+	ID      string
+	Name    string
+	Image   string
+	State   string
+	Status  string
+	Created time.Time
+	Labels  map[string]string
+	// Restarts is the restart policy's count on Docker and Podman and the
+	// kubelet's attempt number on CRI.
+	Restarts int
+	// IP is the address on the container's first network; empty for host
+	// networking or when the runtime does not report it.
+	IP string
+}
+
+// newContainerRuntime returns the client for runtime, using socket or the
+// runtime's default socket.
+func newContainerRuntime(runtime, socket string) (containerRuntime, error) {
+	switch runtime {
+	case "docker", "":
+		if socket == "" {
+			socket = "/var/run/docker.sock"
+		}
+		return &engineRuntime{client: docker.New(socket)}, nil
+	case "podman":
+		if socket == "" {
+			socket = podmanSocket()
+		}
+		return &engineRuntime{client: docker.New(socket)}, nil
+	case "cri":
+		if socket == "" {
+			socket = "/run/containerd/containerd.sock"
+		}
+		return &criRuntime{endpoint: "unix://" + socket}, nil
+	}
+	return nil, fmt.Errorf("unknown container runtime %q", runtime)
+}
+
+// podmanSocket prefers the system service and falls back to the rootless
+// socket of the user the agent runs as.
+func podmanSocket() string {
+	const system = "/run/podman/podman.sock"
+	if _, err := os.Stat(system); err == nil {
+		return system
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	rootless := filepath.Join(dir, "podman", "podman.sock")
+	if _, err := os.Stat(rootless); err == nil {
+		return rootless
+	}
+	return system
+}
+
+// engineRuntime speaks the Docker Engine API, which Podman also serves.
+type engineRuntime struct {
+	client *docker.Client
+}
+
+func (r *engineRuntime) containers(ctx context.Context) ([]runtimeContainer, error) {
+	list, err := r.client.Containers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	containers := make([]runtimeContainer, len(list))
+	for i := range list {
+		ctr := &list[i]
+		containers[i] = runtimeContainer{
+			ID:      ctr.ID,
+			Name:    ctr.Name(),
+			Image:   ctr.Image,
+			State:   ctr.State,
+			Status:  ctr.Status,
+			Created: time.Unix(ctr.Created, 0).UTC(),
+			Labels:  ctr.Labels,
+			IP:      ctr.IPAddress(),
+		}
+	}
+	return containers, nil
+}
+
+func (r *engineRuntime) restartCounts(ctx context.Context, containers []runtimeContainer) {
+	var wg sync.WaitGroup
+	for i := range containers {
+		ctr := &containers[i]
+		if ctr.State != "running" && ctr.State != "restarting" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if details, err := r.client.Inspect(ctx, ctr.ID); err == nil {
+				ctr.Restarts = details.RestartCount
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *engineRuntime) stats(ctx context.Context, out []*ContainerInfo) {
+	var wg sync.WaitGroup
+	for _, ctr := range out {
+		wg.Add(1)
+		go func(ctr *ContainerInfo) {
+			defer wg.Done()
+			if stats, err := r.client.Stats(ctx, ctr.fullID); err == nil {
+				applyContainerStats(ctr, stats)
+			}
+		}(ctr)
+	}
+	wg.Wait()
+}
+
+func (r *engineRuntime) diskUsage(ctx context.Context, info *DockerInfo) error {
+	usage, err := r.client.DiskUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	total := &DockerDiskUsage{Images: usage.LayersSize}
+	for _, img := range usage.Images {
+		created := time.Unix(img.Created, 0).UTC()
+		image := ImageInfo{
+			ID:         shortImageID(img.ID),
+			Size:       img.Size,
+			SharedSize: max(img.SharedSize, 0),
+			CreatedAt:  &created,
+			Containers: max(img.Containers, 0),
+			Dangling:   img.Dangling(),
+		}
+		if !image.Dangling {
+			image.Tags = img.RepoTags
+		} else {
+			total.DanglingImages++
+			total.DanglingSize += img.Size - image.SharedSize
+		}
+		info.Images = append(info.Images, image)
+	}
+	for _, vol := range usage.Volumes {
+		volume := VolumeInfo{Name: vol.Name, Driver: vol.Driver, Size: -1}
+		if vol.UsageData != nil {
+			volume.Size = vol.UsageData.Size
+			volume.Containers = max(vol.UsageData.RefCount, 0)
+			if volume.Size > 0 {
+				total.Volumes += volume.Size
+			}
+			if vol.UsageData.RefCount == 0 {
+				total.UnusedVolumes++
+			}
+		}
+		info.Volumes = append(info.Volumes, volume)
+	}
+	for _, cache := range usage.BuildCache {
+		total.BuildCache += cache.Size
+	}
+	info.DiskUsage = total
+	return nil
+}
+
+func applyContainerStats(out *ContainerInfo, stats *docker.Stats) {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := float64(max(stats.CPUStats.OnlineCPUs, 1))
+		out.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Page cache is reclaimable and not counted, matching `docker stats`:
+	// inactive_file on cgroup v2, cache on v1.
+	usage := stats.MemoryStats.Usage
+	if cache, ok := stats.MemoryStats.Stats["inactive_file"]; ok && cache < usage {
+		usage -= cache
+	} else if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < usage {
+		usage -= cache
+	}
+	out.MemoryUsage = usage
+	out.MemoryLimit = stats.MemoryStats.Limit
+	if stats.MemoryStats.Limit > 0 {
+		out.MemoryPercent = float64(usage) / float64(stats.MemoryStats.Limit) * 100
+	}
+
+	for _, network := range stats.Networks {
+		out.NetRxBytes += network.RxBytes
+		out.NetTxBytes += network.TxBytes
+	}
+}
+
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	return id[:min(len(id), 12)]
+}
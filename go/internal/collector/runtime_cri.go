@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// criRuntime reads containerd or CRI-O through crictl, which keeps the
+// agent free of the CRI gRPC stubs.
+type criRuntime struct {
+	endpoint string
+}
+
+// criUint decodes protobuf JSON integers, which 64-bit values encode as
+// strings.
+type criUint uint64
+
+func (u *criUint) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*u = criUint(v)
+	return nil
+}
+
+type criContainer struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name    string `json:"name"`
+		Attempt int    `json:"attempt"`
+	} `json:"metadata"`
+	Image struct {
+		Image string `json:"image"`
+	} `json:"image"`
+	State     string            `json:"state"`
+	CreatedAt criUint           `json:"createdAt"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type criStats struct {
+	Attributes struct {
+		ID string `json:"id"`
+	} `json:"attributes"`
+	CPU *struct {
+		UsageNanoCores *struct {
+			Value criUint `json:"value"`
+		} `json:"usageNanoCores"`
+	} `json:"cpu"`
+	Memory *struct {
+		WorkingSetBytes *struct {
+			Value criUint `json:"value"`
+		} `json:"workingSetBytes"`
+	} `json:"memory"`
+}
+
+type criImage struct {
+	ID       string   `json:"id"`
+	RepoTags []string `json:"repoTags"`
+	Size     criUint  `json:"size"`
+}
+
+func (r *criRuntime) crictl(ctx context.Context, out interface{}, args ...string) error {
+	timeout := dockerTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	data, err := runCommand(ctx, timeout, "crictl", append([]string{"--runtime-endpoint", r.endpoint}, args...)...)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (r *criRuntime) containers(ctx context.Context) ([]runtimeContainer, error) {
+	var list struct {
+		Containers []criContainer `json:"containers"`
+	}
+	if err := r.crictl(ctx, &list, "ps", "--all", "--output", "json"); err != nil {
+		return nil, err
+	}
+	containers := make([]runtimeContainer, len(list.Containers))
+	for i, ctr := range list.Containers {
+		// CONTAINER_RUNNING -> running, matching the Docker states.
+		state := strings.ToLower(strings.TrimPrefix(ctr.State, "CONTAINER_"))
+		name := ctr.Metadata.Name
+		if pod := ctr.Labels["io.kubernetes.pod.name"]; pod != "" {
+			name = pod + "/" + name
+		}
+		containers[i] = runtimeContainer{
+			ID:       ctr.ID,
+			Name:     name,
+			Image:    ctr.Image.Image,
+			State:    state,
+			Status:   state,
+			Created:  time.Unix(0, int64(ctr.CreatedAt)).UTC(),
+			Labels:   ctr.Labels,
+			Restarts: ctr.Metadata.Attempt,
+		}
+	}
+	return containers, nil
+}
+
+// restartCounts is a no-op: crictl ps already reports the attempt.
+func (r *criRuntime) restartCounts(ctx context.Context, containers []runtimeContainer) {}
+
+func (r *criRuntime) stats(ctx context.Context, out []*ContainerInfo) {
+	var list struct {
+		Stats []criStats `json:"stats"`
+	}
+	if err := r.crictl(ctx, &list, "stats", "--output", "json"); err != nil {
+		return
+	}
+	byID := make(map[string]*criStats, len(list.Stats))
+	for i := range list.Stats {
+		byID[list.Stats[i].Attributes.ID] = &list.Stats[i]
+	}
+	for _, ctr := range out {
+		stats := byID[ctr.fullID]
+		if stats == nil {
+			continue
+		}
+		if stats.CPU != nil && stats.CPU.UsageNanoCores != nil {
+			ctr.CPUPercent = float64(stats.CPU.UsageNanoCores.Value) / 1e7
+		}
+		if stats.Memory != nil && stats.Memory.WorkingSetBytes != nil {
+			ctr.MemoryUsage = uint64(stats.Memory.WorkingSetBytes.Value)
+		}
+	}
+}
+
+// diskUsage reports images only; CRI has no volumes and no per-image
+// shared size.
+func (r *criRuntime) diskUsage(ctx context.Context, info *DockerInfo) error {
+	var list struct {
+		Images []criImage `json:"images"`
+	}
+	if err := r.crictl(ctx, &list, "images", "--output", "json"); err != nil {
+		return err
+	}
+	total := &DockerDiskUsage{}
+	for _, img := range list.Images {
+		image := ImageInfo{
+			ID:       shortImageID(img.ID),
+			Tags:     img.RepoTags,
+			Size:     int64(img.Size),
+			Dangling: len(img.RepoTags) == 0,
+		}
+		total.Images += image.Size
+		if image.Dangling {
+			total.DanglingImages++
+			total.DanglingSize += image.Size
+		}
+		info.Images = append(info.Images, image)
+	}
+	info.DiskUsage = total
+	return nil
+}
@@ -0,0 +1,21 @@
+package collector
+
+import "time"
+
+// schedule gates collectors that run on a slower cadence than the heartbeat.
+type schedule struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newSchedule(interval time.Duration) schedule {
+	return schedule{interval: interval}
+}
+
+func (s *schedule) due(now time.Time) bool {
+	if now.Before(s.next) {
+		return false
+	}
+	s.next = now.Add(s.interval)
+	return true
+}
@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type ScheduledJobsInfo struct {
+	Cron   []CronEntry `json:"cron"`
+	Timers []TimerInfo `json:"timers"`
+	Failed int         `json:"failed"`
+}
+
+type CronEntry struct {
+	Source   string `json:"source"`
+	User     string `json:"user,omitempty"`
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+}
+
+type TimerInfo struct {
+	Name       string     `json:"name"`
+	Unit       string     `json:"unit"`
+	NextRun    *time.Time `json:"nextRun,omitempty"`
+	LastRun    *time.Time `json:"lastRun,omitempty"`
+	Result     string     `json:"result,omitempty"`
+	ExitStatus int        `json:"exitStatus"`
+	Failed     bool       `json:"failed"`
+	LastOutput []string   `json:"lastOutput,omitempty"`
+}
+
+type ScheduledJobsCollector struct {
+	schedule schedule
+	events   *EventBuffer
+	failed   map[string]bool
+}
+
+func NewScheduledJobsCollector(cfg config.ScheduledJobsConfig, events *EventBuffer) *ScheduledJobsCollector {
+	return &ScheduledJobsCollector{
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		failed:   make(map[string]bool),
+	}
+}
+
+func (c *ScheduledJobsCollector) Collect(ctx context.Context) (*ScheduledJobsInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	info, err := collectScheduledJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string]bool)
+	for _, timer := range info.Timers {
+		if !timer.Failed {
+			continue
+		}
+		info.Failed++
+		failed[timer.Name] = true
+		if !c.failed[timer.Name] {
+			c.events.Add("scheduled_job_failed", SeverityWarning,
+				fmt.Sprintf("Last run of %s (%s) failed: %s, exit status %d", timer.Unit, timer.Name, timer.Result, timer.ExitStatus),
+				map[string]string{"timer": timer.Name, "unit": timer.Unit, "result": timer.Result})
+		}
+	}
+	for name := range c.failed {
+		if !failed[name] {
+			c.events.Add("scheduled_job_recovered", SeverityInfo,
+				fmt.Sprintf("Scheduled job %s succeeded again", name),
+				map[string]string{"timer": name})
+		}
+	}
+	c.failed = failed
+
+	return info, nil
+}
@@ -0,0 +1,231 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Each of these directories is run by run-parts on the named schedule.
+var cronPeriodicDirs = map[string]string{
+	"/etc/cron.hourly":  "@hourly",
+	"/etc/cron.daily":   "@daily",
+	"/etc/cron.weekly":  "@weekly",
+	"/etc/cron.monthly": "@monthly",
+}
+
+// maxJobOutputLines is how much of a failed job's journal is reported.
+const maxJobOutputLines = 5
+
+func collectScheduledJobs(ctx context.Context) (*ScheduledJobsInfo, error) {
+	info := &ScheduledJobsInfo{
+		Cron:   collectCron(),
+		Timers: make([]TimerInfo, 0),
+	}
+	// Timers are optional: hosts without systemd still report cron.
+	if timers, err := collectTimers(ctx); err == nil {
+		info.Timers = timers
+	}
+	return info, nil
+}
+
+func collectCron() []CronEntry {
+	entries := make([]CronEntry, 0)
+	entries = append(entries, parseCrontab("/etc/crontab", "", true)...)
+
+	files, _ := filepath.Glob("/etc/cron.d/*")
+	for _, f := range files {
+		entries = append(entries, parseCrontab(f, "", true)...)
+	}
+	// Debian keeps user crontabs in crontabs/, Red Hat directly in cron/.
+	for _, dir := range []string{"/var/spool/cron/crontabs", "/var/spool/cron"} {
+		files, _ := os.ReadDir(dir)
+		for _, f := range files {
+			if f.Type().IsRegular() {
+				entries = append(entries, parseCrontab(filepath.Join(dir, f.Name()), f.Name(), false)...)
+			}
+		}
+	}
+
+	for dir, schedule := range cronPeriodicDirs {
+		files, _ := os.ReadDir(dir)
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") || f.Name() == "placeholder" {
+				continue
+			}
+			entries = append(entries, CronEntry{
+				Source:   dir,
+				User:     "root",
+				Schedule: schedule,
+				Command:  filepath.Join(dir, f.Name()),
+			})
+		}
+	}
+	return entries
+}
+
+// parseCrontab reads a crontab. System crontabs have a user field after the
+// schedule; user crontabs belong to the file's owner.
+func parseCrontab(path, user string, system bool) []CronEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	entries := make([]CronEntry, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		// Environment assignments such as PATH=... or MAILTO="".
+		if strings.Contains(fields[0], "=") {
+			continue
+		}
+
+		scheduleFields := 5
+		if strings.HasPrefix(fields[0], "@") {
+			scheduleFields = 1
+		}
+		if system {
+			scheduleFields++
+		}
+		if len(fields) <= scheduleFields {
+			continue
+		}
+
+		entry := CronEntry{
+			Source:   path,
+			User:     user,
+			Schedule: strings.Join(fields[:scheduleFields], " "),
+			Command:  strings.Join(fields[scheduleFields:], " "),
+		}
+		if system {
+			entry.User = fields[scheduleFields-1]
+			entry.Schedule = strings.Join(fields[:scheduleFields-1], " ")
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func collectTimers(ctx context.Context) ([]TimerInfo, error) {
+	out, err := runCommand(ctx, 0, "systemctl", "list-units", "--type=timer", "--all", "--no-legend", "--plain")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && strings.HasSuffix(fields[0], ".timer") {
+			names = append(names, fields[0])
+		}
+	}
+	if len(names) == 0 {
+		return []TimerInfo{}, nil
+	}
+
+	timerProps, err := showUnits(ctx, names, "Id", "Unit", "NextElapseUSecRealtime", "LastTriggerUSec")
+	if err != nil {
+		return nil, err
+	}
+	units := make([]string, 0, len(timerProps))
+	for _, props := range timerProps {
+		units = append(units, props["Unit"])
+	}
+	unitProps, err := showUnits(ctx, units, "Id", "Result", "ExecMainStatus")
+	if err != nil {
+		return nil, err
+	}
+	byUnit := make(map[string]map[string]string, len(unitProps))
+	for _, props := range unitProps {
+		byUnit[props["Id"]] = props
+	}
+
+	timers := make([]TimerInfo, 0, len(timerProps))
+	for _, props := range timerProps {
+		timer := TimerInfo{
+			Name:    props["Id"],
+			Unit:    props["Unit"],
+			NextRun: parseSystemdTime(props["NextElapseUSecRealtime"]),
+			LastRun: parseSystemdTime(props["LastTriggerUSec"]),
+		}
+		if unit, ok := byUnit[timer.Unit]; ok && timer.LastRun != nil {
+			timer.Result = unit["Result"]
+			timer.ExitStatus, _ = strconv.Atoi(unit["ExecMainStatus"])
+			timer.Failed = timer.Result != "" && timer.Result != "success"
+		}
+		if timer.Failed {
+			timer.LastOutput = journalTail(ctx, timer.Unit, maxJobOutputLines)
+		}
+		timers = append(timers, timer)
+	}
+	return timers, nil
+}
+
+// showUnits runs systemctl show for several units at once and returns one
+// property map per unit, in order.
+func showUnits(ctx context.Context, units []string, props ...string) ([]map[string]string, error) {
+	args := []string{"show", "--timestamp=unix", "-p", strings.Join(props, ",")}
+	out, err := runCommand(ctx, 0, "systemctl", append(args, units...)...)
+	if err != nil {
+		// Older systemd does not know --timestamp; fall back to the
+		// default human-readable timestamps.
+		args = []string{"show", "-p", strings.Join(props, ",")}
+		if out, err = runCommand(ctx, 0, "systemctl", append(args, units...)...); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]map[string]string, 0, len(units))
+	for _, block := range bytes.Split(bytes.TrimSpace(out), []byte("\n\n")) {
+		values := make(map[string]string)
+		for _, line := range strings.Split(string(block), "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[key] = value
+			}
+		}
+		result = append(result, values)
+	}
+	return result, nil
+}
+
+func parseSystemdTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "n/a" || value == "0" {
+		return nil
+	}
+	if strings.HasPrefix(value, "@") {
+		sec, err := strconv.ParseInt(value[1:], 10, 64)
+		if err != nil || sec == 0 {
+			return nil
+		}
+		t := time.Unix(sec, 0).UTC()
+		return &t
+	}
+	t, err := time.Parse("Mon 2006-01-02 15:04:05 MST", value)
+	if err != nil {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func journalTail(ctx context.Context, unit string, lines int) []string {
+	out, err := runCommand(ctx, 0, "journalctl", "-u", unit, "-n", strconv.Itoa(lines), "-o", "cat", "--no-pager")
+	if err != nil {
+		return nil
+	}
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
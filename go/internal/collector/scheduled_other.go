@@ -0,0 +1,12 @@
+//go:build !linux
+
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+func collectScheduledJobs(ctx context.Context) (*ScheduledJobsInfo, error) {
+	return nil, fmt.Errorf("scheduled job inventory is not supported on this platform")
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type SecurityInfo struct {
+	SELinux  *SELinuxStatus  `json:"selinux,omitempty"`
+	AppArmor *AppArmorStatus `json:"apparmor,omitempty"`
+}
+
+type SELinuxStatus struct {
+	Mode   string `json:"mode"`
+	Policy string `json:"policy,omitempty"`
+}
+
+type AppArmorStatus struct {
+	Enabled          bool `json:"enabled"`
+	ProfilesLoaded   int  `json:"profilesLoaded"`
+	ProfilesEnforce  int  `json:"profilesEnforce"`
+	ProfilesComplain int  `json:"profilesComplain"`
+}
+
+type SecurityCollector struct {
+	schedule schedule
+	events   *EventBuffer
+	lastMode string
+}
+
+func NewSecurityCollector(cfg config.SecurityConfig, events *EventBuffer) *SecurityCollector {
+	return &SecurityCollector{
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+	}
+}
+
+func (c *SecurityCollector) Collect(ctx context.Context) (*SecurityInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	info, err := collectMACStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	mode := macMode(info)
+	if c.lastMode != "" && mode != c.lastMode {
+		c.events.Add("mac_mode_changed", SeverityWarning,
+			fmt.Sprintf("Mandatory access control changed from %s to %s", c.lastMode, mode),
+			map[string]string{"previous": c.lastMode, "current": mode})
+	}
+	c.lastMode = mode
+
+	return info, nil
+}
+
+// macMode summarises the MAC posture as a single comparable string.
+func macMode(info *SecurityInfo) string {
+	switch {
+	case info.SELinux != nil && info.SELinux.Mode != "disabled":
+		return "selinux:" + info.SELinux.Mode
+	case info.AppArmor != nil && info.AppArmor.Enabled:
+		return "apparmor:enabled"
+	default:
+		return "none"
+	}
+}
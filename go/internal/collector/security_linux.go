@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+func collectMACStatus() (*SecurityInfo, error) {
+	return &SecurityInfo{
+		SELinux:  readSELinuxStatus(),
+		AppArmor: readAppArmorStatus(),
+	}, nil
+}
+
+func readSELinuxStatus() *SELinuxStatus {
+	status := &SELinuxStatus{Mode: "disabled"}
+
+	if data, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil {
+		if strings.TrimSpace(string(data)) == "1" {
+			status.Mode = "enforcing"
+		} else {
+			status.Mode = "permissive"
+		}
+	} else if _, err := os.Stat("/etc/selinux/config"); err != nil {
+		// SELinux userspace is not installed at all.
+		return nil
+	}
+
+	if f, err := os.Open("/etc/selinux/config"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "SELINUXTYPE=") {
+				status.Policy = strings.TrimPrefix(line, "SELINUXTYPE=")
+			}
+		}
+	}
+	return status
+}
+
+func readAppArmorStatus() *AppArmorStatus {
+	data, err := os.ReadFile("/sys/module/apparmor/parameters/enabled")
+	if err != nil {
+		return nil
+	}
+
+	status := &AppArmorStatus{Enabled: strings.TrimSpace(string(data)) == "Y"}
+	if !status.Enabled {
+		return status
+	}
+
+	// Lines look like "/usr/sbin/ntpd (enforce)"; reading requires root.
+	f, err := os.Open("/sys/kernel/security/apparmor/profiles")
+	if err != nil {
+		return status
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		status.ProfilesLoaded++
+		switch {
+		case strings.HasSuffix(line, "(enforce)"):
+			status.ProfilesEnforce++
+		case strings.HasSuffix(line, "(complain)"):
+			status.ProfilesComplain++
+		}
+	}
+	return status
+}
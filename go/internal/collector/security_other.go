@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func collectMACStatus() (*SecurityInfo, error) {
+	return nil, fmt.Errorf("SELinux/AppArmor reporting is only supported on Linux")
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type SessionsInfo struct {
+	Sessions []UserSession `json:"sessions"`
+	// Users is the number of distinct logged-in users.
+	Users int `json:"users"`
+}
+
+// UserSession is one login. Terminal is the tty or, on Windows, the
+// session name ("Console", "RDP-Tcp#3"); Host is the remote host or RDP
+// client name.
+type UserSession struct {
+	User      string     `json:"user"`
+	Terminal  string     `json:"terminal,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	State     string     `json:"state,omitempty"`
+	LoginTime *time.Time `json:"loginTime,omitempty"`
+}
+
+func (s UserSession) key() string {
+	started := ""
+	if s.LoginTime != nil {
+		started = s.LoginTime.Format(time.RFC3339)
+	}
+	return s.User + "|" + s.Terminal + "|" + s.Host + "|" + started
+}
+
+// SessionCollector lists logged-in users and reports logins and logouts
+// seen between two heartbeats as events.
+type SessionCollector struct {
+	events *EventBuffer
+	known  map[string]UserSession
+}
+
+func NewSessionCollector(events *EventBuffer) *SessionCollector {
+	return &SessionCollector{events: events}
+}
+
+func (c *SessionCollector) Collect(ctx context.Context) (*SessionsInfo, error) {
+	sessions, err := listSessions()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].key() < sessions[j].key() })
+
+	current := make(map[string]UserSession, len(sessions))
+	users := make(map[string]bool)
+	for _, s := range sessions {
+		current[s.key()] = s
+		users[s.User] = true
+	}
+	if c.known != nil {
+		for key, s := range current {
+			if _, ok := c.known[key]; !ok {
+				c.events.Add("user_login", SeverityInfo, fmt.Sprintf("%s logged in%s", s.User, sessionOrigin(s)), sessionAttributes(s))
+			}
+		}
+		for key, s := range c.known {
+			if _, ok := current[key]; !ok {
+				c.events.Add("user_logout", SeverityInfo, fmt.Sprintf("%s logged out%s", s.User, sessionOrigin(s)), sessionAttributes(s))
+			}
+		}
+	}
+	c.known = current
+
+	return &SessionsInfo{Sessions: sessions, Users: len(users)}, nil
+}
+
+func sessionOrigin(s UserSession) string {
+	if s.Host != "" {
+		return " from " + s.Host
+	}
+	if s.Terminal != "" {
+		return " on " + s.Terminal
+	}
+	return ""
+}
+
+func sessionAttributes(s UserSession) map[string]string {
+	attrs := map[string]string{"user": s.User}
+	if s.Terminal != "" {
+		attrs["terminal"] = s.Terminal
+	}
+	if s.Host != "" {
+		attrs["host"] = s.Host
+	}
+	return attrs
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// listSessions reads utmp.
+func listSessions() ([]UserSession, error) {
+	users, err := host.Users()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logged-in users: %w", err)
+	}
+	sessions := make([]UserSession, 0, len(users))
+	for _, u := range users {
+		s := UserSession{User: u.User, Terminal: u.Terminal, Host: u.Host}
+		if u.Started > 0 {
+			started := time.Unix(int64(u.Started), 0).UTC()
+			s.LoginTime = &started
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
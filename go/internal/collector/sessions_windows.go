@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procWTSQuerySessionInformation = windows.NewLazySystemDLL("wtsapi32.dll").NewProc("WTSQuerySessionInformationW")
+
+const (
+	wtsClientName  = 10
+	wtsSessionInfo = 24
+)
+
+// wtsInfo is WTSINFOW.
+type wtsInfo struct {
+	State                   uint32
+	SessionID               uint32
+	IncomingBytes           uint32
+	OutgoingBytes           uint32
+	IncomingFrames          uint32
+	OutgoingFrames          uint32
+	IncomingCompressedBytes uint32
+	OutgoingCompressedBytes uint32
+	WinStationName          [32]uint16
+	Domain                  [17]uint16
+	UserName                [21]uint16
+	ConnectTime             int64
+	DisconnectTime          int64
+	LastInputTime           int64
+	LogonTime               int64
+	CurrentTime             int64
+}
+
+var wtsStates = map[uint32]string{
+	0: "active",
+	1: "connected",
+	3: "shadow",
+	4: "disconnected",
+	5: "idle",
+}
+
+// listSessions enumerates Remote Desktop Services sessions, which include
+// the console, and skips those without a user.
+func listSessions() ([]UserSession, error) {
+	var infos *windows.WTS_SESSION_INFO
+	var count uint32
+	if err := windows.WTSEnumerateSessions(0, 0, 1, &infos, &count); err != nil {
+		return nil, fmt.Errorf("failed to enumerate sessions: %w", err)
+	}
+	defer windows.WTSFreeMemory(uintptr(unsafe.Pointer(infos)))
+
+	var sessions []UserSession
+	for _, si := range unsafe.Slice(infos, count) {
+		buf, err := querySession(si.SessionID, wtsSessionInfo)
+		if err != nil || len(buf) < int(unsafe.Sizeof(wtsInfo{})) {
+			continue
+		}
+		info := (*wtsInfo)(unsafe.Pointer(&buf[0]))
+		user := windows.UTF16ToString(info.UserName[:])
+		if user == "" {
+			continue
+		}
+		if domain := windows.UTF16ToString(info.Domain[:]); domain != "" {
+			user = domain + `\` + user
+		}
+		s := UserSession{
+			User:     user,
+			Terminal: windows.UTF16ToString(info.WinStationName[:]),
+			State:    wtsStates[info.State],
+		}
+		if info.LogonTime > 0 {
+			ft := syscall.Filetime{LowDateTime: uint32(info.LogonTime), HighDateTime: uint32(info.LogonTime >> 32)}
+			logon := time.Unix(0, ft.Nanoseconds()).UTC()
+			s.LoginTime = &logon
+		}
+		if client, err := querySession(si.SessionID, wtsClientName); err == nil && len(client) >= 2 {
+			s.Host = windows.UTF16ToString(unsafe.Slice((*uint16)(unsafe.Pointer(&client[0])), len(client)/2))
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// querySession returns a copy of one WTSQuerySessionInformation value.
+func querySession(session uint32, class uint32) ([]byte, error) {
+	var buf *byte
+	var size uint32
+	r, _, err := procWTSQuerySessionInformation.Call(0, uintptr(session), uintptr(class),
+		uintptr(unsafe.Pointer(&buf)), uintptr(unsafe.Pointer(&size)))
+	if r == 0 {
+		return nil, err
+	}
+	defer windows.WTSFreeMemory(uintptr(unsafe.Pointer(buf)))
+	return append([]byte(nil), unsafe.Slice(buf, size)...), nil
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	suspendPollInterval = 5 * time.Second
+	// suspendThreshold is well above scheduling delays on a loaded host and
+	// above the clock steps NTP usually makes.
+	suspendThreshold = 30 * time.Second
+)
+
+// SuspendWatcher notices when the host resumes from suspend or hibernation.
+// Go's monotonic clock stops while Linux and macOS are suspended but the wall
+// clock does not, so a resume shows up as the two drifting apart; where the
+// monotonic clock keeps running, the watcher's own tick arrives late instead.
+type SuspendWatcher struct {
+	events  *EventBuffer
+	resumed chan time.Duration
+}
+
+func NewSuspendWatcher(events *EventBuffer) *SuspendWatcher {
+	return &SuspendWatcher{events: events, resumed: make(chan time.Duration, 1)}
+}
+
+// Start watches the clocks in the background until ctx is canceled.
+func (w *SuspendWatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(suspendPollInterval)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case now := <-ticker.C:
+				if suspended := suspendedFor(last, now); suspended > suspendThreshold {
+					w.resume(suspended)
+				}
+				last = now
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Resumed receives the approximate time spent suspended after each resume.
+func (w *SuspendWatcher) Resumed() <-chan time.Duration {
+	return w.resumed
+}
+
+func (w *SuspendWatcher) resume(suspended time.Duration) {
+	suspended = suspended.Round(time.Second)
+	w.events.Add("system_resumed", SeverityInfo,
+		fmt.Sprintf("System resumed after about %s suspended", suspended),
+		map[string]string{"suspendedSeconds": fmt.Sprint(int64(suspended.Seconds()))})
+	select {
+	case w.resumed <- suspended:
+	default:
+	}
+}
+
+// suspendedFor estimates how long the host was suspended between two ticks
+// that should be suspendPollInterval apart.
+func suspendedFor(last, now time.Time) time.Duration {
+	monotonic := now.Sub(last)
+	wall := now.Round(0).Sub(last.Round(0))
+	return max(wall-monotonic, monotonic-suspendPollInterval)
+}
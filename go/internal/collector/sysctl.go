@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+// SysctlInfo is the current value of every watched kernel parameter and
+// the ones that differ from the previous snapshot.
+type SysctlInfo struct {
+	Values  map[string]string `json:"values"`
+	Changed []SysctlChange    `json:"changed,omitempty"`
+}
+
+type SysctlChange struct {
+	Key      string `json:"key"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// SysctlCollector snapshots kernel parameters. The snapshot is kept in the
+// state store so changes made while the agent was stopped are reported.
+type SysctlCollector struct {
+	keys     []string
+	schedule schedule
+	events   *EventBuffer
+	state    *state.Bucket
+}
+
+func NewSysctlCollector(cfg config.SysctlConfig, events *EventBuffer, bucket *state.Bucket) *SysctlCollector {
+	return &SysctlCollector{
+		keys:     cfg.Keys,
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		events:   events,
+		state:    bucket,
+	}
+}
+
+func (c *SysctlCollector) Collect(ctx context.Context) (*SysctlInfo, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+	values, err := readSysctls(c.keys)
+	if err != nil {
+		return nil, err
+	}
+	info := &SysctlInfo{Values: values}
+
+	var previous map[string]string
+	if c.state.Get("snapshot", &previous) {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			old, ok := previous[key]
+			if !ok || old == values[key] {
+				continue
+			}
+			change := SysctlChange{Key: key, Previous: old, Current: values[key]}
+			info.Changed = append(info.Changed, change)
+			c.events.Add("sysctl_changed", SeverityWarning,
+				fmt.Sprintf("Kernel parameter %s changed from %q to %q", key, old, values[key]),
+				map[string]string{"key": key, "previous": old, "current": values[key]})
+		}
+	}
+	if err := c.state.Put("snapshot", values); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const procSys = "/proc/sys"
+
+// readSysctls reads the keys from /proc/sys. Keys may be glob patterns
+// such as "net.ipv4.tcp_*"; keys that don't exist are skipped.
+func readSysctls(keys []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range keys {
+		paths, err := filepath.Glob(filepath.Join(procSys, strings.ReplaceAll(key, ".", "/")))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				// Directories and write-only parameters.
+				continue
+			}
+			name := strings.ReplaceAll(strings.TrimPrefix(path, procSys+"/"), "/", ".")
+			values[name] = strings.Join(strings.Fields(string(data)), " ")
+		}
+	}
+	return values, nil
+}
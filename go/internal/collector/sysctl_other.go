@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func readSysctls(keys []string) (map[string]string, error) {
+	return nil, fmt.Errorf("sysctl snapshots are only available on Linux")
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -8,7 +9,6 @@ import (
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/load"
 	"github.com/shirou/gopsutil/v3/mem"
@@ -20,6 +20,9 @@ type SystemMetrics struct {
 	CPU      CPUInfo
 	Memory   MemoryInfo
 	Disk     DiskInfo
+	// Errors holds the sections ("uptime", "cpu", "load", "memory", "swap",
+	// "disk") that could not be read and are left zero.
+	Errors map[string]error
 }
 
 type CPUInfo struct {
@@ -54,7 +57,7 @@ func NewSystemCollector() *SystemCollector {
 	return &SystemCollector{}
 }
 
-func (c *SystemCollector) Collect() (*SystemMetrics, error) {
+func (c *SystemCollector) Collect(ctx context.Context) (*SystemMetrics, error) {
 	metrics := &SystemMetrics{}
 
 	hostname, err := os.Hostname()
@@ -66,11 +69,15 @@ func (c *SystemCollector) Collect() (*SystemMetrics, error) {
 	hostInfo, err := host.Info()
 	if err == nil {
 		metrics.Uptime = hostInfo.Uptime
+	} else {
+		metrics.fail("uptime", err)
 	}
 
 	cpuPercent, err := cpu.Percent(time.Second, false)
 	if err == nil && len(cpuPercent) > 0 {
 		metrics.CPU.Usage = cpuPercent[0]
+	} else if err != nil {
+		metrics.fail("cpu", err)
 	}
 
 	metrics.CPU.Cores = runtime.NumCPU()
@@ -85,6 +92,8 @@ func (c *SystemCollector) Collect() (*SystemMetrics, error) {
 		metrics.CPU.LoadAvg1 = loadAvg.Load1
 		metrics.CPU.LoadAvg5 = loadAvg.Load5
 		metrics.CPU.LoadAvg15 = loadAvg.Load15
+	} else {
+		metrics.fail("load", err)
 	}
 
 	memInfo, err := mem.VirtualMemory()
@@ -93,26 +102,40 @@ func (c *SystemCollector) Collect() (*SystemMetrics, error) {
 		metrics.Memory.Used = memInfo.Used
 		metrics.Memory.Available = memInfo.Available
 		metrics.Memory.UsagePercent = memInfo.UsedPercent
+	} else {
+		metrics.fail("memory", err)
 	}
 
 	swapInfo, err := mem.SwapMemory()
 	if err == nil {
 		metrics.Memory.SwapTotal = swapInfo.Total
 		metrics.Memory.SwapUsed = swapInfo.Used
+	} else {
+		metrics.fail("swap", err)
 	}
 
-	diskInfo, err := disk.Usage("/")
+	// Guarded in case / itself is a network filesystem.
+	diskInfo, err := mountUsage(ctx, "/", 10*time.Second)
 	if err == nil {
 		metrics.Disk.Total = diskInfo.Total
 		metrics.Disk.Used = diskInfo.Used
 		metrics.Disk.Available = diskInfo.Free
 		metrics.Disk.UsagePercent = diskInfo.UsedPercent
 		metrics.Disk.MountPoint = "/"
+	} else {
+		metrics.fail("disk", err)
 	}
 
 	return metrics, nil
 }
 
+func (m *SystemMetrics) fail(section string, err error) {
+	if m.Errors == nil {
+		m.Errors = make(map[string]error)
+	}
+	m.Errors[section] = err
+}
+
 func FormatBytes(bytes uint64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"time"
+)
+
+type TCPStats struct {
+	IntervalSeconds       float64 `json:"intervalSeconds"`
+	SegmentsInPerSec      float64 `json:"segmentsInPerSec"`
+	SegmentsOutPerSec     float64 `json:"segmentsOutPerSec"`
+	RetransPerSec         float64 `json:"retransPerSec"`
+	RetransPercent        float64 `json:"retransPercent"`
+	ResetsSentPerSec      float64 `json:"resetsSentPerSec"`
+	EstabResetsPerSec     float64 `json:"estabResetsPerSec"`
+	AttemptFailsPerSec    float64 `json:"attemptFailsPerSec"`
+	InErrorsPerSec        float64 `json:"inErrorsPerSec"`
+	ListenOverflowsPerSec float64 `json:"listenOverflowsPerSec"`
+	ListenDropsPerSec     float64 `json:"listenDropsPerSec"`
+	TimeoutsPerSec        float64 `json:"timeoutsPerSec"`
+	UDPInErrorsPerSec     float64 `json:"udpInErrorsPerSec"`
+	UDPRcvbufErrorsPerSec float64 `json:"udpRcvbufErrorsPerSec"`
+	CurrentEstablished    uint64  `json:"currentEstablished"`
+}
+
+type TCPStatsCollector struct {
+	previous     map[string]uint64
+	previousTime time.Time
+}
+
+func NewTCPStatsCollector() *TCPStatsCollector {
+	return &TCPStatsCollector{}
+}
+
+// Rebaseline discards the previous counters, e.g. after a resume from
+// suspend, so the next call establishes a new baseline.
+func (c *TCPStatsCollector) Rebaseline() {
+	c.previous = nil
+}
+
+// Collect returns rates since the previous call; the first call only
+// records a baseline and returns nil.
+func (c *TCPStatsCollector) Collect(ctx context.Context) (*TCPStats, error) {
+	counters, err := readTCPCounters()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	prev, prevTime := c.previous, c.previousTime
+	c.previous, c.previousTime = counters, now
+	if prev == nil {
+		return nil, nil
+	}
+
+	elapsed := now.Sub(prevTime).Seconds()
+	if elapsed <= 0 {
+		return nil, nil
+	}
+
+	rate := func(key string) float64 {
+		cur, old := counters[key], prev[key]
+		if cur < old {
+			// Counter reset (e.g. namespace recreated); skip this sample.
+			return 0
+		}
+		return float64(cur-old) / elapsed
+	}
+
+	stats := &TCPStats{
+		IntervalSeconds:       elapsed,
+		SegmentsInPerSec:      rate("Tcp.InSegs"),
+		SegmentsOutPerSec:     rate("Tcp.OutSegs"),
+		RetransPerSec:         rate("Tcp.RetransSegs"),
+		ResetsSentPerSec:      rate("Tcp.OutRsts"),
+		EstabResetsPerSec:     rate("Tcp.EstabResets"),
+		AttemptFailsPerSec:    rate("Tcp.AttemptFails"),
+		InErrorsPerSec:        rate("Tcp.InErrs"),
+		ListenOverflowsPerSec: rate("TcpExt.ListenOverflows"),
+		ListenDropsPerSec:     rate("TcpExt.ListenDrops"),
+		TimeoutsPerSec:        rate("TcpExt.TCPTimeouts"),
+		UDPInErrorsPerSec:     rate("Udp.InErrors"),
+		UDPRcvbufErrorsPerSec: rate("Udp.RcvbufErrors"),
+		CurrentEstablished:    counters["Tcp.CurrEstab"],
+	}
+	if stats.SegmentsOutPerSec > 0 {
+		stats.RetransPercent = stats.RetransPerSec / stats.SegmentsOutPerSec * 100
+	}
+
+	return stats, nil
+}
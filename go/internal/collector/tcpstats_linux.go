@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readTCPCounters merges /proc/net/snmp and /proc/net/netstat into a single
+// map keyed by "Section.Counter", e.g. "Tcp.RetransSegs".
+func readTCPCounters() (map[string]uint64, error) {
+	counters := make(map[string]uint64)
+	if err := readProcNetStat("/proc/net/snmp", counters); err != nil {
+		return nil, err
+	}
+	if err := readProcNetStat("/proc/net/netstat", counters); err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// readProcNetStat parses the paired header/value line format:
+//
+//	Tcp: RtoAlgorithm RtoMin ...
+//	Tcp: 1 200 ...
+func readProcNetStat(path string, counters map[string]uint64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		header := strings.Fields(scanner.Text())
+		if !scanner.Scan() {
+			break
+		}
+		values := strings.Fields(scanner.Text())
+		if len(header) != len(values) || len(header) == 0 || header[0] != values[0] {
+			continue
+		}
+
+		section := strings.TrimSuffix(header[0], ":")
+		for i := 1; i < len(header); i++ {
+			// CurrEstab and MaxConn are gauges and may be negative; parse as int.
+			v, err := strconv.ParseInt(values[i], 10, 64)
+			if err != nil || v < 0 {
+				continue
+			}
+			counters[section+"."+header[i]] = uint64(v)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collector
+
+import "fmt"
+
+func readTCPCounters() (map[string]uint64, error) {
+	return nil, fmt.Errorf("TCP counters are not supported on this platform")
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	tcpEstablished = 1
+	tcpListen      = 10
+)
+
+type TopTalkersInfo struct {
+	TotalConnections int               `json:"totalConnections"`
+	Groups           []ConnectionGroup `json:"groups"`
+	Truncated        bool              `json:"truncated,omitempty"`
+}
+
+type ConnectionGroup struct {
+	Remote        string `json:"remote"`
+	Port          uint16 `json:"port"`
+	Direction     string `json:"direction"`
+	Connections   int    `json:"connections"`
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+}
+
+type TopTalkersCollector struct {
+	maxEntries      int
+	anonymize       bool
+	includeLoopback bool
+}
+
+func NewTopTalkersCollector(cfg config.TopTalkersConfig) *TopTalkersCollector {
+	return &TopTalkersCollector{
+		maxEntries:      cfg.MaxEntries,
+		anonymize:       cfg.Anonymize,
+		includeLoopback: cfg.IncludeLoopback,
+	}
+}
+
+// Collect groups established connections by remote host and service port.
+// Connections to a local listening port are "in", everything else "out".
+func (c *TopTalkersCollector) Collect(ctx context.Context) (*TopTalkersInfo, error) {
+	sockets, err := readTCPSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	listening := make(map[uint16]bool)
+	for _, s := range sockets {
+		if s.state == tcpListen {
+			listening[s.localPort] = true
+		}
+	}
+
+	info := &TopTalkersInfo{}
+	groups := make(map[string]*ConnectionGroup)
+	for _, s := range sockets {
+		if s.state != tcpEstablished {
+			continue
+		}
+		if !c.includeLoopback && s.remoteIP.IsLoopback() {
+			continue
+		}
+		info.TotalConnections++
+
+		direction, port := "out", s.remotePort
+		if listening[s.localPort] {
+			direction, port = "in", s.localPort
+		}
+
+		remote := s.remoteIP.String()
+		if c.anonymize {
+			remote = anonymizeIP(s.remoteIP)
+		}
+
+		key := direction + "|" + remote + "|" + strconv.Itoa(int(port))
+		group, ok := groups[key]
+		if !ok {
+			group = &ConnectionGroup{Remote: remote, Port: port, Direction: direction}
+			groups[key] = group
+		}
+		group.Connections++
+		group.BytesSent += s.sent
+		group.BytesReceived += s.received
+	}
+
+	info.Groups = make([]ConnectionGroup, 0, len(groups))
+	for _, group := range groups {
+		info.Groups = append(info.Groups, *group)
+	}
+	sort.Slice(info.Groups, func(i, j int) bool {
+		a, b := info.Groups[i], info.Groups[j]
+		if a.BytesSent+a.BytesReceived != b.BytesSent+b.BytesReceived {
+			return a.BytesSent+a.BytesReceived > b.BytesSent+b.BytesReceived
+		}
+		return a.Connections > b.Connections
+	})
+	if len(info.Groups) > c.maxEntries {
+		info.Groups = info.Groups[:c.maxEntries]
+		info.Truncated = true
+	}
+
+	return info, nil
+}
+
+// anonymizeIP masks the host portion: IPv4 to /24, IPv6 to /48.
+func anonymizeIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
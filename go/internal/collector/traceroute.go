@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type TracerouteResult struct {
+	Target      string          `json:"target"`
+	Destination string          `json:"destination,omitempty"`
+	HopCount    int             `json:"hopCount"`
+	Reached     bool            `json:"reached"`
+	PathChanged bool            `json:"pathChanged"`
+	Hops        []TracerouteHop `json:"hops"`
+	Error       string          `json:"error,omitempty"`
+}
+
+type TracerouteHop struct {
+	TTL        int     `json:"ttl"`
+	Address    string  `json:"address,omitempty"`
+	RTTMs      float64 `json:"rttMs"`
+	RTTDeltaMs float64 `json:"rttDeltaMs"`
+}
+
+type TracerouteCollector struct {
+	targets  []string
+	maxHops  int
+	schedule schedule
+
+	mu       sync.Mutex
+	previous map[string][]TracerouteHop
+}
+
+func NewTracerouteCollector(cfg config.TracerouteConfig) *TracerouteCollector {
+	return &TracerouteCollector{
+		targets:  cfg.Targets,
+		maxHops:  cfg.MaxHops,
+		schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+		previous: make(map[string][]TracerouteHop),
+	}
+}
+
+// Collect probes every target concurrently. It returns nil until the
+// configured interval has elapsed since the previous run.
+func (c *TracerouteCollector) Collect(ctx context.Context) ([]TracerouteResult, error) {
+	if !c.schedule.due(time.Now()) {
+		return nil, nil
+	}
+
+	if _, err := exec.LookPath("traceroute"); err != nil {
+		return nil, fmt.Errorf("traceroute not found in PATH")
+	}
+
+	results := make([]TracerouteResult, len(c.targets))
+	var wg sync.WaitGroup
+	for i, target := range c.targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			results[i] = c.probe(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func (c *TracerouteCollector) probe(ctx context.Context, target string) TracerouteResult {
+	result := TracerouteResult{Target: target, Hops: make([]TracerouteHop, 0)}
+
+	// One probe per hop with a one second wait keeps the worst case bounded by max_hops.
+	timeout := time.Duration(c.maxHops+5) * time.Second
+	out, err := runCommand(ctx, timeout, "traceroute", "-n", "-q", "1", "-w", "1", "-m", strconv.Itoa(c.maxHops), target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Destination, result.Hops = parseTraceroute(out)
+	result.HopCount = len(result.Hops)
+	if result.HopCount > 0 {
+		result.Reached = result.Hops[result.HopCount-1].Address == result.Destination
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prev, ok := c.previous[target]; ok {
+		result.PathChanged = len(prev) != len(result.Hops)
+		for i := range result.Hops {
+			if i >= len(prev) {
+				break
+			}
+			if prev[i].Address != result.Hops[i].Address {
+				result.PathChanged = true
+				continue
+			}
+			if result.Hops[i].Address != "" {
+				result.Hops[i].RTTDeltaMs = result.Hops[i].RTTMs - prev[i].RTTMs
+			}
+		}
+	}
+	c.previous[target] = result.Hops
+
+	return result
+}
+
+// parseTraceroute parses `traceroute -n -q 1` output, e.g.
+//
+//	traceroute to 8.8.8.8 (8.8.8.8), 30 hops max, 60 byte packets
+//	 1  192.168.1.1  0.512 ms
+//	 2  *
+func parseTraceroute(out []byte) (string, []TracerouteHop) {
+	var destination string
+	hops := make([]TracerouteHop, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "traceroute to") {
+			if start := strings.Index(line, "("); start != -1 {
+				if end := strings.Index(line[start:], ")"); end != -1 {
+					destination = line[start+1 : start+end]
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ttl, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		hop := TracerouteHop{TTL: ttl}
+		if fields[1] != "*" {
+			hop.Address = fields[1]
+			if len(fields) >= 3 {
+				hop.RTTMs, _ = strconv.ParseFloat(fields[2], 64)
+			}
+		}
+		hops = append(hops, hop)
+	}
+
+	return destination, hops
+}
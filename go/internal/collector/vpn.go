@@ -0,0 +1,200 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+// VPNInfo.WireGuardError is set when wg could not be queried; tunnel
+// checks are still reported.
+type VPNInfo struct {
+	WireGuard      []WireGuardInterface `json:"wireguard,omitempty"`
+	WireGuardError string               `json:"wireguardError,omitempty"`
+	Tunnels        []TunnelStatus       `json:"tunnels,omitempty"`
+}
+
+type WireGuardInterface struct {
+	Name       string          `json:"name"`
+	ListenPort int             `json:"listenPort"`
+	Peers      []WireGuardPeer `json:"peers"`
+}
+
+type WireGuardPeer struct {
+	PublicKey           string `json:"publicKey"`
+	Endpoint            string `json:"endpoint,omitempty"`
+	AllowedIPs          string `json:"allowedIps,omitempty"`
+	LatestHandshake     int64  `json:"latestHandshake"`
+	HandshakeAgeSeconds int64  `json:"handshakeAgeSeconds"`
+	TransferRx          uint64 `json:"transferRx"`
+	TransferTx          uint64 `json:"transferTx"`
+	Stale               bool   `json:"stale"`
+}
+
+type TunnelStatus struct {
+	Name        string  `json:"name"`
+	Interface   string  `json:"interface,omitempty"`
+	InterfaceUp bool    `json:"interfaceUp"`
+	Target      string  `json:"target,omitempty"`
+	Reachable   bool    `json:"reachable"`
+	LatencyMs   float64 `json:"latencyMs,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+type VPNCollector struct {
+	handshakeThreshold time.Duration
+	tunnels            []config.TunnelConfig
+	events             *EventBuffer
+	stale              map[string]bool
+	down               map[string]bool
+}
+
+func NewVPNCollector(cfg config.VPNConfig, events *EventBuffer) *VPNCollector {
+	return &VPNCollector{
+		handshakeThreshold: time.Duration(cfg.HandshakeThreshold) * time.Second,
+		tunnels:            cfg.Tunnels,
+		events:             events,
+		stale:              make(map[string]bool),
+		down:               make(map[string]bool),
+	}
+}
+
+func (c *VPNCollector) Collect(ctx context.Context) (*VPNInfo, error) {
+	info := &VPNInfo{}
+
+	if _, err := exec.LookPath("wg"); err == nil {
+		if out, err := runCommand(ctx, 0, "wg", "show", "all", "dump"); err != nil {
+			info.WireGuardError = err.Error()
+		} else {
+			info.WireGuard = parseWireGuardDump(out, time.Now(), c.handshakeThreshold)
+			c.checkPeers(info.WireGuard)
+		}
+	}
+
+	for _, tunnel := range c.tunnels {
+		status := checkTunnel(ctx, tunnel)
+		info.Tunnels = append(info.Tunnels, status)
+
+		healthy := status.InterfaceUp && (status.Target == "" || status.Reachable)
+		if !healthy && !c.down[tunnel.Name] {
+			c.events.Add("tunnel_down", SeverityCritical,
+				fmt.Sprintf("Tunnel %s is down", tunnel.Name),
+				map[string]string{"tunnel": tunnel.Name, "interface": tunnel.Interface, "error": status.Error})
+		} else if healthy && c.down[tunnel.Name] {
+			c.events.Add("tunnel_up", SeverityInfo,
+				fmt.Sprintf("Tunnel %s recovered", tunnel.Name),
+				map[string]string{"tunnel": tunnel.Name, "interface": tunnel.Interface})
+		}
+		c.down[tunnel.Name] = !healthy
+	}
+
+	return info, nil
+}
+
+func (c *VPNCollector) checkPeers(interfaces []WireGuardInterface) {
+	for _, iface := range interfaces {
+		for _, peer := range iface.Peers {
+			key := iface.Name + "/" + peer.PublicKey
+			if peer.Stale && !c.stale[key] {
+				c.events.Add("wireguard_handshake_stale", SeverityWarning,
+					fmt.Sprintf("WireGuard peer %s on %s has not completed a handshake in %ds",
+						shortKey(peer.PublicKey), iface.Name, peer.HandshakeAgeSeconds),
+					map[string]string{"interface": iface.Name, "peer": peer.PublicKey, "endpoint": peer.Endpoint})
+			}
+			c.stale[key] = peer.Stale
+		}
+	}
+}
+
+func checkTunnel(ctx context.Context, tunnel config.TunnelConfig) TunnelStatus {
+	status := TunnelStatus{Name: tunnel.Name, Interface: tunnel.Interface, Target: tunnel.Target}
+
+	if tunnel.Interface != "" {
+		iface, err := net.InterfaceByName(tunnel.Interface)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		status.InterfaceUp = iface.Flags&net.FlagUp != 0
+	} else {
+		status.InterfaceUp = true
+	}
+
+	if tunnel.Target != "" {
+		start := time.Now()
+		dialer := net.Dialer{Timeout: 5 * time.Second}
+		conn, err := dialer.DialContext(ctx, "tcp", tunnel.Target)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		conn.Close()
+		status.Reachable = true
+		status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	}
+
+	return status
+}
+
+// parseWireGuardDump parses `wg show all dump`. Interface lines have five
+// tab-separated fields, peer lines have nine.
+func parseWireGuardDump(out []byte, now time.Time, threshold time.Duration) []WireGuardInterface {
+	interfaces := make([]WireGuardInterface, 0)
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		switch len(fields) {
+		case 5:
+			port, _ := strconv.Atoi(fields[3])
+			index[fields[0]] = len(interfaces)
+			interfaces = append(interfaces, WireGuardInterface{
+				Name:       fields[0],
+				ListenPort: port,
+				Peers:      make([]WireGuardPeer, 0),
+			})
+		case 9:
+			i, ok := index[fields[0]]
+			if !ok {
+				continue
+			}
+			handshake, _ := strconv.ParseInt(fields[5], 10, 64)
+			rx, _ := strconv.ParseUint(fields[6], 10, 64)
+			tx, _ := strconv.ParseUint(fields[7], 10, 64)
+
+			peer := WireGuardPeer{
+				PublicKey:       fields[1],
+				AllowedIPs:      fields[4],
+				LatestHandshake: handshake,
+				TransferRx:      rx,
+				TransferTx:      tx,
+			}
+			if fields[3] != "(none)" {
+				peer.Endpoint = fields[3]
+			}
+			if handshake > 0 {
+				peer.HandshakeAgeSeconds = now.Unix() - handshake
+			}
+			peer.Stale = handshake == 0 || time.Duration(peer.HandshakeAgeSeconds)*time.Second > threshold
+
+			interfaces[i].Peers = append(interfaces[i].Peers, peer)
+		}
+	}
+	return interfaces
+}
+
+func shortKey(key string) string {
+	if len(key) > 8 {
+		return key[:8] + "..."
+	}
+	return key
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+type WindowsInfo struct {
+	ProcessorQueueLength  uint32           `json:"processorQueueLength"`
+	ContextSwitchesPerSec uint32           `json:"contextSwitchesPerSec"`
+	DiskQueueLength       uint32           `json:"diskQueueLength"`
+	PagesPerSec           uint64           `json:"pagesPerSec"`
+	PageFaultsPerSec      uint32           `json:"pageFaultsPerSec"`
+	PagingFilePercent     uint32           `json:"pagingFilePercent"`
+	EventLog              []EventLogCounts `json:"eventLog,omitempty"`
+}
+
+type EventLogCounts struct {
+	Log      string `json:"log"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+type WindowsCollector struct {
+	eventLogs []string
+	lastRun   time.Time
+}
+
+func NewWindowsCollector(cfg config.WindowsConfig) *WindowsCollector {
+	return &WindowsCollector{
+		eventLogs: cfg.EventLogs,
+		lastRun:   time.Now(),
+	}
+}
+
+// Collect reads performance counters and the number of error and warning
+// events written to each configured log since the previous call.
+func (c *WindowsCollector) Collect(ctx context.Context) (*WindowsInfo, error) {
+	since := c.lastRun
+	c.lastRun = time.Now()
+	return collectWindows(c.eventLogs, since)
+}
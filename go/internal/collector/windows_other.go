@@ -0,0 +1,12 @@
+//go:build !windows
+
+package collector
+
+import (
+	"fmt"
+	"time"
+)
+
+func collectWindows(eventLogs []string, since time.Time) (*WindowsInfo, error) {
+	return nil, fmt.Errorf("Windows counters are only available on Windows")
+}
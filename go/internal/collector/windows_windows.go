@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+)
+
+type win32PerfOSSystem struct {
+	ProcessorQueueLength  uint32
+	ContextSwitchesPersec uint32
+}
+
+type win32PerfDisk struct {
+	CurrentDiskQueueLength uint32
+}
+
+type win32PerfMemory struct {
+	PagesPersec      uint64
+	PageFaultsPersec uint32
+}
+
+type win32PerfPagingFile struct {
+	PercentUsage uint32
+}
+
+type win32EventCount struct {
+	EventType uint8
+}
+
+func collectWindows(eventLogs []string, since time.Time) (*WindowsInfo, error) {
+	info := &WindowsInfo{}
+
+	var system []win32PerfOSSystem
+	if err := wmi.Query("SELECT ProcessorQueueLength, ContextSwitchesPersec FROM Win32_PerfFormattedData_PerfOS_System", &system); err != nil {
+		return nil, fmt.Errorf("failed to query system counters: %w", err)
+	}
+	if len(system) > 0 {
+		info.ProcessorQueueLength = system[0].ProcessorQueueLength
+		info.ContextSwitchesPerSec = system[0].ContextSwitchesPersec
+	}
+
+	var disk []win32PerfDisk
+	if err := wmi.Query("SELECT CurrentDiskQueueLength FROM Win32_PerfFormattedData_PerfDisk_PhysicalDisk WHERE Name = '_Total'", &disk); err == nil && len(disk) > 0 {
+		info.DiskQueueLength = disk[0].CurrentDiskQueueLength
+	}
+
+	var memory []win32PerfMemory
+	if err := wmi.Query("SELECT PagesPersec, PageFaultsPersec FROM Win32_PerfFormattedData_PerfOS_Memory", &memory); err == nil && len(memory) > 0 {
+		info.PagesPerSec = memory[0].PagesPersec
+		info.PageFaultsPerSec = memory[0].PageFaultsPersec
+	}
+
+	var paging []win32PerfPagingFile
+	if err := wmi.Query("SELECT PercentUsage FROM Win32_PerfFormattedData_PerfOS_PagingFile WHERE Name = '_Total'", &paging); err == nil && len(paging) > 0 {
+		info.PagingFilePercent = paging[0].PercentUsage
+	}
+
+	// WMI datetime format: yyyymmddHHMMSS.mmmmmm+UUU (offset in minutes).
+	timestamp := since.UTC().Format("20060102150405") + ".000000+000"
+	for _, logName := range eventLogs {
+		var events []win32EventCount
+		query := fmt.Sprintf("SELECT EventType FROM Win32_NTLogEvent WHERE Logfile = '%s' AND (EventType = 1 OR EventType = 2) AND TimeWritten >= '%s'",
+			strings.ReplaceAll(logName, "'", ""), timestamp)
+		if err := wmi.Query(query, &events); err != nil {
+			return nil, fmt.Errorf("failed to query %s event log: %w", logName, err)
+		}
+
+		counts := EventLogCounts{Log: logName}
+		for _, event := range events {
+			switch event.EventType {
+			case 1:
+				counts.Errors++
+			case 2:
+				counts.Warnings++
+			}
+		}
+		info.EventLog = append(info.EventLog, counts)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"sentinel-agent/internal/config"
+)
+
+const (
+	defaultWMINamespace = `root\cimv2`
+	defaultWMITimeout   = 10 * time.Second
+)
+
+// WMIQueryResult holds the instances returned by one configured query.
+// Each row carries the label properties as strings and the metric
+// properties that had a numeric value.
+type WMIQueryResult struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Rows      []WMIRow  `json:"rows,omitempty"`
+}
+
+type WMIRow struct {
+	Labels map[string]string  `json:"labels,omitempty"`
+	Values map[string]float64 `json:"values"`
+}
+
+type wmiQuery struct {
+	cfg      config.WMIQueryConfig
+	schedule schedule
+	timeout  time.Duration
+}
+
+type WMIQueryCollector struct {
+	queries []*wmiQuery
+}
+
+func NewWMIQueryCollector(cfgs []config.WMIQueryConfig) *WMIQueryCollector {
+	c := &WMIQueryCollector{}
+	for _, cfg := range cfgs {
+		if cfg.Namespace == "" {
+			cfg.Namespace = defaultWMINamespace
+		}
+		q := &wmiQuery{
+			cfg:      cfg,
+			schedule: newSchedule(time.Duration(cfg.Interval) * time.Second),
+			timeout:  defaultWMITimeout,
+		}
+		if cfg.Timeout > 0 {
+			q.timeout = time.Duration(cfg.Timeout) * time.Second
+		}
+		c.queries = append(c.queries, q)
+	}
+	return c
+}
+
+// Collect runs the due queries one after another. A query still blocked in
+// WMI from an earlier run fails immediately instead of piling up.
+func (c *WMIQueryCollector) Collect(ctx context.Context) []WMIQueryResult {
+	now := time.Now()
+	var results []WMIQueryResult
+	for _, q := range c.queries {
+		if !q.schedule.due(now) {
+			continue
+		}
+		result := WMIQueryResult{Name: q.cfg.Name, Status: "ok", Timestamp: time.Now().UTC()}
+		properties := append(append([]string{}, q.cfg.Labels...), q.cfg.Metrics...)
+		rows, err := runBlocking(ctx, "wmi "+q.cfg.Name, q.timeout, func() ([]map[string]interface{}, error) {
+			return queryWMI(q.cfg.Namespace, q.cfg.Query, properties)
+		})
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+		}
+		for _, row := range rows {
+			result.Rows = append(result.Rows, q.row(row))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (q *wmiQuery) row(properties map[string]interface{}) WMIRow {
+	row := WMIRow{Values: make(map[string]float64)}
+	for _, name := range q.cfg.Labels {
+		if v, ok := properties[name]; ok && v != nil {
+			if row.Labels == nil {
+				row.Labels = make(map[string]string)
+			}
+			row.Labels[name] = wmiString(v)
+		}
+	}
+	for _, name := range q.cfg.Metrics {
+		if v, ok := wmiNumber(properties[name]); ok {
+			row.Values[name] = v
+		}
+	}
+	return row
+}
+
+func wmiString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	if f, ok := wmiNumber(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
+
+// wmiNumber converts a property value to a float. WMI returns 64-bit
+// counters as strings.
+func wmiNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package collector
+
+import "fmt"
+
+func queryWMI(namespace, query string, properties []string) ([]map[string]interface{}, error) {
+	return nil, fmt.Errorf("WMI queries are only available on Windows")
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// queryWMI runs a WQL query through SWbemServices and returns the named
+// properties of each instance. Unlike wmi.Query it needs no struct type, so
+// queries can come from the configuration.
+func queryWMI(namespace, query string, properties []string) ([]map[string]interface{}, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+		// S_FALSE: COM was already initialized on this thread.
+		if code := err.(*ole.OleError).Code(); code != ole.S_OK && code != 1 {
+			return nil, err
+		}
+	}
+	defer ole.CoUninitialize()
+
+	unknown, err := oleutil.CreateObject("WbemScripting.SWbemLocator")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WMI locator: %w", err)
+	}
+	defer unknown.Release()
+	locator, err := unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return nil, err
+	}
+	defer locator.Release()
+
+	serviceRaw, err := oleutil.CallMethod(locator, "ConnectServer", nil, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", namespace, err)
+	}
+	defer serviceRaw.Clear()
+	service := serviceRaw.ToIDispatch()
+
+	resultRaw, err := oleutil.CallMethod(service, "ExecQuery", query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer resultRaw.Clear()
+
+	var rows []map[string]interface{}
+	err = oleutil.ForEach(resultRaw.ToIDispatch(), func(v *ole.VARIANT) error {
+		item := v.ToIDispatch()
+		defer item.Release()
+		row := make(map[string]interface{}, len(properties))
+		for _, name := range properties {
+			prop, err := oleutil.GetProperty(item, name)
+			if err != nil {
+				continue
+			}
+			row[name] = prop.Value()
+			prop.Clear()
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return rows, nil
+}
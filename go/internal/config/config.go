@@ -1,43 +1,960 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/cron"
 
 	"gopkg.in/yaml.v3"
 )
 
+// EndpointAuto as api_endpoint makes the agent discover the collector via
+// DHCP, DNS-SD or mDNS at startup.
+const EndpointAuto = "auto"
+
 type Config struct {
-	APIEndpoint      string `yaml:"api_endpoint"`
-	OrganizationSlug string `yaml:"organization_slug"`
-	APIKey           string `yaml:"api_key"`
-	Interval         int    `yaml:"interval"`
-	HostIDFile       string `yaml:"host_id_file"`
+	APIEndpoint string `yaml:"api_endpoint"`
+	// APIEndpointHosts pins the hosts a discovered endpoint may be on.
+	APIEndpointHosts []string `yaml:"api_endpoint_hosts"`
+	OrganizationSlug string   `yaml:"organization_slug"`
+	APIKey           string   `yaml:"api_key"`
+	Interval         int      `yaml:"interval"`
+	// MinInterval and MaxInterval bound the interval the backend may ask
+	// for in heartbeat responses.
+	MinInterval     int    `yaml:"min_interval"`
+	MaxInterval     int    `yaml:"max_interval"`
+	HostIDFile      string `yaml:"host_id_file"`
+	EnrollmentToken string `yaml:"enrollment_token"`
+	CredentialsFile string `yaml:"credentials_file"`
+	StateFile       string `yaml:"state_file"`
+	// StateEncrypt encrypts the state file with the host key, like the
+	// spool.
+	StateEncrypt bool `yaml:"state_encrypt"`
+
+	Hostname HostnameConfig `yaml:"hostname"`
+
+	// Environment, Datacenter and Group place the host for the backend,
+	// e.g. to apply per-environment SLOs.
+	Environment string `yaml:"environment"`
+	Datacenter  string `yaml:"datacenter"`
+	Group       string `yaml:"group"`
+
+	QuietHours QuietHoursConfig `yaml:"quiet_hours"`
+	Burst      BurstConfig      `yaml:"burst"`
+	Anomalies  AnomaliesConfig  `yaml:"anomalies"`
+
+	FactsDir      string   `yaml:"facts_dir"`
+	FactsCmd      []string `yaml:"facts_cmd"`
+	FactsInterval int      `yaml:"facts_interval"`
+	Profile       string   `yaml:"profile"`
+
+	HeartbeatPath string            `yaml:"heartbeat_path"`
+	Headers       map[string]string `yaml:"headers"`
+	AWSSigV4      AWSSigV4Config    `yaml:"aws_sigv4"`
+
+	// HTTPTimeout bounds each backend request including the upload, in
+	// seconds; ConnectTimeout and TLSHandshakeTimeout bound its first steps.
+	HTTPTimeout         int `yaml:"http_timeout"`
+	ConnectTimeout      int `yaml:"connect_timeout"`
+	TLSHandshakeTimeout int `yaml:"tls_handshake_timeout"`
+
+	// SourceAddress and SourceInterface pin backend connections to a local
+	// address or network interface.
+	SourceAddress   string `yaml:"source_address"`
+	SourceInterface string `yaml:"source_interface"`
+
+	// APISocket sends all backend requests over a Unix socket, e.g. to a
+	// sidecar proxy. SOCKS5Proxy (socks5://[user:pass@]host:port) routes
+	// them through a SOCKS5 proxy such as Tor or an SSH tunnel.
+	APISocket   string `yaml:"api_socket"`
+	SOCKS5Proxy string `yaml:"socks5_proxy"`
+
+	Resources ResourcesConfig `yaml:"resources"`
+	Spool     SpoolConfig     `yaml:"spool"`
+	SendQueue SendQueueConfig `yaml:"send_queue"`
+	Crypto    CryptoConfig    `yaml:"crypto"`
+
+	Traceroute TracerouteConfig `yaml:"traceroute"`
+	PublicIP   PublicIPConfig   `yaml:"public_ip"`
+	Neighbors  NeighborConfig   `yaml:"neighbors"`
+	VPN        VPNConfig        `yaml:"vpn"`
+	TCPStats   TCPStatsConfig   `yaml:"tcp_stats"`
+
+	ProcessTCP ProcessTCPConfig `yaml:"process_tcp"`
+	TopTalkers TopTalkersConfig `yaml:"top_talkers"`
+
+	Firewall FirewallConfig `yaml:"firewall"`
+	Security SecurityConfig `yaml:"security"`
+
+	Compliance     ComplianceConfig     `yaml:"compliance"`
+	Windows        WindowsConfig        `yaml:"windows"`
+	MacOS          MacOSConfig          `yaml:"macos"`
+	Domain         DomainConfig         `yaml:"domain"`
+	Sessions       SessionsConfig       `yaml:"sessions"`
+	AuthorizedKeys AuthorizedKeysConfig `yaml:"authorized_keys"`
+	LocalUsers     LocalUsersConfig     `yaml:"local_users"`
+	Sysctl         SysctlConfig         `yaml:"sysctl"`
+	WatchFiles     FileWatchConfig      `yaml:"watch_files"`
+	Hardware       HardwareConfig       `yaml:"hardware"`
+
+	Certificates  CertificatesConfig  `yaml:"certificates"`
+	ScheduledJobs ScheduledJobsConfig `yaml:"scheduled_jobs"`
+	KernelEvents  KernelEventsConfig  `yaml:"kernel_events"`
+	Crashes       CrashesConfig       `yaml:"crashes"`
+	Accounting    AccountingConfig    `yaml:"accounting"`
+	Quotas        QuotasConfig        `yaml:"quotas"`
+	DiskUsage     DiskUsageConfig     `yaml:"disk_usage"`
+	NetworkMounts NetworkMountsConfig `yaml:"network_mounts"`
+	LVM           LVMConfig           `yaml:"lvm"`
+	Power         PowerConfig         `yaml:"power"`
+
+	Logs      LogsConfig      `yaml:"logs"`
+	SNMPTraps SNMPTrapsConfig `yaml:"snmp_traps"`
+
+	Apps             []AppConfig      `yaml:"apps"`
+	PrometheusScrape []ScrapeConfig   `yaml:"prometheus_scrape"`
+	WMIQueries       []WMIQueryConfig `yaml:"wmi_queries"`
+
+	Checks    []CheckConfig   `yaml:"checks"`
+	Discovery DiscoveryConfig `yaml:"discovery"`
+	Docker    DockerConfig    `yaml:"docker"`
+	Cluster   ClusterConfig   `yaml:"cluster"`
+
+	Tasks         TasksConfig         `yaml:"tasks"`
+	ScriptLibrary ScriptLibraryConfig `yaml:"script_library"`
+	Cleanup       CleanupConfig       `yaml:"cleanup"`
+	Remediation   RemediationConfig   `yaml:"remediation"`
+
+	Status StatusConfig `yaml:"status"`
+	Sinks  []SinkConfig `yaml:"sinks"`
+
+	// Organizations also receive every heartbeat, e.g. the customer's
+	// organization on hosts an MSP monitors in its own.
+	Organizations []OrganizationConfig `yaml:"organizations"`
+}
+
+type HostnameConfig struct {
+	Override string `yaml:"override"`
+	Format   string `yaml:"format"`
+}
+
+type AWSSigV4Config struct {
+	Enabled bool   `yaml:"enabled"`
+	Region  string `yaml:"region"`
+	Service string `yaml:"service"`
+}
+
+type ResourcesConfig struct {
+	MaxProcs        int    `yaml:"max_procs"`
+	MaxMemoryMB     int    `yaml:"max_memory_mb"`
+	Cgroup          string `yaml:"cgroup"`
+	CPUQuotaPercent int    `yaml:"cpu_quota_percent"`
+	Nice            int    `yaml:"nice"`
+	IOClass         string `yaml:"io_class"`
+	IOPriority      int    `yaml:"io_priority"`
+	PriorityClass   string `yaml:"priority_class"`
+}
+
+type SpoolConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Dir        string `yaml:"dir"`
+	MaxEntries int    `yaml:"max_entries"`
+	Encrypt    bool   `yaml:"encrypt"`
+}
+
+// SendQueueConfig bounds the heartbeats waiting to be sent and the number of
+// concurrent sends.
+type SendQueueConfig struct {
+	Size    int `yaml:"size"`
+	Workers int `yaml:"workers"`
+}
+
+// QuietHoursConfig marks heartbeats sent during any of the windows, e.g.
+// nightly maintenance. Each window opens when its cron schedule fires and
+// lasts Duration minutes. Timezone defaults to the host's.
+type QuietHoursConfig struct {
+	Timezone string              `yaml:"timezone"`
+	Windows  []QuietWindowConfig `yaml:"windows"`
+}
+
+type QuietWindowConfig struct {
+	Schedule string `yaml:"schedule"`
+	Duration int    `yaml:"duration"`
+}
+
+// BurstConfig switches to Interval seconds and adds the full process list
+// for Duration minutes whenever CPU, memory or disk usage reaches its
+// threshold in percent (0 disables a threshold).
+type BurstConfig struct {
+	Enabled       bool    `yaml:"enabled"`
+	CPUPercent    float64 `yaml:"cpu_percent"`
+	MemoryPercent float64 `yaml:"memory_percent"`
+	DiskPercent   float64 `yaml:"disk_percent"`
+	Interval      int     `yaml:"interval"`
+	Duration      int     `yaml:"duration"`
+}
+
+// AnomaliesConfig flags headline metrics more than Threshold standard
+// deviations from their rolling baseline, an exponentially weighted
+// average with smoothing factor Alpha, once it has Warmup samples.
+type AnomaliesConfig struct {
+	Enabled   bool    `yaml:"enabled"`
+	Alpha     float64 `yaml:"alpha"`
+	Threshold float64 `yaml:"threshold"`
+	Warmup    int     `yaml:"warmup"`
+}
+
+// CryptoConfig.KeyFile is a secret for local encryption keys, kept outside
+// the agent's data directories.
+type CryptoConfig struct {
+	FIPS    bool   `yaml:"fips"`
+	Hash    string `yaml:"hash"`
+	KeyFile string `yaml:"key_file"`
+}
+
+type TracerouteConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Targets  []string `yaml:"targets"`
+	Interval int      `yaml:"interval"`
+	MaxHops  int      `yaml:"max_hops"`
+}
+
+type PublicIPConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Endpoint string `yaml:"endpoint"`
+	Interval int    `yaml:"interval"`
+}
+
+type NeighborConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	Interval   int  `yaml:"interval"`
+	MaxEntries int  `yaml:"max_entries"`
+}
+
+type VPNConfig struct {
+	Enabled            bool           `yaml:"enabled"`
+	HandshakeThreshold int            `yaml:"handshake_threshold"`
+	Tunnels            []TunnelConfig `yaml:"tunnels"`
+}
+
+type TCPStatsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type ProcessTCPConfig struct {
+	Enabled bool `yaml:"enabled"`
+	TopN    int  `yaml:"top_n"`
+}
+
+type TopTalkersConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	MaxEntries      int  `yaml:"max_entries"`
+	Anonymize       bool `yaml:"anonymize"`
+	IncludeLoopback bool `yaml:"include_loopback"`
+}
+
+type FirewallConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	Interval      int  `yaml:"interval"`
+	ExpectEnabled bool `yaml:"expect_enabled"`
+}
+
+// CertificatesConfig lists files and directories to scan for certificates.
+// Passwords are tried for PKCS#12 files after the empty password.
+type CertificatesConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	Paths     []string `yaml:"paths"`
+	Interval  int      `yaml:"interval"`
+	WarnDays  int      `yaml:"warn_days"`
+	Passwords []string `yaml:"passwords"`
+}
+
+type ScheduledJobsConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Interval int  `yaml:"interval"`
+}
+
+type KernelEventsConfig struct {
+	Enabled bool `yaml:"enabled"`
 }
 
-func Load(path string) (*Config, error) {
+// CrashesConfig watches systemd-coredump and Dirs for new crash reports.
+type CrashesConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Dirs     []string `yaml:"dirs"`
+	Interval int      `yaml:"interval"`
+}
+
+// AccountingConfig reports the TopN users and cgroups by CPU, walking
+// cgroups down to CgroupDepth levels (slices, then their services).
+type AccountingConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	TopN        int  `yaml:"top_n"`
+	CgroupDepth int  `yaml:"cgroup_depth"`
+}
+
+type QuotasConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	Interval    int  `yaml:"interval"`
+	WarnPercent int  `yaml:"warn_percent"`
+}
+
+// DiskUsageConfig reports the TopN largest directories, down to MaxDepth
+// levels below each root, every Interval seconds and as soon as the main
+// disk's usage reaches TriggerPercent (0 disables the trigger).
+type DiskUsageConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Roots          []string `yaml:"roots"`
+	TopN           int      `yaml:"top_n"`
+	MaxDepth       int      `yaml:"max_depth"`
+	Interval       int      `yaml:"interval"`
+	TriggerPercent float64  `yaml:"trigger_percent"`
+}
+
+// NetworkMountsConfig checks NFS, CIFS and other network filesystems.
+// WriteTest creates and removes a temporary file on each writable mount.
+type NetworkMountsConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Timeout   int  `yaml:"timeout"`
+	WriteTest bool `yaml:"write_test"`
+}
+
+type LVMConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	Interval    int  `yaml:"interval"`
+	WarnPercent int  `yaml:"warn_percent"`
+}
+
+type PowerConfig struct {
+	Battery         bool        `yaml:"battery"`
+	UPS             []UPSConfig `yaml:"ups"`
+	UPSPollInterval int         `yaml:"ups_poll_interval"`
+	Energy          bool        `yaml:"energy"`
+}
+
+// UPSConfig is a UPS read through its monitoring daemon. Type is "nut"
+// (Target is ups@host for upsc) or "apcupsd" (Target is an optional
+// host:port for apcaccess).
+type UPSConfig struct {
+	Name   string `yaml:"name"`
+	Type   string `yaml:"type"`
+	Target string `yaml:"target"`
+}
+
+type SecurityConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval int           `yaml:"interval"`
+	Sandbox  SandboxConfig `yaml:"sandbox"`
+}
+
+type SandboxConfig struct {
+	User          string   `yaml:"user"`
+	Landlock      bool     `yaml:"landlock"`
+	Seccomp       bool     `yaml:"seccomp"`
+	WritablePaths []string `yaml:"writable_paths"`
+}
+
+type ComplianceConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Interval       int      `yaml:"interval"`
+	RuleFiles      []string `yaml:"rule_files"`
+	DisableBuiltin []string `yaml:"disable_builtin"`
+}
+
+type WindowsConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	EventLogs []string `yaml:"event_logs"`
+}
+
+// DomainConfig reports Active Directory membership. PolicyMaxAge is in
+// hours; 0 disables the stale policy event.
+type DomainConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	Interval     int  `yaml:"interval"`
+	PolicyMaxAge int  `yaml:"policy_max_age"`
+}
+
+type SessionsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type AuthorizedKeysConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Interval int  `yaml:"interval"`
+}
+
+type LocalUsersConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Interval int  `yaml:"interval"`
+}
+
+// SysctlConfig lists the kernel parameters to snapshot; glob patterns
+// such as "net.ipv4.tcp_*" are allowed.
+type SysctlConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Interval int      `yaml:"interval"`
+	Keys     []string `yaml:"keys"`
+}
+
+// FileWatchConfig lists files (glob patterns) whose changes are reported
+// with the changed lines. Text after a Redact regex match is never sent.
+type FileWatchConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Interval     int      `yaml:"interval"`
+	Paths        []string `yaml:"paths"`
+	Redact       []string `yaml:"redact"`
+	MaxDiffLines int      `yaml:"max_diff_lines"`
+}
+
+// HardwareConfig enables the hardware inventory (DMI, memory modules,
+// disks and network adapters), sent once per Interval seconds.
+type HardwareConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Interval int  `yaml:"interval"`
+}
+
+// StatusConfig serves the agent's own pipeline counters on Listen, as
+// Prometheus metrics on /metrics and as JSON on /status.
+type StatusConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"`
+}
+
+// SinkConfig sends the listed heartbeat sections (by JSON name, e.g.
+// "events" or "security") to another backend with its own credentials.
+// Exclusive sections are no longer sent to the main backend.
+type SinkConfig struct {
+	Name             string            `yaml:"name"`
+	APIEndpoint      string            `yaml:"api_endpoint"`
+	OrganizationSlug string            `yaml:"organization_slug"`
+	APIKey           string            `yaml:"api_key"`
+	HeartbeatPath    string            `yaml:"heartbeat_path"`
+	Headers          map[string]string `yaml:"headers"`
+	Sections         []string          `yaml:"sections"`
+	Exclusive        bool              `yaml:"exclusive"`
+}
+
+// OrganizationConfig is an additional organization; APIEndpoint defaults
+// to the main api_endpoint. Headers replace the main headers, which may
+// carry the main organization's credentials.
+type OrganizationConfig struct {
+	OrganizationSlug string            `yaml:"organization_slug"`
+	APIKey           string            `yaml:"api_key"`
+	APIEndpoint      string            `yaml:"api_endpoint"`
+	Headers          map[string]string `yaml:"headers"`
+	Sections         []string          `yaml:"sections"`
+}
+
+type MacOSConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type TasksConfig struct {
+	Enabled      bool              `yaml:"enabled"`
+	PublicKey    string            `yaml:"public_key"`
+	Timeout      int               `yaml:"timeout"`
+	Scripts      map[string]string `yaml:"scripts"`
+	Services     []string          `yaml:"services"`
+	FetchDirs    []string          `yaml:"fetch_dirs"`
+	AllowLibrary bool              `yaml:"allow_library"`
+}
+
+// CleanupConfig removes old files matching its rules every Interval
+// seconds (0: only when requested with a cleanup task). With DryRun, the
+// default, runs only report what they would remove.
+type CleanupConfig struct {
+	Enabled  bool                `yaml:"enabled"`
+	Interval int                 `yaml:"interval"`
+	DryRun   bool                `yaml:"dry_run"`
+	Rules    []CleanupRuleConfig `yaml:"rules"`
+}
+
+// CleanupRuleConfig matches files below Paths older than MaxAge hours whose
+// names match Pattern (all files when empty).
+type CleanupRuleConfig struct {
+	Name    string   `yaml:"name"`
+	Paths   []string `yaml:"paths"`
+	MaxAge  int      `yaml:"max_age"`
+	Pattern string   `yaml:"pattern"`
+}
+
+// RemediationConfig restarts systemd units when local checks go down. The
+// rules are the allowlist: nothing else is ever restarted.
+type RemediationConfig struct {
+	Enabled bool                    `yaml:"enabled"`
+	Rules   []RemediationRuleConfig `yaml:"rules"`
+}
+
+// RemediationRuleConfig restarts RestartService whenever Check is down, at
+// most MaxPerHour times within any hour (default 3) and at least Cooldown
+// seconds apart (default 120).
+type RemediationRuleConfig struct {
+	Check          string `yaml:"check"`
+	RestartService string `yaml:"restart_service"`
+	MaxPerHour     int    `yaml:"max_per_hour"`
+	Cooldown       int    `yaml:"cooldown"`
+}
+
+type ScriptLibraryConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	Dir          string `yaml:"dir"`
+	ManifestPath string `yaml:"manifest_path"`
+	PublicKey    string `yaml:"public_key"`
+	Interval     int    `yaml:"interval"`
+}
+
+// CheckConfig defines an active check. Target is host:port for tcp, redis
+// and grpc, a URL for http, a socket path for docker and a path or URL for
+// freshness. Transactions run
+// Steps in order instead of probing a single target.
+type CheckConfig struct {
+	Name         string           `yaml:"name"`
+	Type         string           `yaml:"type"`
+	Target       string           `yaml:"target"`
+	Interval     int              `yaml:"interval"`
+	Timeout      int              `yaml:"timeout"`
+	ExpectStatus int              `yaml:"expect_status"`
+	Steps        []HTTPStepConfig `yaml:"steps"`
+
+	// FailureThreshold consecutive failures mark the check down (default
+	// 3); FlapThreshold status changes within the last 20 runs mark it as
+	// flapping (default 5).
+	FailureThreshold int `yaml:"failure_threshold"`
+	FlapThreshold    int `yaml:"flap_threshold"`
+
+	// Samples is the number of requests per run of an http check, used
+	// for latency percentiles.
+	Samples int `yaml:"samples"`
+
+	// MaxAge is the allowed age in seconds for freshness checks.
+	MaxAge int `yaml:"max_age"`
+
+	// Service and TLS apply to grpc checks.
+	Service string `yaml:"service"`
+	TLS     bool   `yaml:"tls"`
+
+	// Source records how an automatically created check was found.
+	Source string `yaml:"-"`
+}
+
+// LogsConfig configures log forwarding. Lines are sent in gzip-compressed
+// batches of up to BatchSize lines, at least every FlushInterval seconds;
+// up to BufferBatches batches are kept in BufferDir while the backend is
+// unreachable.
+type LogsConfig struct {
+	Enabled       bool              `yaml:"enabled"`
+	Path          string            `yaml:"path"`
+	Sources       []LogSourceConfig `yaml:"sources"`
+	BatchSize     int               `yaml:"batch_size"`
+	FlushInterval int               `yaml:"flush_interval"`
+	BufferDir     string            `yaml:"buffer_dir"`
+	BufferBatches int               `yaml:"buffer_batches"`
+	Encrypt       bool              `yaml:"encrypt"`
+}
+
+// LogSourceConfig is a set of files (glob patterns), journald units or
+// syslog listeners ("udp://:514", "tcp://:514"). Lines must match one
+// Include regex, if any, and no Exclude regex.
+type LogSourceConfig struct {
+	Name    string   `yaml:"name"`
+	Paths   []string `yaml:"paths"`
+	Units   []string `yaml:"units"`
+	Listen  []string `yaml:"listen"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// Journald only. Priority is the least severe level followed
+	// (emerg..debug or 0-7), Fields the structured fields kept with each
+	// entry ("*" for all), and Events sends matched entries as agent
+	// events instead of log lines.
+	Priority string   `yaml:"priority"`
+	Fields   []string `yaml:"fields"`
+	Events   bool     `yaml:"events"`
+}
+
+var journalPriorities = map[string]bool{
+	"emerg": true, "alert": true, "crit": true, "err": true,
+	"warning": true, "notice": true, "info": true, "debug": true,
+	"0": true, "1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true,
+}
+
+// WMIQueryConfig is a WQL query run on Windows. The numeric Metrics
+// properties of each returned instance are reported, identified by the
+// Labels properties.
+type WMIQueryConfig struct {
+	Name      string   `yaml:"name"`
+	Namespace string   `yaml:"namespace"`
+	Query     string   `yaml:"query"`
+	Metrics   []string `yaml:"metrics"`
+	Labels    []string `yaml:"labels"`
+	Interval  int      `yaml:"interval"`
+	Timeout   int      `yaml:"timeout"`
+}
+
+// SNMPTrapsConfig receives SNMPv1/v2c traps and informs as events. An
+// empty Communities accepts any community. Devices names sender addresses;
+// Severity maps trap OIDs, OID prefixes or standard trap names to event
+// severities. RateLimit is the most traps per device and minute.
+type SNMPTrapsConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Listen      string            `yaml:"listen"`
+	Communities []string          `yaml:"communities"`
+	Devices     map[string]string `yaml:"devices"`
+	Severity    map[string]string `yaml:"severity"`
+	RateLimit   int               `yaml:"rate_limit"`
+}
+
+// AppConfig is an application instance to collect metrics from. Target is
+// a URL, "unix:/path" or "host:port" depending on the type.
+type AppConfig struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Target   string `yaml:"target"`
+	Interval int    `yaml:"interval"`
+	Timeout  int    `yaml:"timeout"`
+
+	// Username and Password are sent as HTTP basic auth.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// StatusPath is pm.status_path for phpfpm targets that are FastCGI
+	// sockets (default /status).
+	StatusPath string `yaml:"status_path"`
+}
+
+// ScrapeConfig is a Prometheus endpoint whose series matching Metrics
+// (names or glob patterns) are forwarded in the heartbeat. Labels are added
+// to every forwarded series.
+type ScrapeConfig struct {
+	Name      string            `yaml:"name"`
+	URL       string            `yaml:"url"`
+	Metrics   []string          `yaml:"metrics"`
+	Labels    map[string]string `yaml:"labels"`
+	Interval  int               `yaml:"interval"`
+	Timeout   int               `yaml:"timeout"`
+	MaxSeries int               `yaml:"max_series"`
+	Username  string            `yaml:"username"`
+	Password  string            `yaml:"password"`
+}
+
+// HTTPStepConfig is one request of a transaction check. ${name} in the URL,
+// headers and body is replaced by variables extracted in earlier steps;
+// each Extract entry maps a variable to a regex with one capture group.
+type HTTPStepConfig struct {
+	Name         string            `yaml:"name"`
+	Method       string            `yaml:"method"`
+	URL          string            `yaml:"url"`
+	Headers      map[string]string `yaml:"headers"`
+	Body         string            `yaml:"body"`
+	ExpectStatus int               `yaml:"expect_status"`
+	BodyContains string            `yaml:"body_contains"`
+	BodyRegex    string            `yaml:"body_regex"`
+	MaxLatencyMs int               `yaml:"max_latency_ms"`
+	Extract      map[string]string `yaml:"extract"`
+}
+
+type DiscoveryConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Disable []string `yaml:"disable"`
+}
+
+// DockerConfig enables container monitoring. Runtime is docker, podman or
+// cri (containerd or CRI-O through crictl); Socket defaults to the
+// runtime's usual socket. With label_checks, running containers can request
+// checks through sentinel.check.* labels. Image and volume inventory is
+// sent every DiskUsageInterval seconds; 0 disables it. A container that
+// restarts more than RestartThreshold times within RestartWindow seconds
+// is reported as a restart loop.
+type DockerConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	Runtime           string `yaml:"runtime"`
+	Socket            string `yaml:"socket"`
+	Stats             bool   `yaml:"stats"`
+	LabelChecks       bool   `yaml:"label_checks"`
+	DiskUsageInterval int    `yaml:"disk_usage_interval"`
+	RestartThreshold  int    `yaml:"restart_threshold"`
+	RestartWindow     int    `yaml:"restart_window"`
+
+	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+}
+
+// KubernetesConfig resolves containers to their Deployment, StatefulSet or
+// other workload through the API server, using the pod's service account.
+// The pod list for NodeName (default $NODE_NAME, then the hostname) is
+// refreshed every Interval seconds and whenever an unknown pod appears.
+type KubernetesConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	NodeName string `yaml:"node_name"`
+	Interval int    `yaml:"interval"`
+}
+
+// ClusterConfig groups agents that share cluster-wide checks. The backend
+// elects one member as leader and only the leader runs Checks.
+type ClusterConfig struct {
+	Name   string        `yaml:"name"`
+	Checks []CheckConfig `yaml:"checks"`
+}
+
+type TunnelConfig struct {
+	Name      string `yaml:"name"`
+	Interface string `yaml:"interface"`
+	Target    string `yaml:"target"`
+}
+
+// Options adjust how the config file is read.
+type Options struct {
+	// Lenient ignores keys the agent does not know instead of rejecting
+	// them; they are usually typos such as "api_endpont".
+	Lenient bool
+}
+
+func Load(path string, opts Options) (*Config, error) {
+	cfg, err := Parse(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// defaultConfig is the configuration before the config file is applied.
+func defaultConfig() *Config {
+	return &Config{
+		Interval:    10,
+		MinInterval: 5,
+		MaxInterval: 3600,
+		Burst: BurstConfig{
+			CPUPercent:    90,
+			MemoryPercent: 90,
+			Interval:      2,
+			Duration:      5,
+		},
+		Anomalies: AnomaliesConfig{
+			Alpha:     0.05,
+			Threshold: 3,
+			Warmup:    60,
+		},
+		HostIDFile:      "/var/lib/sentinel-agent/host-id",
+		CredentialsFile: "/var/lib/sentinel-agent/credentials.yaml",
+		StateFile:       "/var/lib/sentinel-agent/state.json",
+		StateEncrypt:    true,
+		HeartbeatPath:   "/api/v2/heartbeat",
+		FactsInterval:   300,
+
+		HTTPTimeout:         30,
+		ConnectTimeout:      10,
+		TLSHandshakeTimeout: 10,
+
+		Hostname: HostnameConfig{
+			Format: "system",
+		},
+		AWSSigV4: AWSSigV4Config{
+			Service: "execute-api",
+		},
+		Crypto: CryptoConfig{
+			Hash: "sha256",
+		},
+		Spool: SpoolConfig{
+			Dir:        "/var/lib/sentinel-agent/spool",
+			MaxEntries: 1000,
+			Encrypt:    true,
+		},
+		SendQueue: SendQueueConfig{
+			Size:    10,
+			Workers: 2,
+		},
+		Traceroute: TracerouteConfig{
+			Interval: 300,
+			MaxHops:  30,
+		},
+		PublicIP: PublicIPConfig{
+			Endpoint: "https://ipinfo.io/json",
+			Interval: 900,
+		},
+		Neighbors: NeighborConfig{
+			Interval:   600,
+			MaxEntries: 1024,
+		},
+		VPN: VPNConfig{
+			HandshakeThreshold: 180,
+		},
+		ProcessTCP: ProcessTCPConfig{
+			TopN: 10,
+		},
+		TopTalkers: TopTalkersConfig{
+			MaxEntries: 20,
+		},
+		Firewall: FirewallConfig{
+			Interval: 300,
+		},
+		Security: SecurityConfig{
+			Interval: 300,
+		},
+		Compliance: ComplianceConfig{
+			Interval: 3600,
+		},
+		Certificates: CertificatesConfig{
+			Interval: 3600,
+			WarnDays: 30,
+		},
+		ScheduledJobs: ScheduledJobsConfig{
+			Interval: 300,
+		},
+		Crashes: CrashesConfig{
+			Dirs:     []string{"/var/crash"},
+			Interval: 60,
+		},
+		Accounting: AccountingConfig{
+			TopN:        20,
+			CgroupDepth: 2,
+		},
+		Quotas: QuotasConfig{
+			Interval:    600,
+			WarnPercent: 90,
+		},
+		Cleanup: CleanupConfig{
+			DryRun: true,
+		},
+		DiskUsage: DiskUsageConfig{
+			TopN:           10,
+			MaxDepth:       3,
+			Interval:       3600,
+			TriggerPercent: 90,
+		},
+		NetworkMounts: NetworkMountsConfig{
+			Timeout: 5,
+		},
+		LVM: LVMConfig{
+			Interval:    300,
+			WarnPercent: 80,
+		},
+		Power: PowerConfig{
+			UPSPollInterval: 10,
+		},
+		Logs: LogsConfig{
+			Path:          "/api/v2/logs",
+			BatchSize:     500,
+			FlushInterval: 5,
+			BufferDir:     "/var/lib/sentinel-agent/logs",
+			BufferBatches: 1000,
+		},
+		SNMPTraps: SNMPTrapsConfig{
+			Listen:    ":162",
+			RateLimit: 60,
+		},
+		Discovery: DiscoveryConfig{
+			Enabled: true,
+		},
+		Docker: DockerConfig{
+			Runtime:           "docker",
+			Stats:             true,
+			LabelChecks:       true,
+			DiskUsageInterval: 3600,
+			RestartThreshold:  5,
+			RestartWindow:     600,
+			Kubernetes: KubernetesConfig{
+				Interval: 300,
+			},
+		},
+		Windows: WindowsConfig{
+			EventLogs: []string{"System", "Application"},
+		},
+		AuthorizedKeys: AuthorizedKeysConfig{
+			Interval: 3600,
+		},
+		LocalUsers: LocalUsersConfig{
+			Interval: 86400,
+		},
+		Sysctl: SysctlConfig{
+			Interval: 300,
+			Keys: []string{
+				"vm.swappiness",
+				"vm.dirty_ratio",
+				"vm.dirty_background_ratio",
+				"vm.overcommit_memory",
+				"vm.max_map_count",
+				"net.core.somaxconn",
+				"net.core.rmem_max",
+				"net.core.wmem_max",
+				"net.ipv4.ip_forward",
+				"net.ipv4.ip_local_port_range",
+				"net.ipv4.tcp_max_syn_backlog",
+				"fs.file-max",
+				"kernel.pid_max",
+			},
+		},
+		WatchFiles: FileWatchConfig{
+			Interval: 300,
+			Redact: []string{
+				`(?i)(password|passwd|passphrase|secret|token|api[_-]?key|private[_-]?key|credentials?)[^=:]*[=:]\s*`,
+			},
+			MaxDiffLines: 200,
+		},
+		Status: StatusConfig{
+			Listen: "127.0.0.1:9465",
+		},
+		Hardware: HardwareConfig{
+			Interval: 86400,
+		},
+		Domain: DomainConfig{
+			Interval:     900,
+			PolicyMaxAge: 24,
+		},
+		Tasks: TasksConfig{
+			Timeout: 300,
+		},
+		ScriptLibrary: ScriptLibraryConfig{
+			Dir:          "/var/lib/sentinel-agent/scripts",
+			ManifestPath: "/api/v2/scripts/manifest",
+			Interval:     3600,
+		},
+	}
+}
+
+// Parse reads the config file and applies defaults, enrollment credentials
+// and the profile without validating the result.
+func Parse(path string, opts Options) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
-
-	cfg := &Config{
-		Interval:   10,
-		HostIDFile: "/var/lib/sentinel-agent/host-id",
+	if data, err = render(data); err != nil {
+		return nil, err
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+	cfg := defaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(!opts.Lenient)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		if !opts.Lenient && strings.Contains(err.Error(), "not found in type") {
+			return nil, fmt.Errorf("failed to parse config file: %w (run with -lenient to ignore unknown keys)", err)
+		}
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	if cfg.APIEndpoint == "" {
-		return nil, fmt.Errorf("api_endpoint is required")
+	if cfg.ScriptLibrary.PublicKey == "" {
+		cfg.ScriptLibrary.PublicKey = cfg.Tasks.PublicKey
 	}
 
-	if cfg.OrganizationSlug == "" {
-		return nil, fmt.Errorf("organization_slug is required")
+	if err := cfg.loadCredentials(); err != nil {
+		return nil, err
 	}
 
+	cfg.applyProfile()
+
 	return cfg, nil
 }
 
@@ -45,11 +962,448 @@ func (c *Config) Validate() error {
 	if c.APIEndpoint == "" {
 		return fmt.Errorf("api_endpoint is required")
 	}
-	if c.OrganizationSlug == "" {
-		return fmt.Errorf("organization_slug is required")
+	if c.OrganizationSlug == "" && c.EnrollmentToken == "" {
+		return fmt.Errorf("organization_slug (or enrollment_token) is required")
 	}
 	if c.Interval < 1 {
 		return fmt.Errorf("interval must be at least 1 second")
 	}
+	if c.MinInterval < 1 || c.MaxInterval < c.MinInterval {
+		return fmt.Errorf("min_interval must be at least 1 second and not above max_interval")
+	}
+	switch c.Hostname.Format {
+	case "system", "short", "fqdn":
+	default:
+		return fmt.Errorf("hostname.format must be system, short or fqdn")
+	}
+	if !strings.HasPrefix(c.HeartbeatPath, "/") {
+		return fmt.Errorf("heartbeat_path must start with /")
+	}
+	for name := range c.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	if c.HTTPTimeout < 1 || c.ConnectTimeout < 1 || c.TLSHandshakeTimeout < 1 {
+		return fmt.Errorf("http_timeout, connect_timeout and tls_handshake_timeout must be at least 1 second")
+	}
+	if c.SourceAddress != "" && net.ParseIP(c.SourceAddress) == nil {
+		return fmt.Errorf("source_address must be an IP address")
+	}
+	if c.APISocket != "" && (c.SOCKS5Proxy != "" || c.SourceAddress != "" || c.SourceInterface != "") {
+		return fmt.Errorf("api_socket cannot be combined with socks5_proxy, source_address or source_interface")
+	}
+	if c.SOCKS5Proxy != "" {
+		u, err := url.Parse(c.SOCKS5Proxy)
+		if err != nil || u.Scheme != "socks5" || u.Host == "" {
+			return fmt.Errorf("socks5_proxy must be a socks5://host:port URL")
+		}
+	}
+	if c.Profile != "" && c.Profile != ProfileStandard && c.Profile != ProfileMinimal {
+		return fmt.Errorf("profile must be %q or %q", ProfileStandard, ProfileMinimal)
+	}
+	if c.Resources.MaxProcs < 0 || c.Resources.MaxMemoryMB < 0 {
+		return fmt.Errorf("resources limits must not be negative")
+	}
+	if c.Resources.CPUQuotaPercent < 0 || c.Resources.CPUQuotaPercent > 100*runtime.NumCPU() {
+		return fmt.Errorf("resources.cpu_quota_percent must be between 0 and %d", 100*runtime.NumCPU())
+	}
+	if c.Resources.Nice < -20 || c.Resources.Nice > 19 {
+		return fmt.Errorf("resources.nice must be between -20 and 19")
+	}
+	switch c.Resources.IOClass {
+	case "", "best-effort", "idle":
+	default:
+		return fmt.Errorf("resources.io_class must be \"best-effort\" or \"idle\"")
+	}
+	if c.Resources.IOPriority < 0 || c.Resources.IOPriority > 7 {
+		return fmt.Errorf("resources.io_priority must be between 0 and 7")
+	}
+	switch c.Resources.PriorityClass {
+	case "", "idle", "below_normal", "normal", "above_normal", "high":
+	default:
+		return fmt.Errorf("resources.priority_class %q is not valid", c.Resources.PriorityClass)
+	}
+	switch c.Crypto.Hash {
+	case "sha256", "sha384", "sha512":
+	default:
+		return fmt.Errorf("crypto.hash must be sha256, sha384 or sha512")
+	}
+	if c.Crypto.KeyFile != "" {
+		if err := c.validateKeyFile(); err != nil {
+			return err
+		}
+	}
+	if c.ProcessTCP.Enabled && c.ProcessTCP.TopN < 1 {
+		return fmt.Errorf("process_tcp.top_n must be at least 1")
+	}
+	if c.TopTalkers.Enabled && c.TopTalkers.MaxEntries < 1 {
+		return fmt.Errorf("top_talkers.max_entries must be at least 1")
+	}
+	if c.Spool.Enabled && c.Spool.MaxEntries < 1 {
+		return fmt.Errorf("spool.max_entries must be at least 1")
+	}
+	if c.SendQueue.Size < 1 || c.SendQueue.Workers < 1 {
+		return fmt.Errorf("send_queue.size and send_queue.workers must be at least 1")
+	}
+	if _, err := time.LoadLocation(c.QuietHours.Timezone); err != nil {
+		return fmt.Errorf("quiet_hours.timezone: %w", err)
+	}
+	if c.Burst.Enabled {
+		if c.Burst.Interval < 1 || c.Burst.Duration < 1 {
+			return fmt.Errorf("burst.interval and burst.duration must be at least 1")
+		}
+		thresholds := []float64{c.Burst.CPUPercent, c.Burst.MemoryPercent, c.Burst.DiskPercent}
+		if slices.Max(thresholds) <= 0 || slices.Min(thresholds) < 0 || slices.Max(thresholds) > 100 {
+			return fmt.Errorf("burst thresholds must be between 0 and 100, and at least one set")
+		}
+	}
+	if c.DiskUsage.Enabled {
+		if c.DiskUsage.TopN < 1 || c.DiskUsage.MaxDepth < 1 || c.DiskUsage.Interval < 1 {
+			return fmt.Errorf("disk_usage.top_n, disk_usage.max_depth and disk_usage.interval must be at least 1")
+		}
+		if c.DiskUsage.TriggerPercent < 0 || c.DiskUsage.TriggerPercent > 100 {
+			return fmt.Errorf("disk_usage.trigger_percent must be between 0 and 100")
+		}
+	}
+	if c.Anomalies.Enabled {
+		if c.Anomalies.Alpha <= 0 || c.Anomalies.Alpha >= 1 {
+			return fmt.Errorf("anomalies.alpha must be between 0 and 1")
+		}
+		if c.Anomalies.Threshold <= 0 || c.Anomalies.Warmup < 0 {
+			return fmt.Errorf("anomalies.threshold must be positive and anomalies.warmup not negative")
+		}
+	}
+	for _, window := range c.QuietHours.Windows {
+		if _, err := cron.Parse(window.Schedule); err != nil {
+			return fmt.Errorf("quiet_hours.windows: %w", err)
+		}
+		if window.Duration < 1 || window.Duration > 7*24*60 {
+			return fmt.Errorf("quiet_hours.windows: duration must be between 1 and 10080 minutes")
+		}
+	}
+	if c.Traceroute.Enabled {
+		if len(c.Traceroute.Targets) == 0 {
+			return fmt.Errorf("traceroute.targets is required when traceroute is enabled")
+		}
+		if c.Traceroute.MaxHops < 1 || c.Traceroute.MaxHops > 64 {
+			return fmt.Errorf("traceroute.max_hops must be between 1 and 64")
+		}
+	}
+	if c.PublicIP.Enabled && c.PublicIP.Endpoint == "" {
+		return fmt.Errorf("public_ip.endpoint is required when public_ip is enabled")
+	}
+	for _, tunnel := range c.VPN.Tunnels {
+		if tunnel.Name == "" {
+			return fmt.Errorf("vpn.tunnels entries require a name")
+		}
+		if tunnel.Interface == "" && tunnel.Target == "" {
+			return fmt.Errorf("vpn tunnel %q needs an interface or a target", tunnel.Name)
+		}
+	}
+	if err := validateChecks("checks", c.Checks); err != nil {
+		return err
+	}
+	if len(c.Cluster.Checks) > 0 && c.Cluster.Name == "" {
+		return fmt.Errorf("cluster.name is required for cluster checks")
+	}
+	if err := validateChecks("cluster.checks", c.Cluster.Checks); err != nil {
+		return err
+	}
+	if c.SNMPTraps.Enabled {
+		if c.SNMPTraps.RateLimit < 1 {
+			return fmt.Errorf("snmp_traps.rate_limit must be at least 1")
+		}
+		for oid, severity := range c.SNMPTraps.Severity {
+			switch severity {
+			case "info", "warning", "critical":
+			default:
+				return fmt.Errorf("snmp_traps.severity: invalid severity %q for %s", severity, oid)
+			}
+		}
+	}
+	if err := c.Logs.validate(); err != nil {
+		return err
+	}
+	if err := validateApps(c.Apps); err != nil {
+		return err
+	}
+	for _, scrape := range c.PrometheusScrape {
+		if scrape.Name == "" || scrape.URL == "" {
+			return fmt.Errorf("prometheus_scrape entries require a name and url")
+		}
+		if len(scrape.Metrics) == 0 {
+			return fmt.Errorf("prometheus_scrape %q: metrics allowlist is required", scrape.Name)
+		}
+	}
+	if c.WatchFiles.Enabled {
+		if len(c.WatchFiles.Paths) == 0 {
+			return fmt.Errorf("watch_files.paths is required when watch_files is enabled")
+		}
+		for _, pattern := range c.WatchFiles.Redact {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("watch_files.redact: invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	for _, q := range c.WMIQueries {
+		if q.Name == "" || q.Query == "" {
+			return fmt.Errorf("wmi_queries entries require a name and query")
+		}
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(q.Query)), "SELECT ") {
+			return fmt.Errorf("wmi_queries %q: query must be a WQL SELECT", q.Name)
+		}
+		if len(q.Metrics) == 0 {
+			return fmt.Errorf("wmi_queries %q: metrics is required", q.Name)
+		}
+	}
+	if c.Certificates.Enabled && len(c.Certificates.Paths) == 0 {
+		return fmt.Errorf("certificates.paths is required when certificates is enabled")
+	}
+	if c.NetworkMounts.Enabled && c.NetworkMounts.Timeout < 1 {
+		return fmt.Errorf("network_mounts.timeout must be at least 1 second")
+	}
+	for _, ups := range c.Power.UPS {
+		switch ups.Type {
+		case "nut":
+			if ups.Target == "" {
+				return fmt.Errorf("power.ups: nut requires target (ups@host)")
+			}
+		case "apcupsd":
+		default:
+			return fmt.Errorf("power.ups: unknown type %q (use nut or apcupsd)", ups.Type)
+		}
+	}
+	if len(c.Power.UPS) > 0 && c.Power.UPSPollInterval < 1 {
+		return fmt.Errorf("power.ups_poll_interval must be at least 1 second")
+	}
+	switch c.Docker.Runtime {
+	case "docker", "podman", "cri":
+	default:
+		return fmt.Errorf("docker.runtime must be docker, podman or cri")
+	}
+	if c.Status.Enabled && c.Status.Listen == "" {
+		return fmt.Errorf("status.listen is required when the status endpoint is enabled")
+	}
+	sinks := make(map[string]bool)
+	for _, sink := range c.Sinks {
+		if sink.Name == "" || sinks[sink.Name] {
+			return fmt.Errorf("sinks need a unique name")
+		}
+		sinks[sink.Name] = true
+		if sink.APIEndpoint == "" || len(sink.Sections) == 0 {
+			return fmt.Errorf("sink %s: api_endpoint and sections are required", sink.Name)
+		}
+		if sink.HeartbeatPath != "" && !strings.HasPrefix(sink.HeartbeatPath, "/") {
+			return fmt.Errorf("sink %s: heartbeat_path must start with /", sink.Name)
+		}
+	}
+	organizations := make(map[string]bool)
+	for _, org := range c.Organizations {
+		if org.OrganizationSlug == "" || organizations[org.OrganizationSlug] {
+			return fmt.Errorf("organizations entries need a unique organization_slug")
+		}
+		organizations[org.OrganizationSlug] = true
+		if org.APIKey == "" {
+			return fmt.Errorf("organization %s: api_key is required", org.OrganizationSlug)
+		}
+	}
+	if c.Docker.RestartThreshold > 0 && c.Docker.RestartWindow < 1 {
+		return fmt.Errorf("docker.restart_window must be at least 1 second")
+	}
+	if c.Docker.Kubernetes.Enabled && c.Docker.Kubernetes.Interval < 1 {
+		return fmt.Errorf("docker.kubernetes.interval must be at least 1 second")
+	}
+	if c.Tasks.Enabled && c.Tasks.PublicKey == "" {
+		return fmt.Errorf("tasks.public_key is required when tasks are enabled")
+	}
+	if c.Tasks.Enabled && c.Tasks.Timeout < 1 {
+		return fmt.Errorf("tasks.timeout must be at least 1 second")
+	}
+	if c.Security.Sandbox.User != "" && c.Tasks.Enabled && len(c.Tasks.Services) > 0 {
+		// systemctl restart needs root once privileges are dropped.
+		return fmt.Errorf("tasks.services cannot be used with security.sandbox.user")
+	}
+	if c.ScriptLibrary.Enabled && c.ScriptLibrary.PublicKey == "" {
+		return fmt.Errorf("script_library.public_key (or tasks.public_key) is required when the script library is enabled")
+	}
+	if c.Cleanup.Enabled {
+		if err := validateCleanup(c.Cleanup); err != nil {
+			return err
+		}
+	}
+	if c.Remediation.Enabled {
+		if c.Security.Sandbox.User != "" && len(c.Remediation.Rules) > 0 {
+			// Like restart_service tasks, remediation runs systemctl.
+			return fmt.Errorf("remediation cannot be used with security.sandbox.user")
+		}
+		for _, rule := range c.Remediation.Rules {
+			if rule.Check == "" || !unitName.MatchString(rule.RestartService) {
+				return fmt.Errorf("remediation.rules entries require a check and a valid restart_service unit name")
+			}
+			if rule.MaxPerHour < 0 || rule.Cooldown < 0 {
+				return fmt.Errorf("remediation rule for %s: max_per_hour and cooldown must not be negative", rule.Check)
+			}
+		}
+	}
+	return nil
+}
+
+// unitName keeps remediation from passing options to systemctl.
+var unitName = regexp.MustCompile(`^[A-Za-z0-9_.@:\\][A-Za-z0-9_.@:\\-]*$`)
+
+func validateCleanup(cfg CleanupConfig) error {
+	if cfg.Interval < 0 {
+		return fmt.Errorf("cleanup.interval must not be negative")
+	}
+	if len(cfg.Rules) == 0 {
+		return fmt.Errorf("cleanup.rules is required when cleanup is enabled")
+	}
+	names := make(map[string]bool)
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("cleanup.rules entries require a name")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate cleanup rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if len(rule.Paths) == 0 || rule.MaxAge < 1 {
+			return fmt.Errorf("cleanup rule %s: paths and a max_age of at least 1 hour are required", rule.Name)
+		}
+		for _, path := range rule.Paths {
+			// A typo must not turn into removing everything.
+			if !filepath.IsAbs(path) || filepath.Dir(filepath.Clean(path)) == filepath.Clean(path) {
+				return fmt.Errorf("cleanup rule %s: %q must be an absolute path below the root directory", rule.Name, path)
+			}
+		}
+		if _, err := filepath.Match(rule.Pattern, ""); err != nil {
+			return fmt.Errorf("cleanup rule %s: invalid pattern: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateChecks(section string, checks []CheckConfig) error {
+	names := make(map[string]bool)
+	for _, check := range checks {
+		if check.Name == "" {
+			return fmt.Errorf("%s entries require a name", section)
+		}
+		if names[check.Name] {
+			return fmt.Errorf("%s: duplicate check name %q", section, check.Name)
+		}
+		names[check.Name] = true
+		switch check.Type {
+		case "tcp", "http", "redis", "docker", "grpc":
+			if check.Target == "" {
+				return fmt.Errorf("check %q: target is required", check.Name)
+			}
+		case "freshness":
+			if check.Target == "" || check.MaxAge <= 0 {
+				return fmt.Errorf("check %q: freshness requires target and max_age", check.Name)
+			}
+		case "transaction":
+			if len(check.Steps) == 0 {
+				return fmt.Errorf("check %q: transaction requires steps", check.Name)
+			}
+			for i, step := range check.Steps {
+				if step.URL == "" {
+					return fmt.Errorf("check %q: step %d requires a url", check.Name, i+1)
+				}
+			}
+		default:
+			return fmt.Errorf("check %q: type must be tcp, http, redis, docker, grpc, freshness or transaction", check.Name)
+		}
+	}
+	return nil
+}
+
+func validateApps(apps []AppConfig) error {
+	names := make(map[string]bool)
+	for _, app := range apps {
+		if app.Name == "" {
+			return fmt.Errorf("apps entries require a name")
+		}
+		if names[app.Name] {
+			return fmt.Errorf("apps: duplicate name %q", app.Name)
+		}
+		names[app.Name] = true
+		switch app.Type {
+		case "phpfpm", "uwsgi", "jolokia", "haproxy", "traefik", "rabbitmq", "kafka", "elasticsearch", "memcached":
+		default:
+			return fmt.Errorf("app %q: type must be phpfpm, uwsgi, jolokia, haproxy, traefik, rabbitmq, kafka, elasticsearch or memcached", app.Name)
+		}
+		if app.Target == "" {
+			return fmt.Errorf("app %q: target is required", app.Name)
+		}
+	}
+	return nil
+}
+
+func (l LogsConfig) validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if len(l.Sources) == 0 {
+		return fmt.Errorf("logs.sources is required when logs are enabled")
+	}
+	if l.BatchSize < 1 || l.FlushInterval < 1 || l.BufferBatches < 1 {
+		return fmt.Errorf("logs.batch_size, flush_interval and buffer_batches must be at least 1")
+	}
+	names := make(map[string]bool)
+	for _, src := range l.Sources {
+		if src.Name == "" {
+			return fmt.Errorf("logs.sources entries require a name")
+		}
+		if names[src.Name] {
+			return fmt.Errorf("logs.sources: duplicate name %q", src.Name)
+		}
+		names[src.Name] = true
+		kinds := 0
+		for _, set := range [][]string{src.Paths, src.Units, src.Listen} {
+			if len(set) > 0 {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return fmt.Errorf("logs source %q: set one of paths, units or listen", src.Name)
+		}
+		for _, addr := range src.Listen {
+			network, address, _ := strings.Cut(addr, "://")
+			if network != "udp" && network != "tcp" || address == "" {
+				return fmt.Errorf("logs source %q: listen address %q must be udp://host:port or tcp://host:port", src.Name, addr)
+			}
+		}
+		if len(src.Units) == 0 && (src.Priority != "" || len(src.Fields) > 0 || src.Events) {
+			return fmt.Errorf("logs source %q: priority, fields and events require units", src.Name)
+		}
+		if src.Priority != "" && !journalPriorities[src.Priority] {
+			return fmt.Errorf("logs source %q: invalid priority %q", src.Name, src.Priority)
+		}
+		for _, pattern := range append(append([]string{}, src.Include...), src.Exclude...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("logs source %q: invalid pattern %q: %w", src.Name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateKeyFile keeps crypto.key_file out of the directories whose data
+// it encrypts, where it would be copied along with that data.
+func (c *Config) validateKeyFile() error {
+	if !filepath.IsAbs(c.Crypto.KeyFile) {
+		return fmt.Errorf("crypto.key_file must be an absolute path")
+	}
+	dirs := []string{filepath.Dir(c.HostIDFile), filepath.Dir(c.StateFile), c.Spool.Dir, c.Logs.BufferDir}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, c.Crypto.KeyFile); err == nil && !strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("crypto.key_file must be outside %s, which holds the data it protects", dir)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Credentials are issued by the backend during enrollment and stored
+// separately from the (often centrally managed) config file.
+type Credentials struct {
+	APIEndpoint      string `yaml:"api_endpoint,omitempty"`
+	OrganizationSlug string `yaml:"organization_slug"`
+	APIKey           string `yaml:"api_key"`
+}
+
+// loadCredentials fills in settings left empty in the config file from a
+// previous enrollment. Explicit config values always win.
+func (c *Config) loadCredentials() error {
+	if c.CredentialsFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.CredentialsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var creds Credentials
+	if err := yaml.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if c.OrganizationSlug == "" {
+		c.OrganizationSlug = creds.OrganizationSlug
+	}
+	if c.APIKey == "" {
+		c.APIKey = creds.APIKey
+	}
+	if creds.APIEndpoint != "" && (c.APIEndpoint == "" || c.APIEndpoint == EndpointAuto) {
+		c.APIEndpoint = creds.APIEndpoint
+	}
+	return nil
+}
+
+func SaveCredentials(path string, creds Credentials) error {
+	data, err := yaml.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for credentials file: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
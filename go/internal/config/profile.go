@@ -0,0 +1,35 @@
+package config
+
+const (
+	ProfileStandard = "standard"
+	ProfileMinimal  = "minimal"
+)
+
+// minimalMemoryMB is the default memory limit of the minimal profile,
+// sized for 512MB Raspberry Pi-class gateways.
+const minimalMemoryMB = 24
+
+// applyProfile adjusts settings for the selected profile. The minimal
+// profile overrides explicit collector settings on purpose: it exists so a
+// fleet-wide config can be shipped to small devices unchanged.
+func (c *Config) applyProfile() {
+	if c.Profile != ProfileMinimal {
+		return
+	}
+
+	c.Interval = max(c.Interval, 60)
+	if c.Resources.MaxMemoryMB == 0 {
+		c.Resources.MaxMemoryMB = minimalMemoryMB
+	}
+
+	c.Traceroute.Enabled = false
+	c.Neighbors.Enabled = false
+	c.ProcessTCP.Enabled = false
+	c.TopTalkers.Enabled = false
+	c.Compliance.Enabled = false
+
+	c.PublicIP.Interval = max(c.PublicIP.Interval, 3600)
+	c.Firewall.Interval = max(c.Firewall.Interval, 3600)
+	c.Security.Interval = max(c.Security.Interval, 3600)
+	c.ScriptLibrary.Interval = max(c.ScriptLibrary.Interval, 6*3600)
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Schema returns a JSON Schema (draft 2020-12) describing the config file,
+// with the built-in defaults. Like strict parsing, it rejects unknown keys.
+func Schema() map[string]interface{} {
+	schema := schemaFor(reflect.TypeOf(Config{}), reflect.ValueOf(defaultConfig()).Elem())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Sentinel agent configuration"
+	return schema
+}
+
+func schemaFor(t reflect.Type, def reflect.Value) map[string]interface{} {
+	s := make(map[string]interface{})
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			var fieldDef reflect.Value
+			if def.IsValid() {
+				fieldDef = def.Field(i)
+			}
+			properties[name] = schemaFor(field.Type, fieldDef)
+		}
+		s["type"] = "object"
+		s["properties"] = properties
+		s["additionalProperties"] = false
+		return s
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), reflect.Value{})
+	case reflect.Slice, reflect.Array:
+		s["type"] = "array"
+		s["items"] = schemaFor(t.Elem(), reflect.Value{})
+	case reflect.Map:
+		s["type"] = "object"
+		s["additionalProperties"] = schemaFor(t.Elem(), reflect.Value{})
+	case reflect.String:
+		s["type"] = "string"
+	case reflect.Bool:
+		s["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s["type"] = "integer"
+	case reflect.Float32, reflect.Float64:
+		s["type"] = "number"
+	}
+	// Only scalars: Go would encode struct defaults with field names
+	// rather than config keys.
+	if def.IsValid() && !def.IsZero() && t.Kind() != reflect.Slice && t.Kind() != reflect.Map {
+		s["default"] = def.Interface()
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"text/template"
+)
+
+// templateFuncs are available in the config file, e.g.
+// `{{ hostname }}` or `{{ env "DC" }}`, so one file can be shipped to a
+// whole fleet.
+var templateFuncs = template.FuncMap{
+	"hostname": func() (string, error) { return os.Hostname() },
+	"env":      os.Getenv,
+	"os":       func() string { return runtime.GOOS },
+	"arch":     func() string { return runtime.GOARCH },
+}
+
+// render resolves template expressions in the raw config file. Files
+// without any are returned unchanged.
+func render(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("{{")) {
+		return data, nil
+	}
+	tmpl, err := template.New("config").Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return nil, fmt.Errorf("failed to render config template: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+// Package cryptopolicy holds the TLS settings outbound HTTP clients use,
+// restricted to FIPS-approved algorithms when crypto.fips is set.
+package cryptopolicy
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+var fipsTLS bool
+
+// EnableFIPS restricts the TLS settings of TLSConfig, NewTransport and
+// http.DefaultTransport to FIPSTLSConfig. It must be called before any
+// HTTP client is created.
+func EnableFIPS() {
+	fipsTLS = true
+	http.DefaultTransport.(*http.Transport).TLSClientConfig = FIPSTLSConfig()
+}
+
+// FIPSTLSConfig limits TLS to FIPS 140-approved versions, cipher suites and
+// curves. Builds made with GOEXPERIMENT=boringcrypto enforce the same policy
+// process-wide through crypto/tls/fipsonly.
+func FIPSTLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+		CurvePreferences: []tls.CurveID{tls.CurveP256, tls.CurveP384},
+	}
+	if !Boring {
+		// The standard library's TLS 1.3 suites cannot be restricted and
+		// include ChaCha20-Poly1305.
+		config.MaxVersion = tls.VersionTLS12
+	}
+	return config
+}
+
+// TLSConfig returns a new TLS configuration following the crypto policy,
+// for clients that need to add e.g. their own root CAs.
+func TLSConfig() *tls.Config {
+	if fipsTLS {
+		return FIPSTLSConfig()
+	}
+	return &tls.Config{}
+}
+
+// NewTransport returns a transport following the crypto policy, for
+// clients that don't derive from http.DefaultTransport. Callers add their
+// own proxy and dial settings.
+func NewTransport() *http.Transport {
+	return &http.Transport{TLSClientConfig: TLSConfig(), ForceAttemptHTTP2: true}
+}
@@ -0,0 +1,8 @@
+//go:build boringcrypto
+
+package cryptopolicy
+
+import _ "crypto/tls/fipsonly"
+
+// Boring reports a build with the BoringCrypto FIPS module.
+const Boring = true
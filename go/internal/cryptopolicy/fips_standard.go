@@ -0,0 +1,6 @@
+//go:build !boringcrypto
+
+package cryptopolicy
+
+// Boring reports a build with the BoringCrypto FIPS module.
+const Boring = false
@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dhcpOption is the site-specific DHCP option carrying the collector URL.
+const dhcpOption = "224"
+
+var dhclientLeaseGlobs = []string{
+	"/var/lib/dhcp/dhclient*.leases",
+	"/var/lib/dhclient/*.lease*",
+	"/var/lib/NetworkManager/*.lease",
+}
+
+// dhcpEndpoint reads the collector URL from systemd-networkd or dhclient
+// leases. dhclient must request the option, e.g.
+// "option sentinel-url code 224 = text; also request sentinel-url;".
+func dhcpEndpoint() string {
+	if u := networkdLeaseEndpoint(); u != "" {
+		return u
+	}
+	for _, pattern := range dhclientLeaseGlobs {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			if u := dhclientLeaseEndpoint(path); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+func networkdLeaseEndpoint() string {
+	paths, _ := filepath.Glob("/run/systemd/netif/leases/*")
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			value, ok := strings.CutPrefix(scanner.Text(), "PRIVATE_"+dhcpOption+"=")
+			if !ok {
+				continue
+			}
+			if data, err := hex.DecodeString(value); err == nil && validEndpoint(string(data)) {
+				f.Close()
+				return string(data)
+			}
+		}
+		f.Close()
+	}
+	return ""
+}
+
+// dhclientLeaseEndpoint returns the option from the most recent lease in
+// the file. Named options ("sentinel-url") and unnamed ones
+// ("unknown-224", hex or quoted) are both accepted.
+func dhclientLeaseEndpoint(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	found := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";"))
+		if len(fields) != 3 || fields[0] != "option" {
+			continue
+		}
+		if fields[1] != "sentinel-url" && fields[1] != "unknown-"+dhcpOption {
+			continue
+		}
+		if value := decodeLeaseValue(fields[2]); validEndpoint(value) {
+			found = value
+		}
+	}
+	return found
+}
+
+func decodeLeaseValue(value string) string {
+	if strings.HasPrefix(value, `"`) {
+		return strings.Trim(value, `"`)
+	}
+	data, err := hex.DecodeString(strings.ReplaceAll(value, ":", ""))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
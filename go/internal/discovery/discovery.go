@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service is the DNS-SD service type a Sentinel collector advertises, both
+// over mDNS (_sentinel._tcp.local) and in unicast DNS search domains.
+const Service = "_sentinel._tcp"
+
+// Endpoint describes how a discovered collector was found.
+type Endpoint struct {
+	URL    string
+	Source string
+}
+
+// Discover looks for the collector, in order, in a DHCP option, unicast
+// DNS-SD SRV records in the resolver's search domains, and mDNS on the LAN.
+// Anyone on the LAN can answer, and the agent sends its API key and
+// enrollment token to the endpoint, so only https endpoints are accepted,
+// and only on hosts when it is not empty.
+func Discover(timeout time.Duration, hosts []string) (*Endpoint, error) {
+	sources := []struct {
+		name string
+		find func() (string, error)
+	}{
+		{"dhcp", func() (string, error) { return dhcpEndpoint(), nil }},
+		{"dns-sd", func() (string, error) { return unicastDNSSD(), nil }},
+		{"mdns", func() (string, error) { return queryMDNS(timeout) }},
+	}
+	var problems []string
+	for _, source := range sources {
+		u, err := source.find()
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if u == "" {
+			continue
+		}
+		if err := trusted(u, hosts); err != nil {
+			problems = append(problems, fmt.Sprintf("ignoring %s from %s: %v", u, source.name, err))
+			continue
+		}
+		return &Endpoint{URL: u, Source: source.name}, nil
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("no usable %s service: %s", Service, strings.Join(problems, "; "))
+	}
+	return nil, fmt.Errorf("no %s service found via DHCP, DNS-SD or mDNS", Service)
+}
+
+func trusted(raw string, hosts []string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("discovered endpoints must use https")
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	for _, host := range hosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host is not in api_endpoint_hosts")
+}
+
+func unicastDNSSD() string {
+	for _, domain := range searchDomains("/etc/resolv.conf") {
+		_, srvs, err := net.LookupSRV("sentinel", "tcp", domain)
+		if err != nil || len(srvs) == 0 {
+			continue
+		}
+		txt, _ := net.LookupTXT(Service + "." + domain)
+		return serviceURL(strings.TrimSuffix(srvs[0].Target, "."), srvs[0].Port, parseTXT(txt))
+	}
+	return ""
+}
+
+func searchDomains(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	domains := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[0] == "search" || fields[0] == "domain" {
+			domains = append(domains, fields[1:]...)
+		}
+	}
+	return domains
+}
+
+func parseTXT(records []string) map[string]string {
+	values := make(map[string]string)
+	for _, record := range records {
+		if key, value, ok := strings.Cut(record, "="); ok {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	return values
+}
+
+// serviceURL builds the endpoint from SRV data. The TXT "scheme" key wins;
+// otherwise port 443 implies https.
+func serviceURL(host string, port uint16, txt map[string]string) string {
+	scheme := txt["scheme"]
+	if scheme != "http" && scheme != "https" {
+		scheme = "http"
+		if port == 443 {
+			scheme = "https"
+		}
+	}
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(host, strconv.Itoa(int(port))), Path: txt["path"]}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+func validEndpoint(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,204 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeTXT = 16
+	dnsTypeSRV = 33
+	dnsClassIN = 1
+	// Asks responders to answer by unicast.
+	mdnsUnicastResponse = 0x8000
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+type dnsRecord struct {
+	name  string
+	rtype uint16
+	data  []byte
+	// offset of data within the message, for names compressed against it
+	offset int
+}
+
+type srvTarget struct {
+	target string
+	port   uint16
+}
+
+// queryMDNS sends a one-shot (legacy unicast) PTR query for the service and
+// resolves the first instance that answers with SRV and A records.
+func queryMDNS(timeout time.Duration) (string, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return "", fmt.Errorf("mdns: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(buildQuery(Service+".local"), mdnsGroup); err != nil {
+		return "", fmt.Errorf("mdns: %w", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	instances := make(map[string]bool)
+	srvs := make(map[string]srvTarget)
+	txts := make(map[string]map[string]string)
+	addrs := make(map[string]net.IP)
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// Deadline reached without a complete answer.
+			return "", nil
+		}
+		msg := buf[:n]
+		records, err := parseRecords(msg)
+		if err != nil {
+			continue
+		}
+
+		for _, rr := range records {
+			switch rr.rtype {
+			case dnsTypePTR:
+				if strings.EqualFold(rr.name, Service+".local") {
+					if name, _, err := readName(msg, rr.offset); err == nil {
+						instances[name] = true
+					}
+				}
+			case dnsTypeSRV:
+				if len(rr.data) > 6 {
+					if target, _, err := readName(msg, rr.offset+6); err == nil {
+						srvs[rr.name] = srvTarget{target: target, port: binary.BigEndian.Uint16(rr.data[4:6])}
+					}
+				}
+			case dnsTypeTXT:
+				txts[rr.name] = parseTXT(txtStrings(rr.data))
+			case dnsTypeA:
+				if len(rr.data) == 4 {
+					addrs[rr.name] = net.IP(rr.data)
+				}
+			}
+		}
+
+		for instance := range instances {
+			srv, ok := srvs[instance]
+			if !ok {
+				continue
+			}
+			ip, ok := addrs[srv.target]
+			if !ok {
+				continue
+			}
+			return serviceURL(ip.String(), srv.port, txts[instance]), nil
+		}
+	}
+}
+
+func buildQuery(name string) []byte {
+	msg := make([]byte, 12, 64)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	for _, label := range strings.Split(name, ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypePTR)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN|mdnsUnicastResponse)
+	return msg
+}
+
+// parseRecords returns the answer, authority and additional records.
+func parseRecords(msg []byte) ([]dnsRecord, error) {
+	if len(msg) < 12 {
+		return nil, fmt.Errorf("short message")
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	rrcount := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+
+	records := make([]dnsRecord, 0, rrcount)
+	for i := 0; i < rrcount; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, fmt.Errorf("truncated record")
+		}
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		if start+length > len(msg) {
+			return nil, fmt.Errorf("truncated record data")
+		}
+		records = append(records, dnsRecord{name: name, rtype: rtype, data: msg[start : start+length], offset: start})
+		off = start + length
+	}
+	return records, nil
+}
+
+// readName decodes a possibly compressed domain name at off and returns it
+// without the trailing dot, plus the offset just past it.
+func readName(msg []byte, off int) (string, int, error) {
+	labels := make([]string, 0, 4)
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, fmt.Errorf("name out of bounds")
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, "."), end, nil
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, fmt.Errorf("name out of bounds")
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, fmt.Errorf("compression loop")
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, fmt.Errorf("label out of bounds")
+			}
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
+
+func txtStrings(data []byte) []string {
+	values := make([]string, 0)
+	for len(data) > 0 {
+		n := int(data[0])
+		if 1+n > len(data) {
+			break
+		}
+		values = append(values, string(data[1:1+n]))
+		data = data[1+n:]
+	}
+	return values
+}
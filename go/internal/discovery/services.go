@@ -0,0 +1,156 @@
+package discovery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"sentinel-agent/internal/config"
+)
+
+// knownService describes a daemon the agent can monitor without
+// configuration. Ports are the defaults it is expected to listen on.
+type knownService struct {
+	name      string
+	processes []string
+	check     func(ports map[uint32]bool) (config.CheckConfig, bool)
+}
+
+var knownServices = []knownService{
+	{
+		name:      "nginx",
+		processes: []string{"nginx"},
+		check: func(ports map[uint32]bool) (config.CheckConfig, bool) {
+			switch {
+			case ports[80]:
+				return config.CheckConfig{Type: "http", Target: "http://127.0.0.1/"}, true
+			case ports[443]:
+				return config.CheckConfig{Type: "tcp", Target: "127.0.0.1:443"}, true
+			}
+			return config.CheckConfig{}, false
+		},
+	},
+	{
+		name:      "postgres",
+		processes: []string{"postgres", "postmaster"},
+		check: func(ports map[uint32]bool) (config.CheckConfig, bool) {
+			if !ports[5432] {
+				return config.CheckConfig{}, false
+			}
+			return config.CheckConfig{Type: "tcp", Target: "127.0.0.1:5432"}, true
+		},
+	},
+	{
+		name:      "redis",
+		processes: []string{"redis-server"},
+		check: func(ports map[uint32]bool) (config.CheckConfig, bool) {
+			if !ports[6379] {
+				return config.CheckConfig{}, false
+			}
+			return config.CheckConfig{Type: "redis", Target: "127.0.0.1:6379"}, true
+		},
+	},
+	{
+		name:      "docker",
+		processes: []string{"dockerd"},
+		check: func(map[uint32]bool) (config.CheckConfig, bool) {
+			const socket = "/var/run/docker.sock"
+			if _, err := os.Stat(socket); err != nil {
+				return config.CheckConfig{}, false
+			}
+			return config.CheckConfig{Type: "docker", Target: socket}, true
+		},
+	},
+}
+
+// DiscoverServices finds well-known daemons running on this host by
+// process name and listening ports and returns a default check for each.
+// Services named in cfg.Disable or already covered by a configured check
+// of the same name are skipped.
+func DiscoverServices(cfg config.DiscoveryConfig, configured []config.CheckConfig) ([]config.CheckConfig, error) {
+	skip := make(map[string]bool)
+	for _, name := range cfg.Disable {
+		skip[name] = true
+	}
+	for _, c := range configured {
+		skip[c.Name] = true
+	}
+
+	running, err := listeningProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]config.CheckConfig, 0)
+	for _, svc := range knownServices {
+		if skip[svc.name] {
+			continue
+		}
+		ports, ok := servicePorts(running, svc.processes)
+		if !ok {
+			continue
+		}
+		check, ok := svc.check(ports)
+		if !ok {
+			continue
+		}
+		check.Name = svc.name
+		check.Source = "discovered"
+		found = append(found, check)
+	}
+	return found, nil
+}
+
+// listeningProcesses maps process names to the TCP ports they listen on.
+// A running process without listening sockets maps to an empty set.
+func listeningProcesses() (map[string]map[uint32]bool, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	names := make(map[int32]string, len(procs))
+	running := make(map[string]map[uint32]bool)
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		name = strings.ToLower(name)
+		names[p.Pid] = name
+		if running[name] == nil {
+			running[name] = make(map[uint32]bool)
+		}
+	}
+
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		// Without socket ownership only socket-based services can be found.
+		return running, nil
+	}
+	for _, conn := range conns {
+		if conn.Status != "LISTEN" {
+			continue
+		}
+		if name, ok := names[conn.Pid]; ok {
+			running[name][conn.Laddr.Port] = true
+		}
+	}
+	return running, nil
+}
+
+func servicePorts(running map[string]map[uint32]bool, processes []string) (map[uint32]bool, bool) {
+	ports := make(map[uint32]bool)
+	found := false
+	for _, name := range processes {
+		if p, ok := running[name]; ok {
+			found = true
+			for port := range p {
+				ports[port] = true
+			}
+		}
+	}
+	return ports, found
+}
@@ -0,0 +1,197 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+// Client talks to the Docker Engine API over its unix socket.
+type Client struct {
+	httpClient *http.Client
+}
+
+func New(socket string) *Client {
+	transport := cryptopolicy.NewTransport()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+	return &Client{httpClient: &http.Client{Transport: transport}}
+}
+
+type Container struct {
+	ID              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Image           string            `json:"Image"`
+	Created         int64             `json:"Created"`
+	State           string            `json:"State"`
+	Status          string            `json:"Status"`
+	Labels          map[string]string `json:"Labels"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Name returns the primary container name without the leading slash.
+func (c *Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID[:min(len(c.ID), 12)]
+	}
+	name := c.Names[0]
+	if len(name) > 0 && name[0] == '/' {
+		name = name[1:]
+	}
+	return name
+}
+
+// IPAddress returns the container's address on its first network, or ""
+// for containers using the host network.
+func (c *Container) IPAddress() string {
+	for _, network := range c.NetworkSettings.Networks {
+		if network.IPAddress != "" {
+			return network.IPAddress
+		}
+	}
+	return ""
+}
+
+type Stats struct {
+	CPUStats    CPUStats    `json:"cpu_stats"`
+	PreCPUStats CPUStats    `json:"precpu_stats"`
+	MemoryStats MemoryStats `json:"memory_stats"`
+	Networks    map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+type CPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+type MemoryStats struct {
+	Usage uint64            `json:"usage"`
+	Limit uint64            `json:"limit"`
+	Stats map[string]uint64 `json:"stats"`
+}
+
+// DiskUsage is the /system/df summary. Sizes the daemon has not computed
+// are -1.
+type DiskUsage struct {
+	LayersSize int64        `json:"LayersSize"`
+	Images     []Image      `json:"Images"`
+	Volumes    []Volume     `json:"Volumes"`
+	BuildCache []BuildCache `json:"BuildCache"`
+}
+
+type Image struct {
+	ID         string   `json:"Id"`
+	RepoTags   []string `json:"RepoTags"`
+	Created    int64    `json:"Created"`
+	Size       int64    `json:"Size"`
+	SharedSize int64    `json:"SharedSize"`
+	Containers int64    `json:"Containers"`
+}
+
+// Dangling reports whether the image has no tag left.
+func (i *Image) Dangling() bool {
+	for _, tag := range i.RepoTags {
+		if tag != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+type Volume struct {
+	Name      string `json:"Name"`
+	Driver    string `json:"Driver"`
+	UsageData *struct {
+		Size     int64 `json:"Size"`
+		RefCount int64 `json:"RefCount"`
+	} `json:"UsageData"`
+}
+
+type BuildCache struct {
+	Size  int64 `json:"Size"`
+	InUse bool  `json:"InUse"`
+}
+
+// Containers lists containers, including stopped ones when all is set.
+func (c *Client) Containers(ctx context.Context, all bool) ([]Container, error) {
+	path := "/containers/json"
+	if all {
+		path += "?all=1"
+	}
+	var containers []Container
+	if err := c.get(ctx, path, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerDetails is the subset of /containers/{id}/json the agent uses.
+type ContainerDetails struct {
+	ID string `json:"Id"`
+	// RestartCount counts restarts by the restart policy.
+	RestartCount int `json:"RestartCount"`
+}
+
+// Inspect returns a container's details.
+func (c *Client) Inspect(ctx context.Context, id string) (*ContainerDetails, error) {
+	var details ContainerDetails
+	if err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/json", &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// Stats returns a single stats sample. The daemon fills precpu_stats from
+// a sample taken about a second earlier, so this call blocks briefly.
+func (c *Client) Stats(ctx context.Context, id string) (*Stats, error) {
+	var stats Stats
+	if err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/stats?stream=false", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// DiskUsage returns images, volumes and build cache with their sizes. The
+// daemon walks every volume for this, so it can take a while.
+func (c *Client) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	var usage DiskUsage
+	if err := c.get(ctx, "/system/df", &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("docker API %s returned %d: %s", path, resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
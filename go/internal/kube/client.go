@@ -0,0 +1,124 @@
+// Package kube reads pod metadata from the Kubernetes API using the
+// in-cluster service account, for agents running as a DaemonSet.
+package kube
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/cryptopolicy"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+type Client struct {
+	server     string
+	httpClient *http.Client
+}
+
+// NewInCluster configures a client from the service account mounted into
+// the pod and the KUBERNETES_SERVICE_* variables.
+func NewInCluster() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes pod (KUBERNETES_SERVICE_HOST is not set)")
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("service account CA contains no certificates")
+	}
+	transport := cryptopolicy.NewTransport()
+	transport.TLSClientConfig.RootCAs = pool
+	transport.TLSClientConfig.MinVersion = tls.VersionTLS12
+	return &Client{
+		server:     "https://" + net.JoinHostPort(host, port),
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+type Pod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []OwnerReference  `json:"ownerReferences"`
+	} `json:"metadata"`
+	Status struct {
+		ContainerStatuses     []ContainerStatus `json:"containerStatuses"`
+		InitContainerStatuses []ContainerStatus `json:"initContainerStatuses"`
+	} `json:"status"`
+}
+
+type OwnerReference struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Controller bool   `json:"controller"`
+}
+
+type ContainerStatus struct {
+	Name string `json:"name"`
+	// ContainerID is "<runtime>://<id>", e.g. "containerd://4f2a...".
+	ContainerID string `json:"containerID"`
+}
+
+// Controller returns the pod's controlling owner, if any.
+func (p *Pod) Controller() (OwnerReference, bool) {
+	for _, ref := range p.Metadata.OwnerReferences {
+		if ref.Controller {
+			return ref, true
+		}
+	}
+	return OwnerReference{}, false
+}
+
+// NodePods lists the pods scheduled on node. The service account needs
+// permission to list pods.
+func (c *Client) NodePods(ctx context.Context, node string) ([]Pod, error) {
+	var list struct {
+		Items []Pod `json:"items"`
+	}
+	path := "/api/v1/pods?fieldSelector=" + url.QueryEscape("spec.nodeName="+node)
+	if err := c.get(ctx, path, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	// Projected tokens are rotated, so the token is read for each request.
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.server+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kubernetes API %s returned %d: %s", path, resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,181 @@
+package logship
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	pollInterval = time.Second
+	// globInterval is how often paths are re-expanded to find new files.
+	globInterval = 10 * time.Second
+)
+
+type fileSource struct {
+	name   string
+	paths  []string
+	filter filter
+	files  map[string]*tailedFile
+}
+
+type tailedFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	reader  *bufio.Reader
+	offset  int64
+	partial string
+}
+
+func newFileSource(name string, paths []string, f filter) *fileSource {
+	return &fileSource{name: name, paths: paths, filter: f, files: make(map[string]*tailedFile)}
+}
+
+// run polls the matched files. At startup files resume from their saved
+// position, or are read from their end if they have none; files that
+// appear later are read from the start.
+func (f *fileSource) run(s *Shipper) {
+	defer func() {
+		for _, t := range f.files {
+			t.file.Close()
+		}
+	}()
+
+	first := true
+	var nextGlob time.Time
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if now := time.Now(); !now.Before(nextGlob) {
+			f.discover(s, first)
+			first = false
+			nextGlob = now.Add(globInterval)
+		}
+		for path, t := range f.files {
+			if !f.follow(s, t) {
+				return
+			}
+			if t.file == nil {
+				delete(f.files, path)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (f *fileSource) discover(s *Shipper, fromEnd bool) {
+	for _, pattern := range f.paths {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if _, ok := f.files[path]; ok {
+				continue
+			}
+			start := int64(0)
+			if fromEnd {
+				start = -1
+				if pos, ok := s.savedPosition(f.key(path)); ok {
+					start = pos.Offset
+				}
+			}
+			if t := openTailed(path, start); t != nil {
+				f.files[path] = t
+			}
+		}
+	}
+}
+
+// openTailed opens path at offset, or at its end when offset is -1. A
+// saved offset beyond the end means the file was replaced meanwhile.
+func openTailed(path string, offset int64) *tailedFile {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil
+	}
+	switch {
+	case offset < 0:
+		offset = info.Size()
+	case offset > info.Size():
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil
+	}
+	return &tailedFile{path: path, file: file, info: info, reader: bufio.NewReader(file), offset: offset}
+}
+
+// follow reads new lines and handles rotation and truncation. It returns
+// false when the shipper is stopping.
+func (f *fileSource) follow(s *Shipper, t *tailedFile) bool {
+	if !f.readLines(s, t) {
+		return false
+	}
+
+	current, err := os.Stat(t.path)
+	switch {
+	case err != nil || !os.SameFile(current, t.info):
+		// Rotated or removed: the old file was read to its end above.
+		// Continue with the new file from its start.
+		t.file.Close()
+		t.file = nil
+		if err == nil {
+			if next := openTailed(t.path, 0); next != nil {
+				*t = *next
+			}
+		}
+	case current.Size() < t.offset:
+		// Truncated in place (copytruncate).
+		t.file.Seek(0, io.SeekStart)
+		t.reader.Reset(t.file)
+		t.offset = 0
+		t.partial = ""
+	}
+	return true
+}
+
+func (f *fileSource) readLines(s *Shipper, t *tailedFile) bool {
+	for {
+		chunk, err := t.reader.ReadString('\n')
+		t.offset += int64(len(chunk))
+		if err != nil {
+			// Keep an unterminated last line until it is completed, up to
+			// the line length limit.
+			t.partial += chunk
+			if len(t.partial) < maxLineLength {
+				return true
+			}
+			chunk, t.partial = t.partial, ""
+		} else {
+			chunk, t.partial = t.partial+chunk, ""
+		}
+
+		line := Line{
+			Source:    f.name,
+			File:      t.path,
+			Timestamp: time.Now().UTC(),
+			Message:   strings.TrimRight(chunk, "\r\n"),
+			posKey:    f.key(t.path),
+			pos:       position{Offset: t.offset},
+		}
+		if !s.emit(line, f.filter) {
+			return false
+		}
+	}
+}
+
+func (f *fileSource) key(path string) string {
+	return "file:" + path
+}
@@ -0,0 +1,208 @@
+package logship
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"sentinel-agent/internal/collector"
+)
+
+const journalRestartDelay = 10 * time.Second
+
+type journalSource struct {
+	name     string
+	units    []string
+	priority string
+	fields   []string
+	events   bool
+	filter   filter
+}
+
+// journalSkipFields are either mapped onto Line or internal to journald
+// and are never kept by a "*" field selection.
+var journalSkipFields = map[string]bool{
+	"MESSAGE": true, "PRIORITY": true, "_SYSTEMD_UNIT": true,
+	"_BOOT_ID": true, "_MACHINE_ID": true, "_SOURCE_REALTIME_TIMESTAMP": true,
+}
+
+// run follows the units with journalctl, resuming after the saved cursor,
+// and restarts it if it exits.
+func (j *journalSource) run(s *Shipper) {
+	key := "journal:" + j.name
+	for {
+		args := []string{"-f", "-o", "json", "--no-pager"}
+		if pos, ok := s.savedPosition(key); ok && pos.Cursor != "" {
+			args = append(args, "--after-cursor", pos.Cursor)
+		} else {
+			args = append(args, "-n", "0")
+		}
+		for _, unit := range j.units {
+			args = append(args, "-u", unit)
+		}
+		if j.priority != "" {
+			args = append(args, "-p", j.priority)
+		}
+
+		err := j.follow(s, key, args)
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		log.Printf("Log source %s: journalctl exited (%v), restarting in %s", j.name, err, journalRestartDelay)
+		select {
+		case <-time.After(journalRestartDelay):
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (j *journalSource) follow(s *Shipper, key string, args []string) error {
+	cmd := exec.Command("journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.stop:
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		line := Line{
+			Source:    j.name,
+			Unit:      journalField(entry["_SYSTEMD_UNIT"]),
+			Priority:  journalField(entry["PRIORITY"]),
+			Timestamp: journalTime(journalField(entry["__REALTIME_TIMESTAMP"])),
+			Message:   journalField(entry["MESSAGE"]),
+			Fields:    j.keepFields(entry),
+			posKey:    key,
+			pos:       position{Cursor: journalField(entry["__CURSOR"])},
+		}
+		if j.events {
+			if !j.sendEvent(s, line) {
+				break
+			}
+			continue
+		}
+		if !s.emit(line, j.filter) {
+			break
+		}
+	}
+	cmd.Process.Kill()
+	return cmd.Wait()
+}
+
+func journalTime(realtime string) time.Time {
+	usec, err := strconv.ParseInt(realtime, 10, 64)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return time.UnixMicro(usec).UTC()
+}
+
+// keepFields returns the configured structured fields of an entry.
+func (j *journalSource) keepFields(entry map[string]json.RawMessage) map[string]string {
+	if len(j.fields) == 0 {
+		return nil
+	}
+	fields := make(map[string]string)
+	if len(j.fields) == 1 && j.fields[0] == "*" {
+		for name, raw := range entry {
+			if !strings.HasPrefix(name, "__") && !journalSkipFields[name] {
+				fields[name] = journalField(raw)
+			}
+		}
+	} else {
+		for _, name := range j.fields {
+			if raw, ok := entry[name]; ok {
+				fields[name] = journalField(raw)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// sendEvent reports a matching entry as an agent event and records the
+// cursor right away, since events are not part of a log batch.
+func (j *journalSource) sendEvent(s *Shipper, line Line) bool {
+	select {
+	case <-s.stop:
+		return false
+	default:
+	}
+	if j.filter.match(line.Message) {
+		attributes := map[string]string{"source": j.name}
+		for name, value := range line.Fields {
+			attributes[name] = value
+		}
+		if line.Unit != "" {
+			attributes["unit"] = line.Unit
+		}
+		if len(line.Message) > maxLineLength {
+			line.Message = line.Message[:maxLineLength]
+		}
+		s.events.Add("journal_entry", journalSeverity(line.Priority), line.Message, attributes)
+		s.lines.Add(1)
+	} else {
+		s.filtered.Add(1)
+	}
+	s.savePositions(map[string]position{line.posKey: line.pos})
+	return true
+}
+
+// journalSeverity maps syslog priorities: emerg to crit are critical,
+// err and warning are warnings.
+func journalSeverity(priority string) string {
+	switch priority {
+	case "0", "1", "2":
+		return collector.SeverityCritical
+	case "3", "4":
+		return collector.SeverityWarning
+	}
+	return collector.SeverityInfo
+}
+
+// journalField decodes a field value, which journalctl prints as an array
+// of bytes when it isn't valid UTF-8.
+func journalField(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var data []byte
+	var values []int
+	if err := json.Unmarshal(raw, &values); err == nil {
+		data = make([]byte, len(values))
+		for i, v := range values {
+			data[i] = byte(v)
+		}
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
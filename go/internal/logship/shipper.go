@@ -0,0 +1,405 @@
+// Package logship tails log files and journald units, receives syslog,
+// and forwards the lines to the backend in compressed batches.
+package logship
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/spool"
+	"sentinel-agent/internal/state"
+)
+
+const (
+	// queueSize bounds the lines read but not yet batched. When the
+	// queue is full, tailers stop reading until the batcher catches up.
+	queueSize     = 10000
+	maxLineLength = 16 * 1024
+	maxBackoff    = 5 * time.Minute
+	replayBatches = 10
+)
+
+// Line is one forwarded log line. File, Unit or Host (the syslog sender)
+// names where it came from.
+type Line struct {
+	Source    string    `json:"source"`
+	File      string    `json:"file,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	Priority  string    `json:"priority,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+
+	// Fields are the structured journald fields selected for the source,
+	// or the syslog facility, app, procid and msgid.
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// posKey and pos record the read position after this line, saved once
+	// the batch containing it has been sent or buffered. Filtered lines
+	// only advance the position.
+	posKey   string
+	pos      position
+	filtered bool
+}
+
+type position struct {
+	Offset int64  `json:"offset,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type batch struct {
+	Lines []Line `json:"lines"`
+}
+
+// PermanentError marks a batch the backend will never accept; it is
+// dropped instead of retried.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Stats are counters since startup. Buffered is the number of batches
+// currently waiting on disk.
+type Stats struct {
+	Lines          uint64 `json:"lines"`
+	Filtered       uint64 `json:"filtered"`
+	Batches        uint64 `json:"batches"`
+	Bytes          uint64 `json:"bytes"`
+	DroppedBatches uint64 `json:"droppedBatches"`
+	Buffered       int    `json:"buffered"`
+}
+
+type filter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func (f filter) match(line string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shipper owns the tailers, the batcher and the on-disk buffer. Batches
+// go straight to the backend while it is reachable and into the buffer
+// otherwise; buffered batches are replayed oldest first before new ones.
+type Shipper struct {
+	cfg       config.LogsConfig
+	send      func(data []byte) error
+	buffer    *spool.Spool
+	positions *state.Bucket
+	events    *collector.EventBuffer
+	sources   []source
+
+	queue chan Line
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	// sendMu serializes direct sends and replays so batches stay in order.
+	sendMu sync.Mutex
+
+	lines, filtered, batches, bytes, dropped atomic.Uint64
+}
+
+type source interface {
+	run(s *Shipper)
+}
+
+// New creates a shipper. send posts one gzip-compressed JSON batch;
+// journald sources in event mode report to events instead.
+func New(cfg config.LogsConfig, send func(data []byte) error, positions *state.Bucket, events *collector.EventBuffer, key []byte) (*Shipper, error) {
+	buffer, err := spool.New(cfg.BufferDir, cfg.BufferBatches, key)
+	if err != nil {
+		return nil, err
+	}
+	s := &Shipper{
+		cfg:       cfg,
+		send:      send,
+		buffer:    buffer,
+		positions: positions,
+		events:    events,
+		queue:     make(chan Line, queueSize),
+		stop:      make(chan struct{}),
+	}
+	for _, src := range cfg.Sources {
+		f, err := newFilter(src)
+		if err != nil {
+			return nil, fmt.Errorf("logs source %s: %w", src.Name, err)
+		}
+		switch {
+		case len(src.Listen) > 0:
+			s.sources = append(s.sources, &syslogSource{name: src.Name, listen: src.Listen, filter: f})
+		case len(src.Units) > 0:
+			s.sources = append(s.sources, &journalSource{
+				name:     src.Name,
+				units:    src.Units,
+				priority: src.Priority,
+				fields:   src.Fields,
+				events:   src.Events,
+				filter:   f,
+			})
+		default:
+			s.sources = append(s.sources, newFileSource(src.Name, src.Paths, f))
+		}
+	}
+	return s, nil
+}
+
+func newFilter(src config.LogSourceConfig) (filter, error) {
+	var f filter
+	for _, pattern := range src.Include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid include pattern: %w", err)
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range src.Exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// Listen binds the syslog listen addresses ahead of Start, so privileged
+// ports can be opened before the agent drops privileges. Start binds
+// whatever Listen did not.
+func (s *Shipper) Listen() {
+	for _, src := range s.sources {
+		if l, ok := src.(*syslogSource); ok {
+			l.bind()
+		}
+	}
+}
+
+func (s *Shipper) Start() {
+	for _, src := range s.sources {
+		s.wg.Add(1)
+		go func(src source) {
+			defer s.wg.Done()
+			src.run(s)
+		}(src)
+	}
+	s.wg.Add(2)
+	go s.batchLoop()
+	go s.replayLoop()
+}
+
+// Stop ends tailing and flushes the lines already read.
+func (s *Shipper) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Shipper) Stats() *Stats {
+	return &Stats{
+		Lines:          s.lines.Load(),
+		Filtered:       s.filtered.Load(),
+		Batches:        s.batches.Load(),
+		Bytes:          s.bytes.Load(),
+		DroppedBatches: s.dropped.Load(),
+		Buffered:       s.buffer.Len(),
+	}
+}
+
+// emit queues a line, blocking while the queue is full. It returns false
+// once the shipper is stopping.
+func (s *Shipper) emit(line Line, f filter) bool {
+	if !f.match(line.Message) {
+		s.filtered.Add(1)
+		line.filtered = true
+	}
+	if len(line.Message) > maxLineLength {
+		line.Message = line.Message[:maxLineLength]
+	}
+	select {
+	case s.queue <- line:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+func (s *Shipper) savedPosition(key string) (position, bool) {
+	var pos position
+	ok := s.positions != nil && s.positions.Get(key, &pos)
+	return pos, ok
+}
+
+func (s *Shipper) batchLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.cfg.FlushInterval) * time.Second)
+	defer ticker.Stop()
+
+	var pending []Line
+	flush := func() {
+		if len(pending) > 0 {
+			s.deliver(pending)
+			pending = nil
+		}
+	}
+	for {
+		select {
+		case line := <-s.queue:
+			pending = append(pending, line)
+			if len(pending) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case line := <-s.queue:
+					pending = append(pending, line)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a batch, or buffers it on disk when the backend is down or
+// older batches are still waiting, then saves the read positions.
+func (s *Shipper) deliver(lines []Line) {
+	b := batch{Lines: make([]Line, 0, len(lines))}
+	positions := make(map[string]position)
+	for _, line := range lines {
+		if line.posKey != "" {
+			positions[line.posKey] = line.pos
+		}
+		if !line.filtered {
+			b.Lines = append(b.Lines, line)
+		}
+	}
+	defer s.savePositions(positions)
+	if len(b.Lines) == 0 {
+		return
+	}
+
+	data, err := encodeBatch(b)
+	if err != nil {
+		log.Printf("Error encoding log batch: %v", err)
+		s.dropped.Add(1)
+		return
+	}
+	s.lines.Add(uint64(len(b.Lines)))
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.buffer.Len() == 0 {
+		err := s.send(data)
+		if err == nil {
+			s.batches.Add(1)
+			s.bytes.Add(uint64(len(data)))
+			return
+		}
+		var permanent *PermanentError
+		if errors.As(err, &permanent) {
+			log.Printf("Dropping log batch rejected by the backend: %v", err)
+			s.dropped.Add(1)
+			return
+		}
+	}
+	if err := s.buffer.Push(data); err != nil {
+		log.Printf("Error buffering log batch: %v", err)
+		s.dropped.Add(1)
+	}
+}
+
+func (s *Shipper) savePositions(positions map[string]position) {
+	if s.positions == nil {
+		return
+	}
+	for key, pos := range positions {
+		s.positions.Put(key, pos)
+	}
+}
+
+// replayLoop retries buffered batches, backing off while the backend keeps
+// failing.
+func (s *Shipper) replayLoop() {
+	defer s.wg.Done()
+	interval := time.Duration(s.cfg.FlushInterval) * time.Second
+	delay := interval
+	for {
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			return
+		}
+		if s.buffer.Len() == 0 {
+			delay = interval
+			continue
+		}
+
+		s.sendMu.Lock()
+		_, err := s.buffer.Replay(replayBatches, func(data []byte) error {
+			err := s.send(data)
+			var permanent *PermanentError
+			if errors.As(err, &permanent) {
+				log.Printf("Dropping buffered log batch rejected by the backend: %v", err)
+				s.dropped.Add(1)
+				return nil
+			}
+			if err == nil {
+				s.batches.Add(1)
+				s.bytes.Add(uint64(len(data)))
+			}
+			return err
+		})
+		s.sendMu.Unlock()
+
+		if err != nil {
+			delay = min(delay*2, maxBackoff)
+			log.Printf("Error sending buffered logs (%d batches waiting, retrying in %s): %v", s.buffer.Len(), delay, err)
+			continue
+		}
+		delay = interval
+	}
+}
+
+func encodeBatch(b batch) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
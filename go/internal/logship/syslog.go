@@ -0,0 +1,308 @@
+package logship
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxSyslogMessage  = 64 * 1024
+	syslogIdleTimeout = 5 * time.Minute
+)
+
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogSource receives RFC 3164 and RFC 5424 messages over UDP and TCP.
+// TCP accepts both newline-delimited and octet-counted framing (RFC 6587).
+type syslogSource struct {
+	name   string
+	listen []string
+	filter filter
+
+	bound     bool
+	conns     []net.PacketConn
+	listeners []net.Listener
+}
+
+// bind opens the listen addresses. Addresses that fail are logged and
+// skipped so one bad address doesn't stop the others.
+func (l *syslogSource) bind() {
+	if l.bound {
+		return
+	}
+	l.bound = true
+	for _, addr := range l.listen {
+		network, address, _ := strings.Cut(addr, "://")
+		switch network {
+		case "udp":
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				log.Printf("Log source %s: %v", l.name, err)
+				continue
+			}
+			l.conns = append(l.conns, conn)
+		case "tcp":
+			ln, err := net.Listen("tcp", address)
+			if err != nil {
+				log.Printf("Log source %s: %v", l.name, err)
+				continue
+			}
+			l.listeners = append(l.listeners, ln)
+		}
+	}
+}
+
+func (l *syslogSource) run(s *Shipper) {
+	l.bind()
+	var wg sync.WaitGroup
+	var closers []io.Closer
+	for _, conn := range l.conns {
+		closers = append(closers, conn)
+		wg.Add(1)
+		go func(conn net.PacketConn) {
+			defer wg.Done()
+			l.serveUDP(s, conn)
+		}(conn)
+	}
+	for _, ln := range l.listeners {
+		closers = append(closers, ln)
+		wg.Add(1)
+		go func(ln net.Listener) {
+			defer wg.Done()
+			l.serveTCP(s, ln)
+		}(ln)
+	}
+	<-s.stop
+	for _, c := range closers {
+		c.Close()
+	}
+	wg.Wait()
+}
+
+func (l *syslogSource) serveUDP(s *Shipper, conn net.PacketConn) {
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Log source %s: %v", l.name, err)
+			}
+			return
+		}
+		if !s.emit(parseSyslog(bytes.TrimRight(buf[:n], "\r\n\x00"), l.name, senderHost(addr)), l.filter) {
+			return
+		}
+	}
+}
+
+func (l *syslogSource) serveTCP(s *Shipper, ln net.Listener) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var mu sync.Mutex
+	conns := make(map[net.Conn]bool)
+	go func() {
+		<-s.stop
+		mu.Lock()
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Log source %s: %v", l.name, err)
+			}
+			return
+		}
+		mu.Lock()
+		conns[conn] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.readStream(s, conn)
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (l *syslogSource) readStream(s *Shipper, conn net.Conn) {
+	host := senderHost(conn.RemoteAddr())
+	r := bufio.NewReaderSize(conn, maxSyslogMessage)
+	for {
+		conn.SetReadDeadline(time.Now().Add(syslogIdleTimeout))
+		msg, err := readFrame(r)
+		if len(msg) > 0 {
+			if !s.emit(parseSyslog(msg, l.name, host), l.filter) {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readFrame reads one message: "LEN MSG" when the frame starts with a
+// digit, otherwise up to the next newline.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		prefix, err := r.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil || size > maxSyslogMessage {
+			return nil, errors.New("invalid octet count")
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(msg, "\r\n"), nil
+	}
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Overlong line: forward what fits and drop the rest.
+		msg := append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull {
+			_, err = r.ReadSlice('\n')
+		}
+		return msg, err
+	}
+	return bytes.TrimRight(line, "\r\n"), err
+}
+
+func senderHost(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// parseSyslog decodes the header of an RFC 5424 or RFC 3164 message.
+// Anything unparseable is forwarded as the message with the sender as host.
+func parseSyslog(data []byte, source, sender string) Line {
+	line := Line{
+		Source:    source,
+		Host:      sender,
+		Timestamp: time.Now().UTC(),
+		Message:   strings.ToValidUTF8(string(data), "�"),
+	}
+	msg := line.Message
+	if !strings.HasPrefix(msg, "<") {
+		return line
+	}
+	end := strings.IndexByte(msg, '>')
+	// ParseUint, unlike Atoi, rejects signs, so PRI is digits only.
+	pri, err := strconv.ParseUint(msg[1:max(end, 1)], 10, 8)
+	if end < 2 || end > 4 || err != nil || pri > 191 {
+		return line
+	}
+	line.Priority = strconv.FormatUint(pri%8, 10)
+	line.Fields = map[string]string{"facility": syslogFacilities[pri/8]}
+	msg = msg[end+1:]
+
+	if rest, ok := strings.CutPrefix(msg, "1 "); ok {
+		parseRFC5424(&line, rest)
+	} else {
+		parseRFC3164(&line, msg)
+	}
+	return line
+}
+
+func parseRFC5424(line *Line, msg string) {
+	// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	parts := strings.SplitN(msg, " ", 6)
+	if len(parts) < 6 {
+		line.Message = msg
+		return
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+		line.Timestamp = ts.UTC()
+	}
+	if parts[1] != "-" {
+		line.Host = parts[1]
+	}
+	for i, name := range []string{"app", "procid", "msgid"} {
+		if parts[i+2] != "-" {
+			line.Fields[name] = parts[i+2]
+		}
+	}
+	sd, text := splitStructuredData(parts[5])
+	if sd != "" {
+		line.Fields["structuredData"] = sd
+	}
+	line.Message = strings.TrimPrefix(text, "\ufeff")
+}
+
+// splitStructuredData separates the SD elements ("-" or one or more
+// "[...]" groups, with \] escapes) from the message.
+func splitStructuredData(s string) (string, string) {
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		return "", strings.TrimPrefix(rest, " ")
+	}
+	i := 0
+	for i < len(s) && s[i] == '[' {
+		for i++; i < len(s) && s[i] != ']'; i++ {
+			if s[i] == '\\' {
+				i++
+			}
+		}
+		i++
+	}
+	i = min(i, len(s))
+	return s[:i], strings.TrimPrefix(s[i:], " ")
+}
+
+func parseRFC3164(line *Line, msg string) {
+	// Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG; many devices omit parts.
+	if len(msg) >= 16 && msg[15] == ' ' {
+		if ts, err := time.ParseInLocation(time.Stamp, msg[:15], time.Local); err == nil {
+			now := time.Now()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			line.Timestamp = ts.UTC()
+			msg = msg[16:]
+			if host, rest, ok := strings.Cut(msg, " "); ok && !strings.HasSuffix(host, ":") {
+				line.Host = host
+				msg = rest
+			}
+		}
+	}
+	if tag, rest, ok := strings.Cut(msg, ": "); ok && !strings.ContainsAny(tag, " ") {
+		if app, pid, ok := strings.Cut(tag, "["); ok {
+			line.Fields["app"] = app
+			line.Fields["procid"] = strings.TrimSuffix(pid, "]")
+		} else {
+			line.Fields["app"] = tag
+		}
+		msg = rest
+	}
+	line.Message = msg
+}
@@ -0,0 +1,60 @@
+package sandbox
+
+import (
+	"fmt"
+	"log"
+	"os/user"
+	"strconv"
+
+	"sentinel-agent/internal/config"
+)
+
+// Apply drops privileges and restricts the agent once all files, sockets
+// and collectors that need root have been set up. writable lists the
+// directories the agent itself still needs to write to (host ID, script
+// library, fetch destinations); they must belong to the sandbox user and are
+// exempted from the Landlock policy. removable lists directories files may
+// only be deleted from, e.g. by cleanup rules; they must belong to the
+// sandbox user as well.
+func Apply(cfg config.SandboxConfig, writable, removable []string) error {
+	writable = append(writable, cfg.WritablePaths...)
+
+	if cfg.User != "" {
+		u, err := user.Lookup(cfg.User)
+		if err != nil {
+			return fmt.Errorf("failed to look up user %s: %w", cfg.User, err)
+		}
+		uid, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return fmt.Errorf("user %s has a non-numeric uid %q", cfg.User, u.Uid)
+		}
+		gid, err := strconv.Atoi(u.Gid)
+		if err != nil {
+			return fmt.Errorf("user %s has a non-numeric gid %q", cfg.User, u.Gid)
+		}
+		for _, dir := range removable {
+			if err := checkOwner(dir, uid); err != nil {
+				return fmt.Errorf("cleanup path: %w", err)
+			}
+		}
+		if err := dropPrivileges(uid, gid, writable); err != nil {
+			return err
+		}
+		log.Printf("Dropped privileges to user %s (uid %d)", cfg.User, uid)
+	}
+
+	if cfg.Landlock {
+		if err := restrictFilesystem(writable, removable); err != nil {
+			return fmt.Errorf("landlock: %w", err)
+		}
+		log.Printf("Landlock filesystem restrictions applied")
+	}
+
+	if cfg.Seccomp {
+		if err := installSeccompFilter(); err != nil {
+			return fmt.Errorf("seccomp: %w", err)
+		}
+		log.Printf("Seccomp filter installed")
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	seccompSetModeFilter   = 1
+	seccompFilterFlagTsync = 1
+	seccompRetAllow        = 0x7fff0000
+	seccompRetErrno        = 0x00050000
+)
+
+// landlockWriteAccess covers every filesystem right that modifies state in
+// Landlock ABI v1. Reads stay unrestricted since collectors read all over
+// /proc, /sys and /etc.
+const landlockWriteAccess = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+	unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+	unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+	unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+	unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+	unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+	unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+	unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+	unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+
+// deniedSyscalls are never needed by the agent or the tools it runs and
+// would be the first steps of most privilege escalation or persistence.
+var deniedSyscalls = []uint32{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_BPF,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+}
+
+var auditArch = map[string]uint32{
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"386":     unix.AUDIT_ARCH_I386,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"arm":     unix.AUDIT_ARCH_ARM,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"s390x":   unix.AUDIT_ARCH_S390X,
+}
+
+// dropPrivileges switches to uid and gid. Ownership is never changed: the
+// writable directories may hold other files, so they must already belong
+// to the user.
+func dropPrivileges(uid, gid int, writable []string) error {
+	for _, dir := range writable {
+		if err := checkOwner(dir, uid); err != nil {
+			return err
+		}
+	}
+
+	// Since Go 1.16 these apply to every thread of the process.
+	if err := syscall.Setgroups([]int{gid}); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("setgid: %w", err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("setuid: %w", err)
+	}
+	return nil
+}
+
+// checkOwner fails unless path, if it exists, belongs to uid.
+func checkOwner(path string, uid int) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != uid {
+		return fmt.Errorf("%s is owned by uid %d, not the sandbox user (uid %d); change its owner or stop using it", path, st.Uid, uid)
+	}
+	return nil
+}
+
+// allThreads runs a syscall on every thread. The runtime refuses when cgo is
+// enabled, which release builds (CGO_ENABLED=0) never are.
+func allThreads(name string, trap, a1, a2, a3 uintptr) error {
+	_, _, errno := syscall.AllThreadsSyscall(trap, a1, a2, a3)
+	if errno == syscall.ENOTSUP {
+		return fmt.Errorf("%s: requires a binary built with CGO_ENABLED=0", name)
+	}
+	if errno != 0 {
+		return fmt.Errorf("%s: %w", name, errno)
+	}
+	return nil
+}
+
+// restrictFilesystem denies writes outside the writable directories, except
+// deleting files below the removable ones. Landlock has no thread-sync flag,
+// so the ruleset is applied on every thread.
+func restrictFilesystem(writable, removable []string) error {
+	attr := unix.LandlockRulesetAttr{Access_fs: landlockWriteAccess}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("not supported by this kernel: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	// Subprocesses without captured output write to /dev/null.
+	paths := append([]string{os.DevNull}, writable...)
+	for _, path := range paths {
+		if err := addLandlockRule(int(fd), path, landlockWriteAccess); err != nil {
+			return err
+		}
+	}
+	for _, path := range removable {
+		if err := addLandlockRule(int(fd), path, unix.LANDLOCK_ACCESS_FS_REMOVE_FILE); err != nil {
+			return err
+		}
+	}
+
+	if err := allThreads("PR_SET_NO_NEW_PRIVS", unix.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0); err != nil {
+		return err
+	}
+	return allThreads("restrict_self", unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0)
+}
+
+func addLandlockRule(rulesetFD int, path string, access uint64) error {
+	f, err := os.OpenFile(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		access &= unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+		if access == 0 {
+			return nil
+		}
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(f.Fd())}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFD), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow access to %s: %w", path, errno)
+	}
+	return nil
+}
+
+// installSeccompFilter loads a denylist filter on all threads. Denied
+// syscalls fail with EPERM rather than killing the agent.
+func installSeccompFilter() error {
+	arch, ok := auditArch[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("unsupported architecture %s", runtime.GOARCH)
+	}
+
+	deny := uint32(seccompRetErrno | uint32(unix.EPERM))
+	n := len(deniedSyscalls)
+	filter := []unix.SockFilter{
+		// seccomp_data.arch; refuse syscalls made through another ABI.
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 4},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: arch, Jt: 1},
+		{Code: unix.BPF_RET | unix.BPF_K, K: deny},
+		// seccomp_data.nr; the x32 ABI shares the x86_64 arch value but
+		// sets bit 30, which would otherwise bypass the denylist.
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 0},
+		{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, K: 0x40000000, Jt: uint8(n + 1)},
+	}
+	for i, nr := range deniedSyscalls {
+		filter = append(filter, unix.SockFilter{
+			Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K,
+			K:    nr,
+			Jt:   uint8(n - i),
+		})
+	}
+	filter = append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetAllow},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: deny},
+	)
+
+	// TSYNC propagates no_new_privs from this thread to all others.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("PR_SET_NO_NEW_PRIVS: %w", err)
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, seccompSetModeFilter, seccompFilterFlagTsync,
+		uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package sandbox
+
+import "fmt"
+
+func dropPrivileges(uid, gid int, writable []string) error {
+	return fmt.Errorf("privilege dropping is only supported on Linux")
+}
+
+func checkOwner(path string, uid int) error {
+	return nil
+}
+
+func restrictFilesystem(writable, removable []string) error {
+	return fmt.Errorf("only supported on Linux")
+}
+
+func installSeccompFilter() error {
+	return fmt.Errorf("only supported on Linux")
+}
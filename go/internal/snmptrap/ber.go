@@ -0,0 +1,171 @@
+package snmptrap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BER tags used by SNMPv1/v2c messages.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagIPAddress   = 0x40
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+	tagOpaque      = 0x44
+	tagCounter64   = 0x46
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	pduResponse = 0xa2
+	pduTrapV1   = 0xa4
+	pduInform   = 0xa6
+	pduTrapV2   = 0xa7
+)
+
+var errTruncated = errors.New("truncated BER element")
+
+type element struct {
+	tag   byte
+	value []byte
+}
+
+// readElement splits the first TLV off data. Only definite lengths are
+// valid in SNMP.
+func readElement(data []byte) (element, []byte, error) {
+	if len(data) < 2 {
+		return element{}, nil, errTruncated
+	}
+	tag, length := data[0], int(data[1])
+	data = data[2:]
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(data) < n {
+			return element{}, nil, errors.New("invalid BER length")
+		}
+		length = 0
+		for _, b := range data[:n] {
+			length = length<<8 | int(b)
+		}
+		data = data[n:]
+	}
+	if length < 0 || length > len(data) {
+		return element{}, nil, errTruncated
+	}
+	return element{tag: tag, value: data[:length]}, data[length:], nil
+}
+
+// children splits a constructed element into its elements.
+func children(data []byte) ([]element, error) {
+	var out []element
+	for len(data) > 0 {
+		e, rest, err := readElement(data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+		data = rest
+	}
+	return out, nil
+}
+
+func parseInt(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errors.New("invalid integer")
+	}
+	v := int64(int8(b[0]))
+	for _, c := range b[1:] {
+		v = v<<8 | int64(c)
+	}
+	return v, nil
+}
+
+func parseUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func parseOID(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", errors.New("empty OID")
+	}
+	var parts []string
+	var v uint64
+	for i, c := range b {
+		v = v<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return "", errTruncated
+			}
+			continue
+		}
+		if parts == nil {
+			// The first subidentifier packs the first two arcs.
+			first := min(v/40, 2)
+			parts = append(parts, strconv.FormatUint(first, 10), strconv.FormatUint(v-first*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(v, 10))
+		}
+		v = 0
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// formatValue renders a varbind value. Octet strings are shown as text
+// when printable and as hex otherwise.
+func formatValue(e element) string {
+	switch e.tag {
+	case tagInteger:
+		v, err := parseInt(e.value)
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatInt(v, 10)
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		return strconv.FormatUint(parseUint(e.value), 10)
+	case tagOctetString, tagOpaque:
+		if utf8.Valid(e.value) && printable(string(e.value)) {
+			return string(e.value)
+		}
+		return hex.EncodeToString(e.value)
+	case tagOID:
+		oid, _ := parseOID(e.value)
+		return oid
+	case tagIPAddress:
+		if len(e.value) == 4 {
+			return net.IP(e.value).String()
+		}
+	case tagNull:
+		return ""
+	case tagNoSuchObject:
+		return "noSuchObject"
+	case tagNoSuchInstance:
+		return "noSuchInstance"
+	case tagEndOfMibView:
+		return "endOfMibView"
+	}
+	return fmt.Sprintf("0x%s", hex.EncodeToString(e.value))
+}
+
+func printable(s string) bool {
+	for _, r := range s {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+// Package snmptrap receives SNMP traps and reports them as agent events
+// attributed to the device that sent them.
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/collector"
+	"sentinel-agent/internal/config"
+)
+
+const (
+	maxMessageSize = 64 * 1024
+	// maxEventVars bounds the varbinds copied into event attributes.
+	maxEventVars = 32
+)
+
+// defaultSeverity applies to the standard traps unless configured.
+var defaultSeverity = map[string]string{
+	"1.3.6.1.6.3.1.1.5.3": collector.SeverityWarning,
+	"1.3.6.1.6.3.1.1.5.5": collector.SeverityWarning,
+	"1.3.6.1.6.3.1.1.5.6": collector.SeverityWarning,
+}
+
+type Receiver struct {
+	cfg         config.SNMPTrapsConfig
+	events      *collector.EventBuffer
+	communities map[string]bool
+	severity    map[string]string
+
+	conn net.PacketConn
+	wg   sync.WaitGroup
+
+	// Traps are counted per device in one-minute windows; beyond
+	// RateLimit they are dropped and a single trap_flood event is sent.
+	window time.Time
+	counts map[string]int
+}
+
+func NewReceiver(cfg config.SNMPTrapsConfig, events *collector.EventBuffer) *Receiver {
+	r := &Receiver{
+		cfg:         cfg,
+		events:      events,
+		communities: make(map[string]bool),
+		severity:    make(map[string]string),
+		counts:      make(map[string]int),
+	}
+	for _, c := range cfg.Communities {
+		r.communities[c] = true
+	}
+	for oid, severity := range defaultSeverity {
+		r.severity[oid] = severity
+	}
+	for oid, severity := range cfg.Severity {
+		for full, name := range genericTraps {
+			if name == oid {
+				oid = full
+			}
+		}
+		r.severity[strings.TrimPrefix(oid, ".")] = severity
+	}
+	return r
+}
+
+// Listen binds the listen address ahead of Start, so the privileged trap
+// port can be opened before the agent drops privileges.
+func (r *Receiver) Listen() error {
+	if r.conn != nil {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp", r.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen for SNMP traps: %w", err)
+	}
+	r.conn = conn
+	return nil
+}
+
+// Start binds the listen address, unless Listen did, and receives traps
+// until Stop.
+func (r *Receiver) Start() error {
+	if err := r.Listen(); err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go r.run()
+	return nil
+}
+
+func (r *Receiver) Stop() {
+	if r.conn != nil {
+		r.conn.Close()
+		r.wg.Wait()
+	}
+}
+
+func (r *Receiver) run() {
+	defer r.wg.Done()
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("SNMP trap receiver stopped: %v", err)
+			}
+			return
+		}
+		r.handle(buf[:n], addr)
+	}
+}
+
+func (r *Receiver) handle(data []byte, addr net.Addr) {
+	sender, _, _ := net.SplitHostPort(addr.String())
+	trap, err := parseTrap(data)
+	if err != nil {
+		log.Printf("Ignoring SNMP message from %s: %v", sender, err)
+		return
+	}
+	if len(r.communities) > 0 && !r.communities[trap.Community] {
+		return
+	}
+	if trap.response != nil {
+		r.conn.WriteTo(trap.response, addr)
+	}
+
+	address := sender
+	if trap.Agent != "" {
+		address = trap.Agent
+	}
+	device := address
+	if name, ok := r.cfg.Devices[address]; ok {
+		device = name
+	}
+	if !r.allow(device) {
+		return
+	}
+
+	name := genericTraps[trap.OID]
+	if name == "" {
+		name = trap.OID
+	}
+	attributes := map[string]string{
+		"device":  device,
+		"address": address,
+		"trapOid": trap.OID,
+		"version": trap.Version,
+	}
+	if address != sender {
+		attributes["sender"] = sender
+	}
+	for i, v := range trap.Vars {
+		if i == maxEventVars {
+			break
+		}
+		attributes[v.OID] = v.Value
+	}
+	r.events.Add("snmp_trap", r.severityFor(trap.OID),
+		fmt.Sprintf("SNMP trap %s from %s", name, device), attributes)
+}
+
+// severityFor returns the severity of the longest configured OID prefix
+// matching oid.
+func (r *Receiver) severityFor(oid string) string {
+	best, severity := -1, collector.SeverityInfo
+	for prefix, s := range r.severity {
+		if (oid == prefix || strings.HasPrefix(oid, prefix+".")) && len(prefix) > best {
+			best, severity = len(prefix), s
+		}
+	}
+	return severity
+}
+
+func (r *Receiver) allow(device string) bool {
+	now := time.Now()
+	if now.Sub(r.window) >= time.Minute {
+		r.window = now
+		clear(r.counts)
+	}
+	r.counts[device]++
+	count := r.counts[device]
+	if count == r.cfg.RateLimit+1 {
+		r.events.Add("snmp_trap_flood", collector.SeverityWarning,
+			fmt.Sprintf("%s sent more than %d SNMP traps in a minute; dropping until the next minute", device, r.cfg.RateLimit),
+			map[string]string{"device": device})
+	}
+	return count <= r.cfg.RateLimit
+}
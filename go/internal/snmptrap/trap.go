@@ -0,0 +1,177 @@
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+const (
+	oidSysUpTime  = "1.3.6.1.2.1.1.3.0"
+	oidTrapOID    = "1.3.6.1.6.3.1.1.4.1.0"
+	oidTrapPrefix = "1.3.6.1.6.3.1.1.5."
+)
+
+// genericTraps names the standard traps (RFC 3418), as v2 OIDs.
+var genericTraps = map[string]string{
+	"1.3.6.1.6.3.1.1.5.1": "coldStart",
+	"1.3.6.1.6.3.1.1.5.2": "warmStart",
+	"1.3.6.1.6.3.1.1.5.3": "linkDown",
+	"1.3.6.1.6.3.1.1.5.4": "linkUp",
+	"1.3.6.1.6.3.1.1.5.5": "authenticationFailure",
+	"1.3.6.1.6.3.1.1.5.6": "egpNeighborLoss",
+}
+
+// Trap is a decoded SNMPv1 trap, SNMPv2c trap or inform.
+type Trap struct {
+	Version   string
+	Community string
+	// Agent is the v1 agent-addr; empty for v2c.
+	Agent  string
+	OID    string
+	Uptime uint64
+	Vars   []Var
+
+	// response is the inform acknowledgement to send back, if any.
+	response []byte
+}
+
+type Var struct {
+	OID   string
+	Value string
+}
+
+// parseTrap decodes a trap message. v1 traps are translated to v2 trap
+// OIDs as described in RFC 3584.
+func parseTrap(data []byte) (*Trap, error) {
+	top, trailing, err := readElement(data)
+	if err != nil {
+		return nil, err
+	}
+	if top.tag != tagSequence {
+		return nil, errors.New("not an SNMP message")
+	}
+	version, rest, err := readElement(top.value)
+	if err != nil {
+		return nil, err
+	}
+	community, rest, err := readElement(rest)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseInt(version.value)
+	if err != nil || version.tag != tagInteger || community.tag != tagOctetString {
+		return nil, errors.New("malformed SNMP header")
+	}
+	pduOffset := len(data) - len(trailing) - len(rest)
+	pdu, _, err := readElement(rest)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := children(pdu.value)
+	if err != nil {
+		return nil, err
+	}
+
+	trap := &Trap{Community: string(community.value)}
+	switch {
+	case v == 0 && pdu.tag == pduTrapV1:
+		trap.Version = "v1"
+		err = trap.parseV1(fields)
+	case v == 1 && (pdu.tag == pduTrapV2 || pdu.tag == pduInform):
+		trap.Version = "v2c"
+		err = trap.parseV2(fields)
+		if err == nil && pdu.tag == pduInform {
+			// The response carries the same request ID and varbinds.
+			trap.response = append([]byte(nil), data[:len(data)-len(trailing)]...)
+			trap.response[pduOffset] = pduResponse
+		}
+	case v == 3:
+		return nil, errors.New("SNMPv3 is not supported")
+	default:
+		return nil, fmt.Errorf("unexpected PDU 0x%x for version %d", pdu.tag, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return trap, nil
+}
+
+func (t *Trap) parseV1(fields []element) error {
+	if len(fields) != 6 {
+		return errors.New("malformed v1 trap")
+	}
+	enterprise, err := parseOID(fields[0].value)
+	if err != nil {
+		return err
+	}
+	if fields[1].tag == tagIPAddress && len(fields[1].value) == 4 {
+		if ip := net.IP(fields[1].value); !ip.IsUnspecified() {
+			t.Agent = ip.String()
+		}
+	}
+	generic, err := parseInt(fields[2].value)
+	if err != nil {
+		return err
+	}
+	specific, err := parseInt(fields[3].value)
+	if err != nil {
+		return err
+	}
+	if generic == 6 {
+		t.OID = enterprise + ".0." + strconv.FormatInt(specific, 10)
+	} else {
+		t.OID = oidTrapPrefix + strconv.FormatInt(generic+1, 10)
+	}
+	t.Uptime = parseUint(fields[4].value)
+	t.Vars, err = parseVarbinds(fields[5])
+	return err
+}
+
+func (t *Trap) parseV2(fields []element) error {
+	if len(fields) != 4 {
+		return errors.New("malformed v2 trap")
+	}
+	vars, err := parseVarbinds(fields[3])
+	if err != nil {
+		return err
+	}
+	// sysUpTime.0 and snmpTrapOID.0 lead the varbinds.
+	for len(vars) > 0 && (vars[0].OID == oidSysUpTime || vars[0].OID == oidTrapOID) {
+		if vars[0].OID == oidSysUpTime {
+			t.Uptime, _ = strconv.ParseUint(vars[0].Value, 10, 64)
+		} else {
+			t.OID = vars[0].Value
+		}
+		vars = vars[1:]
+	}
+	t.Vars = vars
+	if t.OID == "" {
+		return errors.New("v2 trap without snmpTrapOID")
+	}
+	return nil
+}
+
+func parseVarbinds(list element) ([]Var, error) {
+	if list.tag != tagSequence {
+		return nil, errors.New("malformed varbind list")
+	}
+	binds, err := children(list.value)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]Var, 0, len(binds))
+	for _, bind := range binds {
+		pair, err := children(bind.value)
+		if err != nil || len(pair) != 2 || pair[0].tag != tagOID {
+			return nil, errors.New("malformed varbind")
+		}
+		oid, err := parseOID(pair[0].value)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, Var{OID: oid, Value: formatValue(pair[1])})
+	}
+	return vars, nil
+}
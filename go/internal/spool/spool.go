@@ -0,0 +1,168 @@
+package spool
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/utils"
+)
+
+const (
+	plainExt     = ".json"
+	encryptedExt = ".enc"
+)
+
+// Spool keeps payloads that could not be sent on disk, oldest first, so they
+// can be replayed once the backend is reachable again. When a key is set,
+// entries are encrypted at rest.
+type Spool struct {
+	dir        string
+	maxEntries int
+	key        []byte
+
+	mu  sync.Mutex
+	seq int64
+	// replayMu serializes replays without holding mu during sends, so
+	// Push is never held up by a slow backend.
+	replayMu sync.Mutex
+}
+
+// New opens (creating if needed) a spool directory. key may be nil to store
+// entries in plain text.
+func New(dir string, maxEntries int, key []byte) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &Spool{dir: dir, maxEntries: maxEntries, key: key}, nil
+}
+
+func (s *Spool) Push(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ext := plainExt
+	if s.key != nil {
+		sealed, err := utils.Seal(s.key, data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt spool entry: %w", err)
+		}
+		data, ext = sealed, encryptedExt
+	}
+
+	// Nanosecond names sort chronologically; seq breaks ties.
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, ext)
+	if err := writeFile(filepath.Join(s.dir, name), data); err != nil {
+		return err
+	}
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+	if excess := len(entries) - s.maxEntries; excess > 0 {
+		for _, entry := range entries[:excess] {
+			os.Remove(filepath.Join(s.dir, entry))
+		}
+		log.Printf("Spool full, dropped %d oldest entries", excess)
+	}
+	return nil
+}
+
+// Replay hands up to limit entries, oldest first, to send and removes each
+// one that was sent. It stops at the first send error, leaving that entry
+// and everything after it in place. Entries that cannot be decrypted (for
+// example after the host key changed) are discarded.
+func (s *Spool) Replay(limit int, send func(data []byte) error) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	entries, err := s.entries()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, entry := range entries[:min(len(entries), limit)] {
+		path := filepath.Join(s.dir, entry)
+		data, err := s.read(path)
+		if os.IsNotExist(err) {
+			// Dropped by Push since the listing because the spool was full.
+			continue
+		}
+		if err != nil {
+			log.Printf("Discarding unreadable spool entry %s: %v", entry, err)
+			os.Remove(path)
+			continue
+		}
+		if err := send(data); err != nil {
+			return sent, err
+		}
+		os.Remove(path)
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, _ := s.entries()
+	return len(entries)
+}
+
+func (s *Spool) read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, encryptedExt) {
+		return data, nil
+	}
+	if s.key == nil {
+		return nil, fmt.Errorf("entry is encrypted but spool encryption is disabled")
+	}
+	return utils.Open(s.key, data)
+}
+
+func (s *Spool) entries() ([]string, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		name := entry.Name()
+		if strings.HasSuffix(name, plainExt) || strings.HasSuffix(name, encryptedExt) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".spool-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
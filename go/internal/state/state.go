@@ -0,0 +1,147 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store is a small JSON document persisted across restarts, split into
+// named buckets so subsystems don't collide. Changes are kept in memory
+// until Flush, which the agent calls once per heartbeat.
+type Store struct {
+	path   string
+	cipher Cipher
+
+	mu    sync.Mutex
+	data  map[string]map[string]json.RawMessage
+	dirty bool
+}
+
+// Cipher encrypts the state file.
+type Cipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+type Bucket struct {
+	store *Store
+	name  string
+}
+
+// Open loads the store at path. cipher may be nil to store plain JSON; a
+// plain file written before encryption was enabled is still read. A
+// corrupt file, or one encrypted with another key, is logged and replaced
+// rather than keeping the agent from starting.
+func Open(path string, cipher Cipher) (*Store, error) {
+	s := &Store{path: path, cipher: cipher, data: make(map[string]map[string]json.RawMessage)}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if cipher != nil {
+		if json.Valid(data) {
+			// Encrypt the plain file with the next flush.
+			s.dirty = true
+		} else if data, err = cipher.Open(data); err != nil {
+			log.Printf("Discarding unreadable state file %s: %v", path, err)
+			return s, nil
+		}
+	}
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		log.Printf("Discarding unreadable state file %s: %v", path, err)
+		s.data = make(map[string]map[string]json.RawMessage)
+	}
+	return s, nil
+}
+
+func (s *Store) Bucket(name string) *Bucket {
+	return &Bucket{store: s, name: name}
+}
+
+// Flush writes the store to disk if anything changed since the last flush.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	if s.cipher != nil {
+		if data, err = s.cipher.Seal(data); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Get decodes the value stored under key into v and reports whether it
+// was present and readable.
+func (b *Bucket) Get(key string, v interface{}) bool {
+	b.store.mu.Lock()
+	raw, ok := b.store.data[b.name][key]
+	b.store.mu.Unlock()
+	return ok && json.Unmarshal(raw, v) == nil
+}
+
+func (b *Bucket) Put(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	if b.store.data[b.name] == nil {
+		b.store.data[b.name] = make(map[string]json.RawMessage)
+	}
+	b.store.data[b.name][key] = raw
+	b.store.dirty = true
+	return nil
+}
+
+// Flush writes the whole store now, for changes that must survive a crash
+// before the next regular flush.
+func (b *Bucket) Flush() error {
+	return b.store.Flush()
+}
+
+func (b *Bucket) Delete(key string) {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	if _, ok := b.store.data[b.name][key]; ok {
+		delete(b.store.data[b.name], key)
+		b.store.dirty = true
+	}
+}
+
+func (b *Bucket) Keys() []string {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	keys := make([]string, 0, len(b.store.data[b.name]))
+	for key := range b.store.data[b.name] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,229 @@
+package tasks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const manifestFile = "manifest.json"
+
+// Manifest lists the scripts published by the backend. It is delivered as
+// a SignedTask-style envelope so the same key signs tasks and scripts.
+type Manifest struct {
+	Version   string           `json:"version"`
+	Scripts   []ManifestScript `json:"scripts"`
+	ExpiresAt time.Time        `json:"expiresAt,omitempty"`
+}
+
+type ManifestScript struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"`
+	URL     string `json:"url"`
+}
+
+// Fetcher retrieves a path from the backend, e.g. APIClient.Get.
+type Fetcher func(ctx context.Context, path string) ([]byte, error)
+
+type Library struct {
+	dir          string
+	manifestPath string
+	key          ed25519.PublicKey
+	fetch        Fetcher
+
+	mu       sync.RWMutex
+	manifest *Manifest
+}
+
+func NewLibrary(dir, manifestPath, publicKey string, fetch Fetcher) (*Library, error) {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("script library public key: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create script directory: %w", err)
+	}
+
+	l := &Library{dir: dir, manifestPath: manifestPath, key: key, fetch: fetch}
+
+	// Reuse the last verified manifest so scripts are usable before the first sync.
+	if data, err := os.ReadFile(filepath.Join(dir, manifestFile)); err == nil {
+		if manifest, err := l.verify(data); err == nil {
+			l.manifest = manifest
+		}
+	}
+	return l, nil
+}
+
+// Start syncs the library every interval until ctx is canceled.
+func (l *Library) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := l.Sync(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Script library sync failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Sync downloads the signed manifest, fetches new or changed scripts,
+// verifies each against its manifest checksum and removes scripts that are
+// no longer published. A script that fails is logged and skipped, so it
+// doesn't hold up the others; Lookup refuses it until a later sync
+// installs it.
+func (l *Library) Sync(ctx context.Context) error {
+	data, err := l.fetch(ctx, l.manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	manifest, err := l.verify(data)
+	if err != nil {
+		return err
+	}
+
+	published := make(map[string]bool)
+	failed := 0
+	for _, script := range manifest.Scripts {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !validScriptName(script.Name) {
+			log.Printf("Skipping invalid script name %q in manifest", script.Name)
+			failed++
+			continue
+		}
+		published[script.Name] = true
+		if err := l.install(ctx, script); err != nil {
+			log.Printf("Skipping script %s: %v", script.Name, err)
+			failed++
+		}
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() != manifestFile && !published[entry.Name()] {
+			os.Remove(filepath.Join(l.dir, entry.Name()))
+		}
+	}
+
+	if err := writeFileAtomic(filepath.Join(l.dir, manifestFile), data, 0640); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.manifest = manifest
+	l.mu.Unlock()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d script(s) could not be installed", failed, len(manifest.Scripts))
+	}
+	return nil
+}
+
+// install fetches a script unless the installed copy is current.
+func (l *Library) install(ctx context.Context, script ManifestScript) error {
+	path := filepath.Join(l.dir, script.Name)
+	if verifyFileHash(path, script.SHA256) == nil {
+		return nil
+	}
+	body, err := l.fetch(ctx, script.URL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), script.SHA256) {
+		return fmt.Errorf("does not match manifest checksum")
+	}
+	if err := writeFileAtomic(path, body, 0750); err != nil {
+		return fmt.Errorf("failed to install: %w", err)
+	}
+	log.Printf("Installed script %s version %s", script.Name, script.Version)
+	return nil
+}
+
+// Lookup returns the installed path of a library script after re-checking
+// its checksum, so a script modified on disk is never executed.
+func (l *Library) Lookup(name string) (string, string, error) {
+	l.mu.RLock()
+	manifest := l.manifest
+	l.mu.RUnlock()
+
+	if manifest == nil {
+		return "", "", fmt.Errorf("script library has not been synchronized")
+	}
+	for _, script := range manifest.Scripts {
+		if script.Name != name {
+			continue
+		}
+		path := filepath.Join(l.dir, script.Name)
+		if err := verifyFileHash(path, script.SHA256); err != nil {
+			return "", "", err
+		}
+		return path, script.Version, nil
+	}
+	return "", "", fmt.Errorf("script %q is not in the library", name)
+}
+
+func (l *Library) verify(data []byte) (*Manifest, error) {
+	var envelope SignedTask
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid manifest envelope: %w", err)
+	}
+	payload, err := openEnvelope(envelope, l.key)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(payload, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if !manifest.ExpiresAt.IsZero() && time.Now().After(manifest.ExpiresAt) {
+		return nil, fmt.Errorf("manifest expired at %s", manifest.ExpiresAt)
+	}
+	return &manifest, nil
+}
+
+func validScriptName(name string) bool {
+	return name != "" && name != manifestFile && !strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".")
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sentinel-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
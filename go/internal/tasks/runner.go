@@ -0,0 +1,463 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinel-agent/internal/cleanup"
+	"sentinel-agent/internal/config"
+	"sentinel-agent/internal/state"
+)
+
+const (
+	maxOutputBytes = 64 * 1024
+	queueSize      = 32
+	seenRetention  = 24 * time.Hour
+)
+
+type Runner struct {
+	key       ed25519.PublicKey
+	hostID    string
+	timeout   time.Duration
+	scripts   map[string]string
+	services  map[string]bool
+	fetchDirs []string
+	library   *Library
+	cleaner   Cleaner
+
+	queue      chan SignedTask
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	results []Result
+	seen    map[string]*seenTask
+	// seenState keeps the seen task IDs with their expiry across restarts.
+	seenState *state.Bucket
+	stopped   bool
+}
+
+// seenTask remembers an executed task so a redelivery is answered with
+// its result instead of running it again.
+type seenTask struct {
+	until  time.Time
+	result *Result // nil until the task finishes
+}
+
+// NewRunner creates a task runner. library may be nil; when set and
+// allow_library is configured, run_script tasks may name library scripts.
+// seen persists the IDs of executed tasks so a restart cannot run them
+// again.
+func NewRunner(cfg config.TasksConfig, hostID string, library *Library, seen *state.Bucket) (*Runner, error) {
+	key, err := ParsePublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("tasks.public_key: %w", err)
+	}
+
+	services := make(map[string]bool)
+	for _, s := range cfg.Services {
+		services[s] = true
+	}
+
+	if !cfg.AllowLibrary {
+		library = nil
+	}
+
+	r := &Runner{
+		key:       key,
+		hostID:    hostID,
+		timeout:   time.Duration(cfg.Timeout) * time.Second,
+		scripts:   cfg.Scripts,
+		services:  services,
+		fetchDirs: cfg.FetchDirs,
+		library:   library,
+		queue:     make(chan SignedTask, queueSize),
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+		results:   make([]Result, 0),
+		seen:      make(map[string]*seenTask),
+		seenState: seen,
+	}
+	r.loadSeen(time.Now())
+	return r, nil
+}
+
+// loadSeen restores the tasks executed before a restart. Their results
+// were not kept, so a redelivery is answered with a rejection instead.
+func (r *Runner) loadSeen(now time.Time) {
+	for _, id := range r.seenState.Keys() {
+		var until time.Time
+		if !r.seenState.Get(id, &until) || now.After(until) {
+			r.seenState.Delete(id)
+			continue
+		}
+		r.seen[id] = &seenTask{until: until, result: &Result{
+			ID:         id,
+			Status:     StatusRejected,
+			ExitCode:   -1,
+			Error:      "task already ran before the agent restarted; its result was not kept",
+			FinishedAt: now.UTC(),
+		}}
+	}
+}
+
+// Cleaner runs the locally configured cleanup rules cleanup tasks name.
+type Cleaner interface {
+	HasRule(name string) bool
+	Clean(ctx context.Context, rule string, dryRun bool) (cleanup.Report, error)
+}
+
+// SetCleaner enables cleanup tasks. It must be called before Start.
+func (r *Runner) SetCleaner(cleaner Cleaner) {
+	r.cleaner = cleaner
+}
+
+func (r *Runner) Start() {
+	go func() {
+		for task := range r.queue {
+			r.addResult(r.execute(task))
+		}
+	}()
+}
+
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.queue)
+	}
+}
+
+// Submit queues tasks received from the backend. Tasks are executed one at
+// a time in the background so a long task never delays heartbeats.
+func (r *Runner) Submit(tasks []SignedTask) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	for _, task := range tasks {
+		select {
+		case r.queue <- task:
+		default:
+			log.Printf("Task queue full, dropping task")
+		}
+	}
+}
+
+// SetHostID updates the ID tasks must be addressed to after the host ID
+// was regenerated.
+func (r *Runner) SetHostID(hostID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hostID = hostID
+}
+
+func (r *Runner) DrainResults() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.results) == 0 {
+		return nil
+	}
+	results := r.results
+	r.results = make([]Result, 0)
+	return results
+}
+
+func (r *Runner) addResult(result *Result) {
+	if result == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, *result)
+}
+
+func (r *Runner) execute(task SignedTask) *Result {
+	now := time.Now()
+	result := &Result{StartedAt: now.UTC()}
+
+	r.mu.Lock()
+	hostID := r.hostID
+	r.mu.Unlock()
+
+	spec, err := Verify(task, r.key, hostID, now)
+	if spec != nil {
+		result.ID = spec.ID
+		result.Type = spec.Type
+	}
+	if err != nil {
+		log.Printf("Rejected task %s: %v", result.ID, err)
+		return r.reject(result, err)
+	}
+
+	if first, previous := r.markSeen(spec, now); !first {
+		// Already executed; the backend redelivers until it sees our
+		// result, so the earlier one may have been lost in transit.
+		if previous == nil {
+			return nil
+		}
+		log.Printf("Task %s was redelivered, resending its result", spec.ID)
+		resend := *previous
+		return &resend
+	}
+	defer r.remember(spec.ID, result)
+
+	if err := r.allowed(spec); err != nil {
+		log.Printf("Rejected task %s: %v", spec.ID, err)
+		return r.reject(result, err)
+	}
+
+	log.Printf("Executing task %s (%s)", spec.ID, spec.Type)
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	switch spec.Type {
+	case TypeRunScript:
+		var path string
+		path, err = r.scriptPath(spec.Script)
+		if err == nil {
+			err = r.runCommand(ctx, result, path, spec.Args...)
+		}
+	case TypeRestartService:
+		err = r.runCommand(ctx, result, "systemctl", "restart", spec.Service)
+	case TypeFetchFile:
+		err = r.fetchFile(ctx, spec)
+	case TypeCleanup:
+		var report cleanup.Report
+		report, err = r.cleaner.Clean(ctx, spec.Rule, spec.DryRun)
+		result.Stdout = report.String()
+	}
+
+	result.FinishedAt = time.Now().UTC()
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		log.Printf("Task %s failed: %v", spec.ID, err)
+	} else {
+		result.Status = StatusSucceeded
+	}
+	return result
+}
+
+func (r *Runner) reject(result *Result, err error) *Result {
+	result.Status = StatusRejected
+	result.Error = err.Error()
+	result.ExitCode = -1
+	result.FinishedAt = time.Now().UTC()
+	return result
+}
+
+// markSeen records a task about to execute. If it was seen before, it
+// returns false with the earlier result, or nil while that is pending.
+// Tasks are remembered, also on disk, until they expire, so they cannot
+// run twice.
+func (r *Runner) markSeen(spec *Spec, now time.Time) (bool, *Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, seen := range r.seen {
+		if now.After(seen.until) {
+			delete(r.seen, id)
+			r.seenState.Delete(id)
+		}
+	}
+	if seen, ok := r.seen[spec.ID]; ok {
+		return false, seen.result
+	}
+	until := now.Add(seenRetention)
+	if spec.ExpiresAt.After(until) {
+		until = spec.ExpiresAt
+	}
+	r.seen[spec.ID] = &seenTask{until: until}
+	r.seenState.Put(spec.ID, until)
+	// Written before the task runs, so a crash cannot lead to a rerun.
+	if err := r.seenState.Flush(); err != nil {
+		log.Printf("Error saving seen task %s: %v", spec.ID, err)
+	}
+	return true, nil
+}
+
+func (r *Runner) remember(id string, result *Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if seen, ok := r.seen[id]; ok {
+		stored := *result
+		seen.result = &stored
+	}
+}
+
+// allowed applies the local allowlist, which is the final authority even
+// for correctly signed tasks.
+func (r *Runner) allowed(spec *Spec) error {
+	switch spec.Type {
+	case TypeRunScript:
+		path, err := r.scriptPath(spec.Script)
+		if err != nil {
+			return err
+		}
+		if spec.SHA256 != "" {
+			return verifyFileHash(path, spec.SHA256)
+		}
+	case TypeRestartService:
+		if !r.services[spec.Service] {
+			return fmt.Errorf("service %q is not in the local allowlist", spec.Service)
+		}
+	case TypeFetchFile:
+		if spec.SHA256 == "" {
+			return fmt.Errorf("fetch_file requires a sha256")
+		}
+		if !r.destinationAllowed(spec.Destination) {
+			return fmt.Errorf("destination %q is outside the allowed directories", spec.Destination)
+		}
+	case TypeCleanup:
+		if r.cleaner == nil {
+			return fmt.Errorf("cleanup is not enabled")
+		}
+		if !r.cleaner.HasRule(spec.Rule) {
+			return fmt.Errorf("cleanup rule %q is not configured", spec.Rule)
+		}
+	default:
+		return fmt.Errorf("unknown task type %q", spec.Type)
+	}
+	return nil
+}
+
+func (r *Runner) scriptPath(name string) (string, error) {
+	if path, ok := r.scripts[name]; ok {
+		return path, nil
+	}
+	if r.library != nil {
+		path, _, err := r.library.Lookup(name)
+		return path, err
+	}
+	return "", fmt.Errorf("script %q is not in the local allowlist", name)
+}
+
+func (r *Runner) destinationAllowed(dest string) bool {
+	if !filepath.IsAbs(dest) {
+		return false
+	}
+	dest = filepath.Clean(dest)
+	for _, dir := range r.fetchDirs {
+		dir = filepath.Clean(dir)
+		if strings.HasPrefix(dest, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) runCommand(ctx context.Context, result *Result, name string, args ...string) error {
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", r.timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("exited with status %d", result.ExitCode)
+	}
+	return err
+}
+
+func (r *Runner) fetchFile(ctx context.Context, spec *Spec) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", spec.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", spec.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+
+	dest := filepath.Clean(spec.Destination)
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".sentinel-fetch-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, spec.SHA256) {
+		return fmt.Errorf("checksum mismatch: got %s", sum)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func verifyFileHash(path, expected string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), expected) {
+		return fmt.Errorf("%s does not match the signed checksum", path)
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first maxOutputBytes of a stream and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := maxOutputBytes - b.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.Buffer.Write(p[:remaining])
+			b.truncated = true
+		} else {
+			b.Buffer.Write(p)
+		}
+	} else {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "\n[output truncated]"
+	}
+	return b.Buffer.String()
+}
@@ -0,0 +1,108 @@
+package tasks
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	TypeRunScript      = "run_script"
+	TypeRestartService = "restart_service"
+	TypeFetchFile      = "fetch_file"
+	TypeCleanup        = "cleanup"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusRejected  = "rejected"
+)
+
+// SignedTask is a task as delivered by the backend. Payload is the
+// base64-encoded JSON Spec and Signature an ed25519 signature over the
+// decoded payload bytes.
+type SignedTask struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type Spec struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	HostID      string    `json:"hostId,omitempty"`
+	Script      string    `json:"script,omitempty"`
+	Args        []string  `json:"args,omitempty"`
+	Service     string    `json:"service,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Rule        string    `json:"rule,omitempty"`
+	DryRun      bool      `json:"dryRun,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type Result struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type,omitempty"`
+	Status     string    `json:"status"`
+	ExitCode   int       `json:"exitCode"`
+	Stdout     string    `json:"stdout,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key encoding: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify checks the signature and decodes the spec. It does not apply the
+// local allowlist; that is the runner's job.
+func Verify(task SignedTask, key ed25519.PublicKey, hostID string, now time.Time) (*Spec, error) {
+	payload, err := openEnvelope(task, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(payload, &spec); err != nil {
+		return nil, fmt.Errorf("invalid task payload: %w", err)
+	}
+	if spec.ID == "" {
+		return nil, fmt.Errorf("task has no id")
+	}
+	if spec.HostID != "" && spec.HostID != hostID {
+		return &spec, fmt.Errorf("task is addressed to host %s", spec.HostID)
+	}
+	if spec.ExpiresAt.IsZero() || now.After(spec.ExpiresAt) {
+		return &spec, fmt.Errorf("task expired")
+	}
+	return &spec, nil
+}
+
+// openEnvelope returns the payload bytes once the signature has been verified.
+func openEnvelope(envelope SignedTask, key ed25519.PublicKey) ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload encoding: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(envelope.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+	return payload, nil
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySource derives the 256-bit keys for encrypting local data. A stolen
+// disk or backup stays unreadable only if the key material is not on it:
+// a hardware identifier readable only by root (the DMI product UUID on
+// Linux) or a KeyFile the operator keeps elsewhere, e.g. on a secrets
+// mount. Without either, a random secret in FallbackFile, next to the data,
+// is used; that only protects copies that leave the secret behind. The
+// host ID is never part of a key, so regenerating it keeps local data
+// readable.
+type KeySource struct {
+	KeyFile      string
+	FallbackFile string
+}
+
+// Protected reports whether the keys come from material that is not
+// stored with the agent's data.
+func (s KeySource) Protected() bool {
+	return s.KeyFile != "" || hostKeyMaterial() != ""
+}
+
+// Key derives the key for purpose, which separates keys for different
+// stores.
+func (s KeySource) Key(purpose string) ([]byte, error) {
+	material := hostKeyMaterial()
+	switch {
+	case s.KeyFile != "":
+		data, err := os.ReadFile(s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read crypto.key_file: %w", err)
+		}
+		secret := strings.TrimSpace(string(data))
+		if len(secret) < 16 {
+			return nil, fmt.Errorf("crypto.key_file %s must hold at least 16 characters of secret", s.KeyFile)
+		}
+		material += "|" + secret
+	case material == "":
+		secret, err := loadSecret(s.FallbackFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load host key secret: %w", err)
+		}
+		material = secret
+	}
+	// Pinned to SHA-256 so crypto.hash cannot change existing keys.
+	mac := hmac.New(sha256.New, []byte(material))
+	mac.Write([]byte("sentinel-agent " + purpose))
+	return mac.Sum(nil)[:32], nil
+}
+
+// HostKeySecretFile is where the fallback secret is kept, next to the
+// host ID.
+func HostKeySecretFile(hostIDFile string) string {
+	return filepath.Join(filepath.Dir(hostIDFile), "host-key")
+}
+
+// loadSecret reads the secret at path, creating a random one, readable
+// only by the agent's user, on first use.
+func loadSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", err
+	}
+	encoded := hex.EncodeToString(secret)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(encoded+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return encoded, nil
+}
+
+// Seal encrypts data with AES-256-GCM, prefixing the random nonce.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// Cipher seals and opens data with a fixed key, for stores that take a
+// cipher rather than a key.
+type Cipher []byte
+
+func (key Cipher) Seal(plaintext []byte) ([]byte, error) {
+	return Seal(key, plaintext)
+}
+
+func (key Cipher) Open(sealed []byte) ([]byte, error) {
+	return Open(key, sealed)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
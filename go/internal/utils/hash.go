@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+var hashAlgorithms = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+var hashAlgorithm = crypto.SHA256
+
+// SetHashAlgorithm selects the hash used for host IDs and fingerprints.
+// Checksums defined by the backend protocol (task and script sha256 fields)
+// and local key derivation are unaffected.
+func SetHashAlgorithm(name string) error {
+	h, ok := hashAlgorithms[name]
+	if !ok {
+		return fmt.Errorf("unsupported hash algorithm %q", name)
+	}
+	hashAlgorithm = h
+	return nil
+}
+
+func NewHash() hash.Hash {
+	return hashAlgorithm.New()
+}
+
+func Sum(data []byte) []byte {
+	h := NewHash()
+	h.Write(data)
+	return h.Sum(nil)
+}
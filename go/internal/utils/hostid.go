@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"crypto/sha256"
+	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -49,7 +49,7 @@ func generateHostID() (string, error) {
 
 	data := fmt.Sprintf("%s|%s|sentinel", mac, hostname)
 	
-	hash := sha256.Sum256([]byte(data))
+	hash := Sum([]byte(data))
 	
 	hostID := fmt.Sprintf("host-%s", hex.EncodeToString(hash[:8]))
 	
@@ -61,7 +61,36 @@ func GetMachineFingerprint() string {
 	hostname, _ := os.Hostname()
 	
 	data := fmt.Sprintf("%s|%s", mac, hostname)
-	hash := sha256.Sum256([]byte(data))
+	hash := Sum([]byte(data))
 	
 	return hex.EncodeToString(hash[:16])
 }
+
+// SaveHostID persists a host ID assigned by the backend.
+func SaveHostID(hostIDFile, hostID string) error {
+	if err := os.MkdirAll(filepath.Dir(hostIDFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for host ID file: %w", err)
+	}
+	if err := os.WriteFile(hostIDFile, []byte(hostID), 0644); err != nil {
+		return fmt.Errorf("failed to save host ID: %w", err)
+	}
+	return nil
+}
+
+// RegenerateHostID replaces the stored host ID with a new one. Unlike the
+// initial ID it includes random data, since it is used when the MAC and
+// hostname are shared with another host (e.g. a cloned image).
+func RegenerateHostID(hostIDFile string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	hostname, _ := os.Hostname()
+	data := fmt.Sprintf("%s|%s|%x|sentinel", collector.GetPrimaryMAC(), hostname, nonce)
+	hostID := fmt.Sprintf("host-%s", hex.EncodeToString(Sum([]byte(data))[:8]))
+
+	if err := SaveHostID(hostIDFile, hostID); err != nil {
+		return "", err
+	}
+	return hostID, nil
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// hostKeyMaterial is the DMI product UUID, which lives in firmware and is
+// readable only by root. /etc/machine-id is not used: it is on the disk
+// that the encryption protects. Many ARM boards, containers and VMs have
+// no product UUID.
+func hostKeyMaterial() string {
+	data, err := os.ReadFile("/sys/class/dmi/id/product_uuid")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
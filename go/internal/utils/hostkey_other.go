@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utils
+
+// hostKeyMaterial has no platform source outside Linux; keys then come
+// from crypto.key_file or the fallback secret.
+func hostKeyMaterial() string {
+	return ""
+}
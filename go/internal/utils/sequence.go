@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sequence is a monotonic counter persisted across restarts, so the backend
+// can detect lost heartbeats from gaps.
+type Sequence struct {
+	path string
+
+	mu   sync.Mutex
+	last uint64
+}
+
+func OpenSequence(path string) (*Sequence, error) {
+	s := &Sequence{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read sequence file: %w", err)
+	}
+	if err == nil {
+		s.last, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sequence file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Next returns the next sequence number. The counter is saved before it is
+// handed out so a crash can never reuse a number.
+func (s *Sequence) Next() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.last + 1
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(next, 10)), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, err
+	}
+	s.last = next
+	return next, nil
+}
+
+// NewUUID returns a random RFC 4122 version 4 UUID.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// SequenceFile is the counter's location, kept next to the host ID.
+func SequenceFile(hostIDFile string) string {
+	return filepath.Join(filepath.Dir(hostIDFile), "sequence")
+}